@@ -0,0 +1,358 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/descriptor"
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+var (
+	// ErrUnsupportedScriptType is returned when an account key is for a script type that addresses cannot be generated for
+	// (e.g. multisig Ypub/Zpub) or that the network of the account does not support (e.g. Dogecoin P2WPKH)
+	ErrUnsupportedScriptType = errors.New("Extended key script type is not supported for address generation")
+
+	// purposeScriptTypes maps BIP purposes to the script type of their addresses
+	purposeScriptTypes = map[uint32]keys.ScriptType{
+		keys.BIP44Purpose: keys.ScriptP2PKH,
+		keys.BIP49Purpose: keys.ScriptP2WPKHInP2SH,
+		keys.BIP84Purpose: keys.ScriptP2WPKH,
+		keys.BIP86Purpose: keys.ScriptP2TR,
+	}
+)
+
+// Account is a BIP44, BIP49, BIP84 or BIP86 account whose script type and network are known
+// so addresses can be generated without choosing the address function up front
+type Account struct {
+	// key is the account key (m / purpose' / coin_type' / account') encoded with the P2PKH version of its network
+	// so hdkeychain can neuter it regardless of its SLIP-132 version
+	key        *hdkeychain.ExtendedKey
+	deriver    *keys.AccountDeriver
+	scriptType keys.ScriptType
+	net        network.Network
+	netParam   *chaincfg.Params
+
+	// cashAddrPrefix is set for networks whose P2PKH addresses are CashAddr encoded (Bitcoin Cash)
+	cashAddrPrefix string
+
+	// origin is the master fingerprint and path of key, nil when unknown (accounts created from a bare extended key)
+	origin *descriptor.KeyOrigin
+
+	// chain is the chain addresses are derived from when isChange is false,
+	// the change chain for accounts created from a /1/* descriptor
+	chain keys.AddressType
+}
+
+// NewAccountFromExtendedKey returns an account for any single-sig SLIP-132 extended key (xpub/ypub/zpub, tpub/upub/vpub or private equivalent).
+// Script type and network are inferred from the key version bytes, xpub/tpub keys are treated as P2PKH accounts
+func NewAccountFromExtendedKey(extendedKey string) (*Account, error) {
+	v, isPrivate, err := keys.GetExtendedKeyInfo(extendedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAccount(extendedKey, v.ScriptType, v.Network, isPrivate)
+}
+
+// NewAccountFromExtendedKeyOnNetwork returns an account for a single-sig SLIP-132 extended key on net.
+// Regtest and signet keys share the testnet versions so their network cannot be inferred from the key
+func NewAccountFromExtendedKeyOnNetwork(extendedKey string, net network.Network) (*Account, error) {
+	v, isPrivate, err := keys.GetExtendedKeyInfo(extendedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAccountKeyVersion(extendedKey, v.ScriptType, net); err != nil {
+		return nil, err
+	}
+
+	return newAccount(extendedKey, v.ScriptType, net, isPrivate)
+}
+
+// NewP2TRAccountFromExtendedKey returns a BIP86 Taproot account for an xpub/tpub (or private equivalent) account key.
+// BIP86 keys share their version bytes with BIP44 so the script type cannot be inferred from the key
+func NewP2TRAccountFromExtendedKey(extendedKey string) (*Account, error) {
+	v, isPrivate, err := keys.GetExtendedKeyInfo(extendedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAccountKeyVersion(extendedKey, keys.ScriptP2TR, v.Network); err != nil {
+		return nil, err
+	}
+
+	return newAccount(extendedKey, keys.ScriptP2TR, v.Network, isPrivate)
+}
+
+// NewAccountFromSeed returns the account at accountIndex for a BIP purpose (44, 49, 84 or 86)
+func NewAccountFromSeed(seed []byte, purpose int, accountIndex int, testnet bool) (*Account, error) {
+	return NewAccountFromSeedOnNetwork(seed, purpose, accountIndex, testnetToNetwork(testnet))
+}
+
+// NewAccountFromSeedOnNetwork returns the account at accountIndex for a BIP purpose (44, 49, 84 or 86) on net
+func NewAccountFromSeedOnNetwork(seed []byte, purpose int, accountIndex int, net network.Network) (*Account, error) {
+	p, err := intToUint32(purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	scriptType, ok := purposeScriptTypes[p]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported account purpose %d", purpose)
+	}
+
+	index, err := intToUint32(accountIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAccountFromMaster(m, p, scriptType, index, net)
+}
+
+// newAccountFromMaster derives the account at m / purpose' / coin_type' / accountIndex' of net from a master private key.
+// The coin type is taken from net by keys.GetAccountKeyOnNetwork as networks sharing Bitcoin version bytes (e.g. Bitcoin Cash)
+// would otherwise be derived with the coin type of Bitcoin
+func newAccountFromMaster(m *hdkeychain.ExtendedKey, p uint32, scriptType keys.ScriptType, index uint32, net network.Network) (*Account, error) {
+	def, err := network.GetDefinition(net)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keys.GetAccountKeyOnNetwork(m, p, net, index, true)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := newAccount(key, scriptType, net, true)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := keys.GetMasterFingerprint(m)
+	if err != nil {
+		return nil, err
+	}
+
+	account.origin = &descriptor.KeyOrigin{Fingerprint: fingerprint, Path: keys.NewAccountPath(p, def.CoinType, index)}
+	return account, nil
+}
+
+// newAccount parses an account key after checking it is for a script type addresses can be generated for
+func newAccount(extendedKey string, scriptType keys.ScriptType, net network.Network, isPrivate bool) (*Account, error) {
+	switch scriptType {
+	case keys.ScriptP2PKH, keys.ScriptP2WPKHInP2SH, keys.ScriptP2WPKH, keys.ScriptP2TR:
+	default:
+		return nil, ErrUnsupportedScriptType
+	}
+
+	def, err := network.GetDefinition(net)
+	if err != nil {
+		return nil, err
+	}
+
+	if !def.SupportsScriptType(scriptType) {
+		return nil, ErrUnsupportedScriptType
+	}
+
+	netParam, err := keys.GetChainParams(net)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, err := getVersionedAccountKey(extendedKey, keys.ScriptP2PKH, net, isPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := keys.GetExtendedKeyFromString(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Account{key: k, deriver: keys.NewAccountDeriver(k), scriptType: scriptType, net: net, netParam: netParam, cashAddrPrefix: def.CashAddrPrefix}, nil
+}
+
+// ScriptType returns the output script type of the account addresses
+func (a *Account) ScriptType() keys.ScriptType {
+	return a.scriptType
+}
+
+// IsTestnet returns true if the account generates addresses for a test network (testnet, regtest or signet)
+func (a *Account) IsTestnet() bool {
+	def, err := network.GetDefinition(a.net)
+	return err == nil && def.Testnet
+}
+
+// Network returns the network of the account addresses
+func (a *Account) Network() network.Network {
+	return a.net
+}
+
+// IsPrivate returns true if the account holds the extended private key
+func (a *Account) IsPrivate() bool {
+	return a.key.IsPrivate()
+}
+
+// Address returns the receive address at addressIndex, the address of the descriptor chain for accounts created from a descriptor
+func (a *Account) Address(addressIndex int) (string, error) {
+	return a.address(a.addressChain(false), addressIndex)
+}
+
+// ChangeAddress returns the change address at addressIndex
+func (a *Account) ChangeAddress(addressIndex int) (string, error) {
+	return a.address(keys.ChangeAddress, addressIndex)
+}
+
+// AddressRange returns count consecutive receive or change addresses starting at addressIndex.
+// Keys are derived in one pass from the cached chain key, spread over workers goroutines.
+// Accounts created from a /1/* descriptor return change addresses for both chains
+func (a *Account) AddressRange(addressIndex int, count int, isChange bool, workers int) ([]string, error) {
+	addt := a.addressChain(isChange)
+	index, err := intToUint32(addressIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	ks, err := a.deriver.AddressKeys(addt, index, count, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, len(ks))
+	for i, k := range ks {
+		addresses[i], err = a.encodeAddress(k)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return addresses, nil
+}
+
+// AddressPublicKey returns the hex encoded compressed public key of the address at addressIndex
+func (a *Account) AddressPublicKey(addressIndex int, isChange bool) (string, error) {
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := a.addressKey(addt, addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	pk, err := k.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(pk.SerializeCompressed()), nil
+}
+
+// LegacyAddress returns the base58 P2PKH address at addressIndex, the form Bitcoin Cash addresses had before CashAddr.
+// For other networks it is the same as the P2PKH address
+func (a *Account) LegacyAddress(addressIndex int, isChange bool) (string, error) {
+	if a.scriptType != keys.ScriptP2PKH {
+		return "", ErrUnsupportedScriptType
+	}
+
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := a.addressKey(addt, addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	return getP2PKHAddress(k, a.netParam)
+}
+
+// ExtendedPublicKey returns the account extended public key with the SLIP-132 version of the account script type
+func (a *Account) ExtendedPublicKey() (string, error) {
+	pub, err := a.key.Neuter()
+	if err != nil {
+		return "", err
+	}
+
+	return getVersionedAccountKey(pub.String(), a.scriptType, a.net, false)
+}
+
+// addressChain returns the change chain or the chain receive addresses are derived from
+func (a *Account) addressChain(isChange bool) keys.AddressType {
+	if isChange {
+		return keys.ChangeAddress
+	}
+	return a.chain
+}
+
+// address returns the address of the account script type at addressIndex on the change or external chain
+func (a *Account) address(addt keys.AddressType, addressIndex int) (string, error) {
+	k, err := a.addressKey(addt, addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	return a.encodeAddress(k)
+}
+
+// encodeAddress returns the address of the account script type for an address key
+func (a *Account) encodeAddress(k *hdkeychain.ExtendedKey) (string, error) {
+	switch a.scriptType {
+	case keys.ScriptP2PKH:
+		if a.cashAddrPrefix != "" {
+			return getCashAddress(k, a.cashAddrPrefix)
+		}
+		return getP2PKHAddress(k, a.netParam)
+	case keys.ScriptP2WPKHInP2SH:
+		return getP2SHAddress(k, a.netParam)
+	case keys.ScriptP2WPKH:
+		return getP2WPKHAddress(k, a.netParam)
+	case keys.ScriptP2TR:
+		return getP2TRAddress(k, a.netParam)
+	}
+	return "", ErrUnsupportedScriptType
+}
+
+// addressKey derives the key at addressIndex on the change or external chain
+func (a *Account) addressKey(addt keys.AddressType, addressIndex int) (*hdkeychain.ExtendedKey, error) {
+	index, err := intToUint32(addressIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	if index >= keys.HardenedKeyZeroIndex {
+		return nil, fmt.Errorf("Address index %d is out of range", addressIndex)
+	}
+
+	return a.deriver.AddressKey(addt, index)
+}
@@ -0,0 +1,322 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sanscentral/sanswallet/keys"
+)
+
+const (
+	// BIP84 test vector ref: https://github.com/bitcoin/bips/blob/master/bip-0084.mediawiki#test-vectors
+	testP2WPKH0PubKey = "0330d54fd0dd420a6e5f8d3624f5f3482cae350f79d5f0753bf5beef9c2d91af3c"
+
+	// testBenchmarkRange is the number of addresses generated per benchmark operation
+	testBenchmarkRange = 100
+)
+
+func TestAccountFromExtendedKey(t *testing.T) {
+	accounts := []struct {
+		key        string
+		scriptType keys.ScriptType
+		testnet    bool
+		pub        string
+		addresses  map[int]string
+	}{
+		{testP2PKHPriv, keys.ScriptP2PKH, false, testP2PKHPub, map[int]string{0: testP2PK0, 1: testP2PK1, 10: testP2PK10}},
+		{testP2PKHPub, keys.ScriptP2PKH, false, testP2PKHPub, map[int]string{0: testP2PK0, 1: testP2PK1, 10: testP2PK10}},
+		{testP2SHPriv, keys.ScriptP2WPKHInP2SH, false, testP2SHPub, map[int]string{0: testP2SH0, 1: testP2SH1, 10: testP2SH10}},
+		{testP2SHPub, keys.ScriptP2WPKHInP2SH, false, testP2SHPub, map[int]string{0: testP2SH0, 1: testP2SH1, 10: testP2SH10}},
+		{testP2WPKHPriv, keys.ScriptP2WPKH, false, testP2WPKHPub, map[int]string{0: testP2WPKH0, 1: testP2WPKH1, 10: testP2WPKH10}},
+		{testP2WPKHPub, keys.ScriptP2WPKH, false, testP2WPKHPub, map[int]string{0: testP2WPKH0, 1: testP2WPKH1, 10: testP2WPKH10}},
+		{testTestnetP2SHPriv, keys.ScriptP2WPKHInP2SH, true, testTestnetP2SHPub, map[int]string{0: testTestnetP2SH0, 1: testTestnetP2SH1}},
+		{testTestnetP2WPKHPub, keys.ScriptP2WPKH, true, testTestnetP2WPKHPub, map[int]string{0: testTestnetP2WPKH0, 1: testTestnetP2WPKH1}},
+	}
+
+	for _, a := range accounts {
+		account, err := NewAccountFromExtendedKey(a.key)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		if account.ScriptType() != a.scriptType || account.IsTestnet() != a.testnet {
+			t.Errorf("account for %s detected as %s (testnet %t)", a.key[:4], account.ScriptType(), account.IsTestnet())
+		}
+
+		pub, err := account.ExtendedPublicKey()
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if pub != a.pub {
+			t.Errorf("account extended public key is not expected value want\n%s \ngot \n%s", a.pub, pub)
+		}
+
+		for index, expected := range a.addresses {
+			address, err := account.Address(index)
+			if err != nil {
+				t.Error(err.Error())
+			}
+
+			if address != expected {
+				t.Errorf("%s account address %d is not expected value want %s got %s", a.key[:4], index, expected, address)
+			}
+		}
+	}
+}
+
+func TestAccountFromSeed(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	for purpose, pub := range map[int]string{44: testP2PKHPub, 49: testP2SHPub, 84: testP2WPKHPub} {
+		account, err := NewAccountFromSeed(seed, purpose, 0, testIsTestnet)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		if !account.IsPrivate() {
+			t.Errorf("account for purpose %d from seed should hold the private key", purpose)
+		}
+
+		k, err := account.ExtendedPublicKey()
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if k != pub {
+			t.Errorf("account for purpose %d extended public key is not expected value want\n%s \ngot \n%s", purpose, pub, k)
+		}
+
+		// Change addresses must match the existing address functions
+		change, err := account.ChangeAddress(3)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		var expected string
+		switch purpose {
+		case 44:
+			expected, err = GetP2PKHAddressForIndex(pub, 3, true, testIsTestnet)
+		case 49:
+			expected, err = GetP2SHAddressForIndex(pub, 3, true, testIsTestnet)
+		case 84:
+			expected, err = GetP2WPKHAddressForIndex(pub, 3, true, testIsTestnet)
+		}
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if change != expected {
+			t.Errorf("account for purpose %d change address is not expected value want %s got %s", purpose, expected, change)
+		}
+	}
+
+	account, err := NewAccountFromSeed(seed, 84, 0, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pk, err := account.AddressPublicKey(0, false)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if pk != testP2WPKH0PubKey {
+		t.Errorf("account address public key is not expected value want %s got %s", testP2WPKH0PubKey, pk)
+	}
+
+	account, err = NewAccountFromSeed(seed, 86, 0, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a, _ := account.Address(1); a != testP2TR1 {
+		t.Errorf("account for purpose 86 address is not expected value want %s got %s", testP2TR1, a)
+	}
+
+	if k, _ := account.ExtendedPublicKey(); k != testP2TRPub {
+		t.Errorf("account for purpose 86 extended public key is not expected value want %s got %s", testP2TRPub, k)
+	}
+
+	if _, err := NewAccountFromSeed(seed, 45, 0, testIsTestnet); err == nil {
+		t.Error("unsupported purpose did not fail where expected")
+	}
+}
+
+func TestP2TRAccountFromExtendedKey(t *testing.T) {
+	account, err := NewP2TRAccountFromExtendedKey(testP2TRPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if account.ScriptType() != keys.ScriptP2TR {
+		t.Errorf("account script type is %s want P2TR", account.ScriptType())
+	}
+
+	addresses, err := account.AddressRange(0, 2, false, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if addresses[0] != testP2TR0 || addresses[1] != testP2TR1 {
+		t.Errorf("P2TR account addresses are not expected value, got %v", addresses)
+	}
+
+	change, err := account.ChangeAddress(0)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if change != testP2TRChange0 {
+		t.Errorf("P2TR account change address is not expected value want %s got %s", testP2TRChange0, change)
+	}
+
+	if _, err := NewP2TRAccountFromExtendedKey(testP2WPKHPub); err == nil {
+		t.Error("zpub did not fail for P2TR account where expected")
+	}
+}
+
+func TestAccountAddressRange(t *testing.T) {
+	for _, pub := range []string{testP2PKHPub, testP2SHPub, testP2WPKHPub} {
+		account, err := NewAccountFromExtendedKey(pub)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		for _, isChange := range []bool{false, true} {
+			addresses, err := account.AddressRange(10, 25, isChange, 4)
+			if err != nil {
+				t.Error(err.Error())
+				continue
+			}
+
+			if len(addresses) != 25 {
+				t.Errorf("address range returned %d addresses want 25", len(addresses))
+				continue
+			}
+
+			for i, a := range addresses {
+				var expected string
+				if isChange {
+					expected, err = account.ChangeAddress(10 + i)
+				} else {
+					expected, err = account.Address(10 + i)
+				}
+				if err != nil {
+					t.Error(err.Error())
+				}
+
+				if a != expected {
+					t.Errorf("address range entry %d is not expected value want %s got %s", 10+i, expected, a)
+				}
+			}
+		}
+	}
+
+	account, err := NewAccountFromExtendedKey(testP2WPKHPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := account.AddressRange(-1, 2, false, 1); err == nil {
+		t.Error("negative start index did not fail where expected")
+	}
+
+	if _, err := account.AddressRange(1<<31-1, 2, false, 1); err == nil {
+		t.Error("range into hardened indexes did not fail where expected")
+	}
+}
+
+func BenchmarkGetP2WPKHAddressForIndex(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		for i := 0; i < testBenchmarkRange; i++ {
+			if _, err := GetP2WPKHAddressForIndex(testP2WPKHPub, i, false, testIsTestnet); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkAccountAddressRange(b *testing.B) {
+	benchmarkAccountAddressRange(b, 1)
+}
+
+func BenchmarkAccountAddressRangeParallel(b *testing.B) {
+	benchmarkAccountAddressRange(b, 4)
+}
+
+func benchmarkAccountAddressRange(b *testing.B, workers int) {
+	account, err := NewAccountFromExtendedKey(testP2WPKHPub)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := account.AddressRange(0, testBenchmarkRange, false, workers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAccountMalformedInput(t *testing.T) {
+	invalid := []string{
+		"",
+		"x",
+		"xpub",
+		"zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3",
+		testP2WPKHPub[:len(testP2WPKHPub)-1] + "t",
+		"not a key at all 0OIl",
+	}
+
+	for _, k := range invalid {
+		if _, err := NewAccountFromExtendedKey(k); err == nil {
+			t.Errorf("malformed key %q did not fail where expected", k)
+		}
+	}
+
+	// Multisig keys carry a valid SLIP-132 version but cannot produce single-sig addresses
+	zpub, err := ConvertExtendedKey(testP2WPKHPub, "Zpub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewAccountFromExtendedKey(zpub); err != ErrUnsupportedScriptType {
+		t.Error("multisig key did not fail where expected")
+	}
+
+	account, err := NewAccountFromExtendedKey(testP2WPKHPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, index := range []int{-1, 1 << 31} {
+		if _, err := account.Address(index); err == nil {
+			t.Errorf("address index %d did not fail where expected", index)
+		}
+	}
+}
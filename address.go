@@ -0,0 +1,232 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil/base58"
+
+	"github.com/sanscentral/sanswallet/cashaddr"
+	"github.com/sanscentral/sanswallet/network"
+	"github.com/sanscentral/sanswallet/segwit"
+)
+
+// Address types returned by DecodeAddress
+const (
+	AddressP2PKH  = "P2PKH"
+	AddressP2SH   = "P2SH"
+	AddressP2WPKH = "P2WPKH"
+	AddressP2WSH  = "P2WSH"
+	AddressP2TR   = "P2TR"
+
+	// AddressWitnessUnknown is a valid segwit address of a witness version or program length without defined semantics
+	AddressWitnessUnknown = "WITNESS_UNKNOWN"
+)
+
+// hashLength is the length of the hash in base58 and CashAddr P2PKH/P2SH addresses
+const hashLength = 20
+
+var (
+	// ErrWrongNetwork is returned when an address is valid but for a network other than the expected one
+	ErrWrongNetwork = errors.New("Address is for a different network")
+
+	// ErrUnknownAddressVersion is returned when a base58 address has a version byte no registered network uses
+	ErrUnknownAddressVersion = errors.New("Address version byte is unknown")
+
+	// ErrInvalidAddressLength is returned when a base58 or CashAddr address does not hold a 20 byte hash
+	ErrInvalidAddressLength = errors.New("Address hash has an invalid length")
+)
+
+// AddressInfo describes a decoded address
+type AddressInfo struct {
+	// Type is the output type (P2PKH, P2SH, P2WPKH, P2WSH, P2TR or WITNESS_UNKNOWN)
+	Type string
+
+	// WitnessVersion is the segwit version, -1 for base58 and CashAddr addresses
+	WitnessVersion int
+
+	// Program is the hex encoded witness program, or the key/script hash of base58 and CashAddr addresses
+	Program string
+
+	// ScriptPubKey is the hex encoded output script paying to the address
+	ScriptPubKey string
+}
+
+// DecodeAddress decodes and validates a mainnet or testnet address
+func DecodeAddress(address string, testnet bool) (*AddressInfo, error) {
+	return DecodeAddressOnNetwork(address, testnetToNetwork(testnet))
+}
+
+// DecodeAddressOnNetwork decodes and validates a base58, segwit (bech32/bech32m) or CashAddr address of net.
+// Errors are those of the failing step: base58.ErrChecksum, segwit.ErrInvalidChecksum, segwit.ErrMixedCase, segwit.ErrWrongEncoding
+// (bech32 used for version 1+ or bech32m for version 0), ErrWrongNetwork, ...
+func DecodeAddressOnNetwork(address string, net network.Network) (*AddressInfo, error) {
+	def, err := network.GetDefinition(net)
+	if err != nil {
+		return nil, err
+	}
+
+	address = strings.TrimSpace(address)
+	if isCashAddress(address, def) {
+		return decodeCashAddress(address, def)
+	}
+
+	if hrp, ok := segwitPrefix(address); ok {
+		if hrp != def.Bech32HRP {
+			return nil, ErrWrongNetwork
+		}
+		return decodeSegwitAddress(address)
+	}
+	return decodeBase58Address(address, def)
+}
+
+// isCashAddress reports whether address is prefixed CashAddr, or unprefixed CashAddr on a CashAddr network.
+// Unprefixed CashAddr payloads start with q (P2KH) or p (P2SH), which base58 addresses of such networks never do
+func isCashAddress(address string, def *network.Definition) bool {
+	if strings.Contains(address, ":") {
+		return true
+	}
+	return def.CashAddrPrefix != "" && address != "" && strings.IndexByte("qpQP", address[0]) >= 0
+}
+
+// segwitPrefix returns the human readable part of an address when it is the segwit prefix of a registered network
+func segwitPrefix(address string) (string, bool) {
+	lower := strings.ToLower(address)
+	pos := strings.LastIndexByte(lower, '1')
+	if pos < 1 {
+		return "", false
+	}
+
+	hrp := lower[:pos]
+	for _, n := range network.Networks() {
+		if def, err := network.GetDefinition(n); err == nil && def.Bech32HRP != "" && def.Bech32HRP == hrp {
+			return hrp, true
+		}
+	}
+	return "", false
+}
+
+// decodeSegwitAddress decodes a segwit address whose prefix has been checked
+func decodeSegwitAddress(address string) (*AddressInfo, error) {
+	_, version, program, err := segwit.DecodeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addressType := AddressWitnessUnknown
+	switch {
+	case version == 0 && len(program) == 20:
+		addressType = AddressP2WPKH
+	case version == 0 && len(program) == 32:
+		addressType = AddressP2WSH
+	case version == segwit.TaprootWitnessVersion && len(program) == 32:
+		addressType = AddressP2TR
+	}
+
+	op := byte(txscript.OP_0)
+	if version > 0 {
+		op = txscript.OP_1 + version - 1
+	}
+
+	script, err := txscript.NewScriptBuilder().AddOp(op).AddData(program).Script()
+	if err != nil {
+		return nil, err
+	}
+	return newAddressInfo(addressType, int(version), program, script), nil
+}
+
+// decodeBase58Address decodes a base58check P2PKH or P2SH address
+func decodeBase58Address(address string, def *network.Definition) (*AddressInfo, error) {
+	hash, version, err := base58.CheckDecode(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hash) != hashLength {
+		return nil, ErrInvalidAddressLength
+	}
+
+	switch version {
+	case def.PubKeyHashAddrID:
+		return hashAddressInfo(AddressP2PKH, hash)
+	case def.ScriptHashAddrID:
+		return hashAddressInfo(AddressP2SH, hash)
+	}
+
+	for _, n := range network.Networks() {
+		if d, err := network.GetDefinition(n); err == nil && (d.PubKeyHashAddrID == version || d.ScriptHashAddrID == version) {
+			return nil, ErrWrongNetwork
+		}
+	}
+	return nil, ErrUnknownAddressVersion
+}
+
+// decodeCashAddress decodes a prefixed CashAddr P2PKH or P2SH address
+func decodeCashAddress(address string, def *network.Definition) (*AddressInfo, error) {
+	prefix, addrType, hash, err := cashaddr.Decode(address, def.CashAddrPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if prefix != def.CashAddrPrefix {
+		return nil, ErrWrongNetwork
+	}
+
+	if len(hash) != hashLength {
+		return nil, ErrInvalidAddressLength
+	}
+
+	switch addrType {
+	case cashaddr.P2KH:
+		return hashAddressInfo(AddressP2PKH, hash)
+	case cashaddr.P2SH:
+		return hashAddressInfo(AddressP2SH, hash)
+	}
+	return nil, cashaddr.ErrInvalidAddressType
+}
+
+// hashAddressInfo returns the info of a P2PKH or P2SH address
+func hashAddressInfo(addressType string, hash []byte) (*AddressInfo, error) {
+	b := txscript.NewScriptBuilder()
+	if addressType == AddressP2PKH {
+		b.AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).AddData(hash).AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG)
+	} else {
+		b.AddOp(txscript.OP_HASH160).AddData(hash).AddOp(txscript.OP_EQUAL)
+	}
+
+	script, err := b.Script()
+	if err != nil {
+		return nil, err
+	}
+	return newAddressInfo(addressType, -1, hash, script), nil
+}
+
+// newAddressInfo returns an AddressInfo with hex encoded program and script
+func newAddressInfo(addressType string, version int, program []byte, script []byte) *AddressInfo {
+	return &AddressInfo{
+		Type:           addressType,
+		WitnessVersion: version,
+		Program:        hex.EncodeToString(program),
+		ScriptPubKey:   hex.EncodeToString(script),
+	}
+}
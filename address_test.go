@@ -0,0 +1,125 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+
+	"github.com/sanscentral/sanswallet/network"
+	"github.com/sanscentral/sanswallet/segwit"
+)
+
+const (
+	// P2WSH and unknown witness version ref: https://github.com/bitcoin/bips/blob/master/bip-0350.mediawiki#test-vectors
+	testP2WSHAddress      = "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3"
+	testWitnessV16Address = "BC1SW50QGDZ25J"
+	testBech32mV0Address  = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh"
+)
+
+var testP2TR0Program = []byte{
+	0xa6, 0x08, 0x69, 0xf0, 0xdb, 0xcf, 0x1d, 0xc6, 0x59, 0xc9, 0xce, 0xcb, 0xaf, 0x80, 0x50, 0x13,
+	0x5e, 0xa9, 0xe8, 0xcd, 0xc4, 0x87, 0x05, 0x3f, 0x1d, 0xc6, 0x88, 0x09, 0x49, 0xdc, 0x68, 0x4c,
+}
+
+func TestDecodeAddress(t *testing.T) {
+	addresses := []struct {
+		address      string
+		net          network.Network
+		addressType  string
+		version      int
+		program      string
+		scriptPubKey string
+	}{
+		{testP2PK0, network.BTCMainnet, AddressP2PKH, -1, "d986ed01b7a22225a70edbf2ba7cfb63a15cb3aa", "76a914d986ed01b7a22225a70edbf2ba7cfb63a15cb3aa88ac"},
+		{testP2SH0, network.BTCMainnet, AddressP2SH, -1, "3fb6e95812e57bb4691f9a4a628862a61a4f769b", "a9143fb6e95812e57bb4691f9a4a628862a61a4f769b87"},
+		{testP2WPKH0, network.BTCMainnet, AddressP2WPKH, 0, "c0cebcd6c3d3ca8c75dc5ec62ebe55330ef910e2", "0014c0cebcd6c3d3ca8c75dc5ec62ebe55330ef910e2"},
+		{strings.ToUpper(testP2WPKH0), network.BTCMainnet, AddressP2WPKH, 0, "c0cebcd6c3d3ca8c75dc5ec62ebe55330ef910e2", "0014c0cebcd6c3d3ca8c75dc5ec62ebe55330ef910e2"},
+		{testP2WSHAddress, network.BTCMainnet, AddressP2WSH, 0, "1863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262", "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{testP2TR0, network.BTCMainnet, AddressP2TR, 1, "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c", "5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c"},
+		{testWitnessV16Address, network.BTCMainnet, AddressWitnessUnknown, 16, "751e", "6002751e"},
+		{testTestnetP2SH0, network.BTCTestnet, AddressP2SH, -1, "3fb6e95812e57bb4691f9a4a628862a61a4f769b", "a9143fb6e95812e57bb4691f9a4a628862a61a4f769b87"},
+		{testTestnetP2WPKH0, network.BTCSignet, AddressP2WPKH, 0, "c0cebcd6c3d3ca8c75dc5ec62ebe55330ef910e2", "0014c0cebcd6c3d3ca8c75dc5ec62ebe55330ef910e2"},
+		{testRegtestP2TR0, network.BTCRegtest, AddressP2TR, 1, "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c", "5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c"},
+		{testBCH0, network.BCHMainnet, AddressP2PKH, -1, "086a977c7dad32a56996af2ce90a727238d48998", "76a914086a977c7dad32a56996af2ce90a727238d4899888ac"},
+		{strings.TrimPrefix(testBCH0, "bitcoincash:"), network.BCHMainnet, AddressP2PKH, -1, "086a977c7dad32a56996af2ce90a727238d48998", "76a914086a977c7dad32a56996af2ce90a727238d4899888ac"},
+		{testBCHLegacy0, network.BCHMainnet, AddressP2PKH, -1, "086a977c7dad32a56996af2ce90a727238d48998", "76a914086a977c7dad32a56996af2ce90a727238d4899888ac"},
+	}
+
+	for _, a := range addresses {
+		info, err := DecodeAddressOnNetwork(a.address, a.net)
+		if err != nil {
+			t.Errorf("%s did not decode: %s", a.address, err.Error())
+			continue
+		}
+
+		if info.Type != a.addressType || info.WitnessVersion != a.version {
+			t.Errorf("%s decoded as %s version %d want %s version %d", a.address, info.Type, info.WitnessVersion, a.addressType, a.version)
+		}
+
+		if info.Program != a.program {
+			t.Errorf("%s program is not expected value want %s got %s", a.address, a.program, info.Program)
+		}
+
+		if info.ScriptPubKey != a.scriptPubKey {
+			t.Errorf("%s scriptPubKey is not expected value want %s got %s", a.address, a.scriptPubKey, info.ScriptPubKey)
+		}
+	}
+}
+
+func TestDecodeAddressErrors(t *testing.T) {
+	// Taproot program of testP2TR0 with a Bech32 rather than Bech32m checksum
+	data, err := segwit.ConvertBits(testP2TR0Program, 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bech32Taproot, err := segwit.Encode("bc", append([]byte{segwit.TaprootWitnessVersion}, data...), segwit.Bech32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []struct {
+		address string
+		testnet bool
+		err     error
+	}{
+		{testP2PK0[:len(testP2PK0)-1] + "b", false, base58.ErrChecksum},
+		{"", false, base58.ErrInvalidFormat},
+		{testP2WPKH0[:len(testP2WPKH0)-1] + "q", false, segwit.ErrInvalidChecksum},
+		{"bc1qCR8te4kr609gcawutmrza0j4xv80jy8z306fyu", false, segwit.ErrMixedCase},
+		{bech32Taproot, false, segwit.ErrWrongEncoding},
+		{testBech32mV0Address, false, segwit.ErrWrongEncoding},
+		{testP2WPKH0, true, ErrWrongNetwork},
+		{testTestnetP2WPKH0, false, ErrWrongNetwork},
+		{testP2SH0, true, ErrWrongNetwork},
+		{testTestnetP2SH0, false, ErrWrongNetwork},
+		{testLTCP2PKH0, false, ErrWrongNetwork},
+		{testLTCP2WPKH0, false, ErrWrongNetwork},
+		{testBCH0, false, ErrWrongNetwork},
+	}
+
+	for _, a := range invalid {
+		if _, err := DecodeAddress(a.address, a.testnet); err != a.err {
+			t.Errorf("%q (testnet %t) returned error %v want %v", a.address, a.testnet, err, a.err)
+		}
+	}
+}
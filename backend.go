@@ -0,0 +1,56 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"sync"
+)
+
+// MemoryChainBackend is a ChainBackend holding address history in memory, for tests and offline use
+type MemoryChainBackend struct {
+	mu      sync.RWMutex
+	history map[string][]string
+}
+
+// NewMemoryChainBackend returns an empty in-memory chain backend
+func NewMemoryChainBackend() *MemoryChainBackend {
+	return &MemoryChainBackend{history: make(map[string][]string)}
+}
+
+// AddTransaction records a transaction in the history of address, adding the same transaction twice has no effect
+func (b *MemoryChainBackend) AddTransaction(address string, txid string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, id := range b.history[address] {
+		if id == txid {
+			return
+		}
+	}
+	b.history[address] = append(b.history[address], txid)
+}
+
+// AddressHistory returns the transactions recorded for address
+func (b *MemoryChainBackend) AddressHistory(address string) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	txids := b.history[address]
+	return append([]string(nil), txids...), nil
+}
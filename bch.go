@@ -0,0 +1,77 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/cashaddr"
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// GetExtPrvForBCHAccount returns extended private key for Bitcoin Cash BIP44 account (m/44'/145'/account')
+func GetExtPrvForBCHAccount(seed []byte, accountIndex int) (string, error) {
+	return GetExtPrvForP2PKHAccountOnNetwork(seed, accountIndex, network.BCHMainnet)
+}
+
+// GetExtPubForBCHAccount returns extended public key for Bitcoin Cash BIP44 account (m/44'/145'/account')
+func GetExtPubForBCHAccount(seed []byte, accountIndex int) (string, error) {
+	return GetExtPubForP2PKHAccountOnNetwork(seed, accountIndex, network.BCHMainnet)
+}
+
+// GetBCHAddressForIndex returns CashAddr address (bitcoincash:q...) for Bitcoin Cash account extended key at given index
+func GetBCHAddressForIndex(accountKey string, addressIndex int, isChange bool) (string, error) {
+	if err := checkAccountKeyVersion(accountKey, keys.ScriptP2PKH, network.BCHMainnet); err != nil {
+		return "", err
+	}
+
+	index, err := intToUint32(addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := keys.GetAccountAddressKey(accountKey, addt, index)
+	if err != nil {
+		return "", err
+	}
+
+	return getCashAddress(k, cashaddr.MainnetPrefix)
+}
+
+// GetBCHLegacyAddressForIndex returns legacy base58 address ('1' prefixed) for Bitcoin Cash account extended key at given index
+func GetBCHLegacyAddressForIndex(accountKey string, addressIndex int, isChange bool) (string, error) {
+	return GetP2PKHAddressForIndexOnNetwork(accountKey, addressIndex, isChange, network.BCHMainnet)
+}
+
+// getCashAddress returns the CashAddr P2KH address of an address key
+func getCashAddress(k *hdkeychain.ExtendedKey, prefix string) (string, error) {
+	pk, err := k.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+
+	return cashaddr.Encode(prefix, cashaddr.P2KH, btcutil.Hash160(pk.SerializeCompressed()))
+}
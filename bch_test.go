@@ -0,0 +1,84 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+const (
+	// Bitcoin Cash BIP44 (m/44'/145'/0') ref: https://iancoleman.io/bip39/
+	testBCH0       = "bitcoincash:qqyx49mu0kkn9ftfj6hje6g2wfer34yfnq5tahq3q6"
+	testBCHLegacy0 = "1mW6fDEMjKrDHvLvoEsaeLxSCzZBf3Bfg"
+)
+
+func TestBCHAddressGeneration(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	pub, err := GetExtPubForBCHAccount(seed, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Coin type 145 must give a different account than Bitcoin
+	if pub == testP2PKHPub {
+		t.Error("Bitcoin Cash account key is the Bitcoin account key")
+	}
+
+	address, err := GetBCHAddressForIndex(pub, 0, testIsChangeAddress)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if address != testBCH0 {
+		t.Errorf("BCH address is not expected value want %s got %s", testBCH0, address)
+	}
+
+	legacy, err := GetBCHLegacyAddressForIndex(pub, 0, testIsChangeAddress)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if legacy != testBCHLegacy0 {
+		t.Errorf("BCH legacy address is not expected value want %s got %s", testBCHLegacy0, legacy)
+	}
+
+	account, err := NewAccountFromSeedOnNetwork(seed, 44, 0, network.BCHMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if address, _ := account.Address(0); address != testBCH0 {
+		t.Errorf("BCH account address is not expected value want %s got %s", testBCH0, address)
+	}
+
+	if legacy, _ := account.LegacyAddress(0, false); legacy != testBCHLegacy0 {
+		t.Errorf("BCH account legacy address is not expected value want %s got %s", testBCHLegacy0, legacy)
+	}
+
+	if _, err := GetBCHAddressForIndex(testP2WPKHPub, 0, false); err == nil {
+		t.Error("zpub key did not fail for BCH address where expected")
+	}
+}
@@ -0,0 +1,158 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"crypto/rand"
+
+	"github.com/btcsuite/btcutil"
+
+	"github.com/sanscentral/sanswallet/bip38"
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// GetBIP38ForIndex returns the BIP38 encrypted private key of the address at given index for an account extended private key
+func GetBIP38ForIndex(accountKey string, addressIndex int, isChange bool, passphrase string, testnet bool) (string, error) {
+	return GetBIP38ForIndexOnNetwork(accountKey, addressIndex, isChange, passphrase, testnetToNetwork(testnet))
+}
+
+// GetBIP38ForIndexOnNetwork returns the BIP38 encrypted private key of the address at given index for an account extended private key on net
+func GetBIP38ForIndexOnNetwork(accountKey string, addressIndex int, isChange bool, passphrase string, net network.Network) (string, error) {
+	wif, err := GetWIFForIndexOnNetwork(accountKey, addressIndex, isChange, true, net)
+	if err != nil {
+		return "", err
+	}
+
+	k, err := NewImportedKeyFromWIFOnNetwork(wif, net)
+	if err != nil {
+		return "", err
+	}
+	return k.BIP38(passphrase)
+}
+
+// AddressBIP38 returns the BIP38 encrypted private key of the address at addressIndex, the account must hold the extended private key
+func (a *Account) AddressBIP38(addressIndex int, isChange bool, passphrase string) (string, error) {
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := a.addressKey(addt, addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	priv, err := k.ECPrivKey()
+	if err != nil {
+		return "", err
+	}
+	return bip38.Encrypt(priv, passphrase, true, a.netParam)
+}
+
+// NewImportedKeyFromBIP38 returns a standalone key for a mainnet or testnet BIP38 encrypted private key
+func NewImportedKeyFromBIP38(encrypted string, passphrase string, testnet bool) (*ImportedKey, error) {
+	return NewImportedKeyFromBIP38OnNetwork(encrypted, passphrase, testnetToNetwork(testnet))
+}
+
+// NewImportedKeyFromBIP38OnNetwork returns a standalone key for a BIP38 encrypted private key of net, with or without EC multiplication
+func NewImportedKeyFromBIP38OnNetwork(encrypted string, passphrase string, net network.Network) (*ImportedKey, error) {
+	netParam, err := keys.GetChainParams(net)
+	if err != nil {
+		return nil, err
+	}
+
+	priv, compressed, err := bip38.Decrypt(encrypted, passphrase, netParam)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := btcutil.NewWIF(priv, netParam, compressed)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportedKey{wif: w, netParam: netParam}, nil
+}
+
+// BIP38 returns the key encrypted with passphrase (BIP38 without EC multiplication)
+func (k *ImportedKey) BIP38(passphrase string) (string, error) {
+	return bip38.Encrypt(k.wif.PrivKey, passphrase, k.wif.CompressPubKey, k.netParam)
+}
+
+// NewBIP38IntermediateCode returns a random intermediate passphrase code that a third party can generate
+// BIP38 encrypted keys for without learning the passphrase or the private keys
+func NewBIP38IntermediateCode(passphrase string) (string, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return bip38.NewIntermediateCode(passphrase, salt)
+}
+
+// NewBIP38IntermediateCodeWithLot returns a random intermediate passphrase code whose keys carry a lot and sequence number
+func NewBIP38IntermediateCodeWithLot(passphrase string, lot int, sequence int) (string, error) {
+	l, err := intToUint32(lot)
+	if err != nil {
+		return "", err
+	}
+
+	s, err := intToUint32(sequence)
+	if err != nil {
+		return "", err
+	}
+
+	salt := make([]byte, 4)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return bip38.NewIntermediateCodeWithLot(passphrase, salt, l, s)
+}
+
+// GenerateBIP38Key returns a new BIP38 encrypted key, its address and confirmation code for an intermediate passphrase code
+func GenerateBIP38Key(intermediate string, compressed bool, testnet bool) (*bip38.GeneratedKey, error) {
+	return GenerateBIP38KeyOnNetwork(intermediate, compressed, testnetToNetwork(testnet))
+}
+
+// GenerateBIP38KeyOnNetwork returns a new BIP38 encrypted key, its address and confirmation code for an intermediate passphrase code on net
+func GenerateBIP38KeyOnNetwork(intermediate string, compressed bool, net network.Network) (*bip38.GeneratedKey, error) {
+	netParam, err := keys.GetChainParams(net)
+	if err != nil {
+		return nil, err
+	}
+
+	seedb := make([]byte, 24)
+	if _, err := rand.Read(seedb); err != nil {
+		return nil, err
+	}
+	return bip38.GenerateEncryptedKey(intermediate, seedb, compressed, netParam)
+}
+
+// VerifyBIP38ConfirmationCode returns the address of a generated key after checking its confirmation code against the passphrase
+func VerifyBIP38ConfirmationCode(code string, passphrase string, testnet bool) (string, error) {
+	return VerifyBIP38ConfirmationCodeOnNetwork(code, passphrase, testnetToNetwork(testnet))
+}
+
+// VerifyBIP38ConfirmationCodeOnNetwork returns the address of a generated key after checking its confirmation code against the passphrase on net
+func VerifyBIP38ConfirmationCodeOnNetwork(code string, passphrase string, net network.Network) (string, error) {
+	netParam, err := keys.GetChainParams(net)
+	if err != nil {
+		return "", err
+	}
+	return bip38.VerifyConfirmationCode(code, passphrase, netParam)
+}
@@ -0,0 +1,508 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package bip38
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// encryptedKeyLength is the length of a decoded encrypted key without its checksum
+	encryptedKeyLength = 39
+
+	// checksumLength is the length of the double SHA256 base58check checksum
+	checksumLength = 4
+
+	// flagCompressed marks keys whose address uses the compressed public key
+	flagCompressed = 0x20
+
+	// flagNonECMultiply is set on keys encrypted without EC multiplication (both of its bits are always set together)
+	flagNonECMultiply = 0xc0
+
+	// flagLotSequence marks EC multiplied keys whose owner entropy carries a lot and sequence number
+	flagLotSequence = 0x04
+
+	// MaxLot and MaxSequence are the largest lot and sequence numbers of an intermediate code
+	MaxLot      = 1<<20 - 1
+	MaxSequence = 1<<12 - 1
+)
+
+// scrypt parameters of the passphrase (N=16384, r=8, p=8) and of the EC multiply address hash (N=1024, r=1, p=1)
+const (
+	scryptN, scryptR, scryptP                = 16384, 8, 8
+	scryptPointN, scryptPointR, scryptPointP = 1024, 1, 1
+)
+
+var (
+	// prefixNonECMultiply and prefixECMultiply start encrypted keys (6P)
+	prefixNonECMultiply = []byte{0x01, 0x42}
+	prefixECMultiply    = []byte{0x01, 0x43}
+
+	// magicLotSequence and magicNoLotSequence start intermediate codes (passphrase...)
+	magicLotSequence   = []byte{0x2c, 0xe9, 0xb3, 0xe1, 0xff, 0x39, 0xe2, 0x51}
+	magicNoLotSequence = []byte{0x2c, 0xe9, 0xb3, 0xe1, 0xff, 0x39, 0xe2, 0x53}
+
+	// prefixConfirmation starts confirmation codes (cfrm38)
+	prefixConfirmation = []byte{0x64, 0x3b, 0xf6, 0xa8, 0x9a}
+)
+
+var (
+	// ErrInvalidEncryptedKey is returned when a string is not a base58check encoded BIP38 key
+	ErrInvalidEncryptedKey = errors.New("Not a valid BIP38 encrypted private key")
+
+	// ErrWrongPassphrase is returned when the decrypted key does not match the address hash of the encrypted key
+	ErrWrongPassphrase = errors.New("BIP38 passphrase is incorrect")
+
+	// ErrInvalidIntermediateCode is returned when a string is not a base58check encoded intermediate code
+	ErrInvalidIntermediateCode = errors.New("Not a valid BIP38 intermediate passphrase code")
+
+	// ErrInvalidConfirmationCode is returned when a string is not a valid confirmation code or does not match the passphrase
+	ErrInvalidConfirmationCode = errors.New("BIP38 confirmation code is invalid")
+
+	// ErrInvalidOwnerSalt is returned when an owner salt is not 8 bytes (4 bytes with a lot and sequence)
+	ErrInvalidOwnerSalt = errors.New("BIP38 owner salt must be 8 bytes, or 4 bytes with a lot and sequence number")
+
+	// ErrInvalidLotSequence is returned when a lot or sequence number does not fit the owner entropy
+	ErrInvalidLotSequence = errors.New("BIP38 lot must be at most 1048575 and sequence at most 4095")
+
+	// ErrInvalidSeed is returned when seedb is not 24 bytes or its factor is not a valid private key
+	ErrInvalidSeed = errors.New("BIP38 seedb must be 24 bytes giving a valid factor")
+)
+
+// GeneratedKey is an EC multiplied key generated from an intermediate code without knowing the passphrase
+type GeneratedKey struct {
+	// EncryptedKey is the BIP38 encrypted private key (6P...)
+	EncryptedKey string
+
+	// ConfirmationCode proves to the passphrase owner that EncryptedKey belongs to Address (cfrm38...)
+	ConfirmationCode string
+
+	// Address is the P2PKH address of the key
+	Address string
+}
+
+// Encrypt returns the BIP38 encryption of a private key without EC multiplication (6PR... or 6PY... when compressed)
+func Encrypt(priv *btcec.PrivateKey, passphrase string, compressed bool, netParam *chaincfg.Params) (string, error) {
+	pub := (*btcec.PublicKey)(&priv.PublicKey)
+	addrHash, err := addressHash(pub, compressed, netParam)
+	if err != nil {
+		return "", err
+	}
+
+	derived, err := scrypt.Key(normalize(passphrase), addrHash, scryptN, scryptR, scryptP, 64)
+	if err != nil {
+		return "", err
+	}
+
+	flag := byte(flagNonECMultiply)
+	if compressed {
+		flag |= flagCompressed
+	}
+
+	key := paddedKey(priv.D)
+	encrypted, err := encryptHalves(key, derived)
+	if err != nil {
+		return "", err
+	}
+
+	payload := append(append(append([]byte{}, prefixNonECMultiply...), flag), addrHash...)
+	return checkEncode(append(payload, encrypted...)), nil
+}
+
+// Decrypt returns the private key of a BIP38 encrypted key, with or without EC multiplication, and whether its address
+// uses the compressed public key
+func Decrypt(encrypted string, passphrase string, netParam *chaincfg.Params) (*btcec.PrivateKey, bool, error) {
+	b, err := checkDecode(encrypted, encryptedKeyLength)
+	if err != nil {
+		return nil, false, ErrInvalidEncryptedKey
+	}
+
+	flag, addrHash := b[2], b[3:7]
+	compressed := flag&flagCompressed != 0
+
+	var priv *btcec.PrivateKey
+	switch {
+	case bytes.Equal(b[:2], prefixNonECMultiply) && flag&flagNonECMultiply == flagNonECMultiply:
+		priv, err = decryptNonECMultiply(b, passphrase)
+	case bytes.Equal(b[:2], prefixECMultiply) && flag&flagNonECMultiply == 0:
+		priv, err = decryptECMultiply(b, passphrase)
+	default:
+		return nil, false, ErrInvalidEncryptedKey
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	check, err := addressHash((*btcec.PublicKey)(&priv.PublicKey), compressed, netParam)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !bytes.Equal(check, addrHash) {
+		return nil, false, ErrWrongPassphrase
+	}
+	return priv, compressed, nil
+}
+
+// NewIntermediateCode returns the intermediate code (passphrase...) a third party generates encrypted keys from.
+// ownerSalt must be 8 random bytes
+func NewIntermediateCode(passphrase string, ownerSalt []byte) (string, error) {
+	if len(ownerSalt) != 8 {
+		return "", ErrInvalidOwnerSalt
+	}
+	return intermediateCode(passphrase, ownerSalt, ownerSalt, magicNoLotSequence)
+}
+
+// NewIntermediateCodeWithLot returns an intermediate code whose keys carry a lot and sequence number.
+// ownerSalt must be 4 random bytes
+func NewIntermediateCodeWithLot(passphrase string, ownerSalt []byte, lot uint32, sequence uint32) (string, error) {
+	if len(ownerSalt) != 4 {
+		return "", ErrInvalidOwnerSalt
+	}
+
+	if lot > MaxLot || sequence > MaxSequence {
+		return "", ErrInvalidLotSequence
+	}
+
+	lotSequence := lot<<12 | sequence
+	ownerEntropy := append(append([]byte{}, ownerSalt...), byte(lotSequence>>24), byte(lotSequence>>16), byte(lotSequence>>8), byte(lotSequence))
+	return intermediateCode(passphrase, ownerSalt, ownerEntropy, magicLotSequence)
+}
+
+// GenerateEncryptedKey returns a new EC multiplied encrypted key for an intermediate code.
+// seedb must be 24 random bytes
+func GenerateEncryptedKey(intermediate string, seedb []byte, compressed bool, netParam *chaincfg.Params) (*GeneratedKey, error) {
+	b, err := checkDecode(intermediate, 49)
+	if err != nil {
+		return nil, ErrInvalidIntermediateCode
+	}
+
+	flag := byte(0)
+	switch {
+	case bytes.Equal(b[:8], magicLotSequence):
+		flag = flagLotSequence
+	case bytes.Equal(b[:8], magicNoLotSequence):
+	default:
+		return nil, ErrInvalidIntermediateCode
+	}
+
+	if compressed {
+		flag |= flagCompressed
+	}
+
+	ownerEntropy, passpointBytes := b[8:16], b[16:49]
+	passpoint, err := btcec.ParsePubKey(passpointBytes, btcec.S256())
+	if err != nil {
+		return nil, ErrInvalidIntermediateCode
+	}
+
+	if len(seedb) != 24 {
+		return nil, ErrInvalidSeed
+	}
+
+	factorb := chainhash.DoubleHashB(seedb)
+	if !validScalar(factorb) {
+		return nil, ErrInvalidSeed
+	}
+
+	generated := multiply(passpoint, factorb)
+	addrHash, err := addressHash(generated, compressed, netParam)
+	if err != nil {
+		return nil, err
+	}
+
+	derived, err := scrypt.Key(passpointBytes, append(append([]byte{}, addrHash...), ownerEntropy...), scryptPointN, scryptPointR, scryptPointP, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derived[32:])
+	if err != nil {
+		return nil, err
+	}
+
+	part1 := make([]byte, 16)
+	block.Encrypt(part1, xor(seedb[:16], derived[:16]))
+
+	part2 := make([]byte, 16)
+	block.Encrypt(part2, xor(append(append([]byte{}, part1[8:]...), seedb[16:]...), derived[16:32]))
+
+	payload := append(append(append([]byte{}, prefixECMultiply...), flag), addrHash...)
+	payload = append(append(append(payload, ownerEntropy...), part1[:8]...), part2...)
+
+	// The confirmation code carries pointb = factorb * G encrypted like the key, so the owner can rebuild the address
+	pointb := multiply(nil, factorb).SerializeCompressed()
+	encryptedPointb := make([]byte, 33)
+	encryptedPointb[0] = pointb[0] ^ (derived[63] & 1)
+	block.Encrypt(encryptedPointb[1:17], xor(pointb[1:17], derived[:16]))
+	block.Encrypt(encryptedPointb[17:], xor(pointb[17:], derived[16:32]))
+
+	confirmation := append(append(append([]byte{}, prefixConfirmation...), flag), addrHash...)
+	confirmation = append(append(confirmation, ownerEntropy...), encryptedPointb...)
+
+	address, err := p2pkhAddress(generated, compressed, netParam)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeneratedKey{EncryptedKey: checkEncode(payload), ConfirmationCode: checkEncode(confirmation), Address: address}, nil
+}
+
+// VerifyConfirmationCode returns the address a confirmation code was generated for after checking it against the passphrase
+func VerifyConfirmationCode(code string, passphrase string, netParam *chaincfg.Params) (string, error) {
+	b, err := checkDecode(code, 51)
+	if err != nil || !bytes.Equal(b[:5], prefixConfirmation) {
+		return "", ErrInvalidConfirmationCode
+	}
+
+	flag, addrHash, ownerEntropy, encryptedPointb := b[5], b[6:10], b[10:18], b[18:51]
+	passfactor, err := passFactor(passphrase, ownerEntropy, flag&flagLotSequence != 0)
+	if err != nil {
+		return "", err
+	}
+
+	passpoint := multiply(nil, passfactor).SerializeCompressed()
+	derived, err := scrypt.Key(passpoint, append(append([]byte{}, addrHash...), ownerEntropy...), scryptPointN, scryptPointR, scryptPointP, 64)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(derived[32:])
+	if err != nil {
+		return "", err
+	}
+
+	pointb := make([]byte, 33)
+	pointb[0] = encryptedPointb[0] ^ (derived[63] & 1)
+	block.Decrypt(pointb[1:17], encryptedPointb[1:17])
+	block.Decrypt(pointb[17:], encryptedPointb[17:])
+	copy(pointb[1:], xor(pointb[1:], derived[:32]))
+
+	p, err := btcec.ParsePubKey(pointb, btcec.S256())
+	if err != nil {
+		return "", ErrInvalidConfirmationCode
+	}
+
+	compressed := flag&flagCompressed != 0
+	generated := multiply(p, passfactor)
+	check, err := addressHash(generated, compressed, netParam)
+	if err != nil {
+		return "", err
+	}
+
+	if !bytes.Equal(check, addrHash) {
+		return "", ErrInvalidConfirmationCode
+	}
+	return p2pkhAddress(generated, compressed, netParam)
+}
+
+// decryptNonECMultiply decrypts the key of a 0x0142 encrypted key
+func decryptNonECMultiply(b []byte, passphrase string) (*btcec.PrivateKey, error) {
+	derived, err := scrypt.Key(normalize(passphrase), b[3:7], scryptN, scryptR, scryptP, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derived[32:])
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	block.Decrypt(key[:16], b[7:23])
+	block.Decrypt(key[16:], b[23:39])
+
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), xor(key, derived[:32]))
+	return priv, nil
+}
+
+// decryptECMultiply recovers seedb of a 0x0143 encrypted key and multiplies its factor with the passphrase factor
+func decryptECMultiply(b []byte, passphrase string) (*btcec.PrivateKey, error) {
+	flag, addrHash, ownerEntropy := b[2], b[3:7], b[7:15]
+	passfactor, err := passFactor(passphrase, ownerEntropy, flag&flagLotSequence != 0)
+	if err != nil {
+		return nil, err
+	}
+
+	passpoint := multiply(nil, passfactor).SerializeCompressed()
+	derived, err := scrypt.Key(passpoint, append(append([]byte{}, addrHash...), ownerEntropy...), scryptPointN, scryptPointR, scryptPointP, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derived[32:])
+	if err != nil {
+		return nil, err
+	}
+
+	// encryptedpart2 decrypts to the second half of encryptedpart1 and the last 8 bytes of seedb
+	part2 := make([]byte, 16)
+	block.Decrypt(part2, b[23:39])
+	part2 = xor(part2, derived[16:32])
+
+	part1 := make([]byte, 16)
+	block.Decrypt(part1, append(append([]byte{}, b[15:23]...), part2[:8]...))
+	seedb := append(xor(part1, derived[:16]), part2[8:]...)
+
+	factorb := new(big.Int).SetBytes(chainhash.DoubleHashB(seedb))
+	d := new(big.Int).Mul(new(big.Int).SetBytes(passfactor), factorb)
+	d.Mod(d, btcec.S256().N)
+
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), paddedKey(d))
+	return priv, nil
+}
+
+// intermediateCode encodes magic || ownerentropy || passpoint
+func intermediateCode(passphrase string, ownerSalt []byte, ownerEntropy []byte, magic []byte) (string, error) {
+	passfactor, err := passFactor(passphrase, ownerEntropy, len(ownerSalt) == 4)
+	if err != nil {
+		return "", err
+	}
+
+	passpoint := multiply(nil, passfactor).SerializeCompressed()
+	payload := append(append(append([]byte{}, magic...), ownerEntropy...), passpoint...)
+	return checkEncode(payload), nil
+}
+
+// passFactor derives the passphrase factor from the passphrase and owner salt (the first 4 bytes of the owner entropy with a lot and sequence)
+func passFactor(passphrase string, ownerEntropy []byte, lotSequence bool) ([]byte, error) {
+	ownerSalt := ownerEntropy
+	if lotSequence {
+		ownerSalt = ownerEntropy[:4]
+	}
+
+	prefactor, err := scrypt.Key(normalize(passphrase), ownerSalt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	passfactor := prefactor
+	if lotSequence {
+		passfactor = chainhash.DoubleHashB(append(prefactor, ownerEntropy...))
+	}
+
+	if !validScalar(passfactor) {
+		return nil, ErrInvalidIntermediateCode
+	}
+	return passfactor, nil
+}
+
+// encryptHalves AES encrypts both halves of a 32 byte key XORed with derivedhalf1, keyed with derivedhalf2
+func encryptHalves(key []byte, derived []byte) ([]byte, error) {
+	block, err := aes.NewCipher(derived[32:])
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 32)
+	block.Encrypt(out[:16], xor(key[:16], derived[:16]))
+	block.Encrypt(out[16:], xor(key[16:], derived[16:32]))
+	return out, nil
+}
+
+// addressHash returns the first 4 bytes of the double SHA256 of the P2PKH address of a public key
+func addressHash(pub *btcec.PublicKey, compressed bool, netParam *chaincfg.Params) ([]byte, error) {
+	address, err := p2pkhAddress(pub, compressed, netParam)
+	if err != nil {
+		return nil, err
+	}
+	return chainhash.DoubleHashB([]byte(address))[:4], nil
+}
+
+// p2pkhAddress returns the base58 P2PKH address of a public key
+func p2pkhAddress(pub *btcec.PublicKey, compressed bool, netParam *chaincfg.Params) (string, error) {
+	serialized := pub.SerializeUncompressed()
+	if compressed {
+		serialized = pub.SerializeCompressed()
+	}
+
+	a, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(serialized), netParam)
+	if err != nil {
+		return "", err
+	}
+	return a.EncodeAddress(), nil
+}
+
+// multiply returns k * p, or k * G when p is nil
+func multiply(p *btcec.PublicKey, k []byte) *btcec.PublicKey {
+	curve := btcec.S256()
+	if p == nil {
+		x, y := curve.ScalarBaseMult(k)
+		return &btcec.PublicKey{Curve: curve, X: x, Y: y}
+	}
+
+	x, y := curve.ScalarMult(p.X, p.Y, k)
+	return &btcec.PublicKey{Curve: curve, X: x, Y: y}
+}
+
+// validScalar returns true if b is a valid private key (0 < b < N)
+func validScalar(b []byte) bool {
+	k := new(big.Int).SetBytes(b)
+	return k.Sign() > 0 && k.Cmp(btcec.S256().N) < 0
+}
+
+// paddedKey returns a private key scalar as 32 big endian bytes
+func paddedKey(d *big.Int) []byte {
+	b := d.Bytes()
+	return append(make([]byte, 32-len(b)), b...)
+}
+
+// xor returns a XOR b over the length of a
+func xor(a []byte, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+// normalize returns the NFC form of a passphrase as BIP38 requires
+func normalize(passphrase string) []byte {
+	return []byte(norm.NFC.String(passphrase))
+}
+
+// checkEncode returns the base58 encoding of payload followed by its double SHA256 checksum
+func checkEncode(payload []byte) string {
+	return base58.Encode(append(append([]byte{}, payload...), chainhash.DoubleHashB(payload)[:checksumLength]...))
+}
+
+// checkDecode returns the payload of a base58check string after verifying its checksum and length
+func checkDecode(s string, length int) ([]byte, error) {
+	b := base58.Decode(s)
+	if len(b) != length+checksumLength {
+		return nil, ErrInvalidEncryptedKey
+	}
+
+	payload := b[:length]
+	if !bytes.Equal(b[length:], chainhash.DoubleHashB(payload)[:checksumLength]) {
+		return nil, ErrInvalidEncryptedKey
+	}
+	return payload, nil
+}
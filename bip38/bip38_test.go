@@ -0,0 +1,187 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package bip38
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// Test vector ref: https://github.com/bitcoin/bips/blob/master/bip-0038.mediawiki#test-vectors
+var testNonECMultiply = []struct {
+	passphrase string
+	encrypted  string
+	wif        string
+}{
+	{"TestingOneTwoThree", "6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg", "5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR"},
+	{"Satoshi", "6PRNFFkZc2NZ6dJqFfhRoFNMR9Lnyj7dYGrzdgXXVMXcxoKTePPX1dWByq", "5HtasZ6ofTHP6HCwTqTkLDuLQisYPah7aUnSKfC7h4hMUVw2gi5"},
+	{"ϓ\u0000\U00010400\U0001F4A9", "6PRW5o9FLp4gJDDVqJQKJFTpMvdsSGJxMYHtHaQBF3ooa8mwD69bapcDQn", "5Jajm8eQ22H3pGWLEVCXyvND8dQZhiQhoLJNKjYXk9roUFTMSZ4"},
+	{"TestingOneTwoThree", "6PYNKZ1EAgYgmQfmNVamxyXVWHzK5s6DGhwP4J5o44cvXdoY7sRzhtpUeo", "L44B5gGEpqEDRS9vVPz7QT35jcBG2r3CZwSwQ4fCewXAhAhqGVpP"},
+	{"Satoshi", "6PYLtMnXvfG3oJde97zRyLYFZCYizPU5T3LwgdYJz1fRhh16bU7u6PPmY7", "KwYgW8gcxj1JWJXhPSu4Fqwzfhp5Yfi42mdYmMa4XqK7NJxXUSK7"},
+}
+
+var testECMultiply = []struct {
+	passphrase   string
+	intermediate string
+	encrypted    string
+	address      string
+	wif          string
+	confirmation string
+	lot          uint32
+	sequence     uint32
+}{
+	{"TestingOneTwoThree", "passphrasepxFy57B9v8HtUsszJYKReoNDV6VHjUSGt8EVJmux9n1J3Ltf1gRxyDGXqnf9qm", "6PfQu77ygVyJLZjfvMLyhLMQbYnu5uguoJJ4kMCLqWwPEdfpwANVS76gTX",
+		"1PE6TQi6HTVNz5DLwB1LcpMBALubfuN2z2", "5K4caxezwjGCGfnoPTZ8tMcJBLB7Jvyjv4xxeacadhq8nLisLR2", "", 0, 0},
+	{"Satoshi", "passphraseoRDGAXTWzbp72eVbtUDdn1rwpgPUGjNZEc6CGBo8i5EC1FPW8wcnLdq4ThKzAS", "6PfLGnQs6VZnrNpmVKfjotbnQuaJK4KZoPFrAjx1JMJUa1Ft8gnf5WxfKd",
+		"1CqzrtZC6mXSAhoxtFwVjz8LtwLJjDYU3V", "5KJ51SgxWaAYR13zd9ReMhJpwrcX47xTJh2D3fGPG9CM8vkv5sH", "", 0, 0},
+	{"MOLON LABE", "passphraseaB8feaLQDENqCgr4gKZpmf4VoaT6qdjJNJiv7fsKvjqavcJxvuR1hy25aTu5sX", "6PgNBNNzDkKdhkT6uJntUXwwzQV8Rr2tZcbkDcuC9DZRsS6AtHts4Ypo1j",
+		"1Jscj8ALrYu2y9TD8NrpvDBugPedmbj4Yh", "5JLdxTtcTHcfYcmJsNVy1v2PMDx432JPoYcBTVVRHpPaxUrdtf8",
+		"cfrm38V8aXBn7JWA1ESmFMUn6erxeBGZGAxJPY4e36S9QWkzZKtaVqLNMgnifETYw7BPwWC9aPD", 263183, 1},
+	{"ΜΟΛΩΝ ΛΑΒΕ", "passphrased3z9rQJHSyBkNBwTRPkUGNVEVrUAcfAXDyRU1V28ie6hNFbqDwbFBvsTK7yWVK", "6PgGWtx25kUg8QWvwuJAgorN6k9FbE25rv5dMRwu5SKMnfpfVe5mar2ngH",
+		"1Lurmih3KruL4xDB5FmHof38yawNtP9oGf", "5KMKKuUmAkiNbA3DazMQiLfDq47qs8MAEThm4yL8R2PhV1ov33D",
+		"cfrm38V8G4qq2ywYEFfWLD5Cc6msj9UwsG2Mj4Z6QdGJAFQpdatZLavkgRd1i4iBMdRngDqDs51", 806938, 1},
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, v := range testNonECMultiply {
+		wif, err := btcutil.DecodeWIF(v.wif)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encrypted, err := Encrypt(wif.PrivKey, v.passphrase, wif.CompressPubKey, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if encrypted != v.encrypted {
+			t.Errorf("encrypted key is not expected value want %s got %s", v.encrypted, encrypted)
+		}
+
+		priv, compressed, err := Decrypt(v.encrypted, v.passphrase, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !priv.PubKey().IsEqual(wif.PrivKey.PubKey()) || compressed != wif.CompressPubKey {
+			t.Errorf("%s did not decrypt to %s", v.encrypted, v.wif)
+		}
+	}
+
+	if _, _, err := Decrypt(testNonECMultiply[0].encrypted, "TestingOneTwoThre", &chaincfg.MainNetParams); err != ErrWrongPassphrase {
+		t.Error("wrong passphrase did not fail where expected")
+	}
+
+	if _, _, err := Decrypt(testNonECMultiply[0].wif, "TestingOneTwoThree", &chaincfg.MainNetParams); err != ErrInvalidEncryptedKey {
+		t.Error("WIF did not fail as encrypted key where expected")
+	}
+}
+
+func TestECMultiply(t *testing.T) {
+	for _, v := range testECMultiply {
+		priv, compressed, err := Decrypt(v.encrypted, v.passphrase, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		wif, err := btcutil.NewWIF(priv, &chaincfg.MainNetParams, compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wif.String() != v.wif {
+			t.Errorf("%s did not decrypt to %s, got %s", v.encrypted, v.wif, wif.String())
+		}
+
+		// Intermediate codes are random, rebuilding one from the owner salt of a vector must give the same code
+		ownerEntropy := base58.Decode(v.intermediate)[8:16]
+		intermediate, err := NewIntermediateCode(v.passphrase, ownerEntropy)
+		if v.confirmation != "" {
+			intermediate, err = NewIntermediateCodeWithLot(v.passphrase, ownerEntropy[:4], v.lot, v.sequence)
+		}
+		if err != nil {
+			t.Error(err)
+		}
+
+		if intermediate != v.intermediate {
+			t.Errorf("intermediate code is not expected value want %s got %s", v.intermediate, intermediate)
+		}
+
+		if v.confirmation == "" {
+			continue
+		}
+
+		address, err := VerifyConfirmationCode(v.confirmation, v.passphrase, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if address != v.address {
+			t.Errorf("confirmation code address is not expected value want %s got %s", v.address, address)
+		}
+	}
+}
+
+func TestGenerateEncryptedKey(t *testing.T) {
+	v := testECMultiply[2]
+	seedb := []byte("sansgenerateencryptedkey")
+	for _, compressed := range []bool{false, true} {
+		generated, err := GenerateEncryptedKey(v.intermediate, seedb, compressed, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		priv, isCompressed, err := Decrypt(generated.EncryptedKey, v.passphrase, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		address, err := p2pkhAddress(priv.PubKey(), compressed, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if address != generated.Address || isCompressed != compressed {
+			t.Errorf("generated key decrypts to %s want %s", address, generated.Address)
+		}
+
+		confirmed, err := VerifyConfirmationCode(generated.ConfirmationCode, v.passphrase, &chaincfg.MainNetParams)
+		if err != nil || confirmed != generated.Address {
+			t.Errorf("confirmation code gives %s want %s", confirmed, generated.Address)
+		}
+	}
+
+	if _, err := VerifyConfirmationCode(v.confirmation, "molon labe", &chaincfg.MainNetParams); err != ErrInvalidConfirmationCode {
+		t.Error("confirmation code with wrong passphrase did not fail where expected")
+	}
+
+	if _, err := GenerateEncryptedKey(v.encrypted, seedb, false, &chaincfg.MainNetParams); err != ErrInvalidIntermediateCode {
+		t.Error("encrypted key did not fail as intermediate code where expected")
+	}
+
+	if _, err := NewIntermediateCodeWithLot(v.passphrase, []byte{1, 2, 3, 4}, MaxLot+1, 0); err != ErrInvalidLotSequence {
+		t.Error("lot out of range did not fail where expected")
+	}
+}
@@ -0,0 +1,98 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"testing"
+
+	"github.com/sanscentral/sanswallet/bip38"
+)
+
+const (
+	testBIP38Passphrase = "TestingOneTwoThree"
+
+	// BIP38 compressed vector ref: https://github.com/bitcoin/bips/blob/master/bip-0038.mediawiki#test-vectors
+	testBIP38Encrypted = "6PYNKZ1EAgYgmQfmNVamxyXVWHzK5s6DGhwP4J5o44cvXdoY7sRzhtpUeo"
+	testBIP38WIF       = "L44B5gGEpqEDRS9vVPz7QT35jcBG2r3CZwSwQ4fCewXAhAhqGVpP"
+)
+
+func TestBIP38ImportExport(t *testing.T) {
+	key, err := NewImportedKeyFromBIP38(testBIP38Encrypted, testBIP38Passphrase, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.WIF() != testBIP38WIF {
+		t.Errorf("decrypted WIF is not expected value want %s got %s", testBIP38WIF, key.WIF())
+	}
+
+	encrypted, err := key.BIP38(testBIP38Passphrase)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if encrypted != testBIP38Encrypted {
+		t.Errorf("encrypted key is not expected value want %s got %s", testBIP38Encrypted, encrypted)
+	}
+
+	// A derived address key must decrypt back to its WIF
+	encrypted, err = GetBIP38ForIndex(testP2WPKHPriv, 0, testIsChangeAddress, testBIP38Passphrase, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err = NewImportedKeyFromBIP38(encrypted, testBIP38Passphrase, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.WIF() != testP2WPKH0WIF {
+		t.Errorf("decrypted address key is not expected value want %s got %s", testP2WPKH0WIF, key.WIF())
+	}
+
+	if _, err := NewImportedKeyFromBIP38(encrypted, "wrong", testIsTestnet); err != bip38.ErrWrongPassphrase {
+		t.Error("wrong passphrase did not fail where expected")
+	}
+}
+
+func TestBIP38GeneratedKey(t *testing.T) {
+	intermediate, err := NewBIP38IntermediateCodeWithLot(testBIP38Passphrase, 263183, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := GenerateBIP38Key(intermediate, true, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address, err := VerifyBIP38ConfirmationCode(generated.ConfirmationCode, testBIP38Passphrase, testIsTestnet)
+	if err != nil || address != generated.Address {
+		t.Errorf("confirmation code address is not expected value want %s got %s", generated.Address, address)
+	}
+
+	key, err := NewImportedKeyFromBIP38(generated.EncryptedKey, testBIP38Passphrase, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if address, _ := key.P2PKHAddress(); address != generated.Address {
+		t.Errorf("generated key address is not expected value want %s got %s", generated.Address, address)
+	}
+}
@@ -0,0 +1,197 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package cashaddr
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/sanscentral/sanswallet/segwit"
+)
+
+// AddressType is the type bits of a CashAddr version byte
+type AddressType byte
+
+const (
+	// P2KH pay-to-public-key-hash (bitcoincash:q...)
+	P2KH AddressType = 0
+
+	// P2SH pay-to-script-hash (bitcoincash:p...)
+	P2SH AddressType = 1
+)
+
+const (
+	// charset maps 5 bit values to CashAddr characters (same as bech32)
+	charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// separator divides the prefix from the payload
+	separator = ":"
+
+	// checksumLength is the number of 5 bit characters in a checksum (40 bits)
+	checksumLength = 8
+
+	// MainnetPrefix is the prefix of Bitcoin Cash mainnet addresses
+	MainnetPrefix = "bitcoincash"
+)
+
+var (
+	// ErrMixedCase is returned when an address mixes upper and lower case characters
+	ErrMixedCase = errors.New("CashAddr must not mix upper and lower case")
+
+	// ErrInvalidCharacter is returned when an address contains a character outside the CashAddr charset
+	ErrInvalidCharacter = errors.New("CashAddr contains an invalid character")
+
+	// ErrInvalidChecksum is returned when the checksum does not match the prefix and payload
+	ErrInvalidChecksum = errors.New("CashAddr checksum is invalid")
+
+	// ErrInvalidHashLength is returned when the hash length is not one of the sizes of the version byte
+	ErrInvalidHashLength = errors.New("CashAddr hash length is invalid")
+
+	// ErrInvalidAddressType is returned when the type does not fit the 4 type bits of the version byte
+	ErrInvalidAddressType = errors.New("CashAddr type is invalid")
+
+	// ErrInvalidPrefix is returned when an address has no prefix and no default is given, or the prefix is not lower case letters and digits
+	ErrInvalidPrefix = errors.New("CashAddr prefix is invalid")
+)
+
+// hashSizes are the hash lengths in bytes selected by the size bits of the version byte
+var hashSizes = []int{20, 24, 28, 32, 40, 48, 56, 64}
+
+// Encode returns the CashAddr (e.g. bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a) of a hash
+func Encode(prefix string, addrType AddressType, hash []byte) (string, error) {
+	if !validPrefix(prefix) {
+		return "", ErrInvalidPrefix
+	}
+
+	if addrType > 15 {
+		return "", ErrInvalidAddressType
+	}
+
+	size := -1
+	for i, s := range hashSizes {
+		if s == len(hash) {
+			size = i
+		}
+	}
+	if size < 0 {
+		return "", ErrInvalidHashLength
+	}
+
+	version := byte(addrType)<<3 | byte(size)
+	payload, err := segwit.ConvertBits(append([]byte{version}, hash...), 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	mod := polymod(prefix, append(payload, make([]byte, checksumLength)...))
+	out := make([]byte, 0, len(prefix)+1+len(payload)+checksumLength)
+	out = append(append(out, prefix...), separator...)
+	for _, d := range payload {
+		out = append(out, charset[d])
+	}
+	for i := 0; i < checksumLength; i++ {
+		out = append(out, charset[mod>>uint(5*(checksumLength-1-i))&31])
+	}
+	return string(out), nil
+}
+
+// Decode returns the prefix, type and hash of a CashAddr.
+// Addresses without prefix are checked against defaultPrefix
+func Decode(addr string, defaultPrefix string) (prefix string, addrType AddressType, hash []byte, err error) {
+	lower := strings.ToLower(addr)
+	if lower != addr && strings.ToUpper(addr) != addr {
+		return "", 0, nil, ErrMixedCase
+	}
+
+	prefix, payload := defaultPrefix, lower
+	if pos := strings.LastIndex(lower, separator); pos >= 0 {
+		prefix, payload = lower[:pos], lower[pos+1:]
+	}
+
+	if !validPrefix(prefix) {
+		return "", 0, nil, ErrInvalidPrefix
+	}
+
+	data := make([]byte, 0, len(payload))
+	for _, c := range []byte(payload) {
+		d := strings.IndexByte(charset, c)
+		if d < 0 {
+			return "", 0, nil, ErrInvalidCharacter
+		}
+		data = append(data, byte(d))
+	}
+
+	if len(data) <= checksumLength || polymod(prefix, data) != 0 {
+		return "", 0, nil, ErrInvalidChecksum
+	}
+
+	decoded, err := segwit.ConvertBits(data[:len(data)-checksumLength], 5, 8, false)
+	if err != nil || len(decoded) == 0 {
+		return "", 0, nil, ErrInvalidHashLength
+	}
+
+	version, hash := decoded[0], decoded[1:]
+	if version&0x80 != 0 {
+		return "", 0, nil, ErrInvalidAddressType
+	}
+
+	if hashSizes[version&7] != len(hash) {
+		return "", 0, nil, ErrInvalidHashLength
+	}
+	return prefix, AddressType(version >> 3), hash, nil
+}
+
+// validPrefix returns true for a non-empty prefix of lower case letters and digits
+func validPrefix(prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+
+	for _, c := range []byte(prefix) {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// polymod computes the 40 bit BCH checksum of the prefix (low 5 bits of each character), a zero separator and data.
+// A valid address has a result of 0
+func polymod(prefix string, data []byte) uint64 {
+	gen := [5]uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+	chk := uint64(1)
+	step := func(v byte) {
+		top := chk >> 35
+		chk = (chk&0x07ffffffff)<<5 ^ uint64(v)
+		for i := 0; i < 5; i++ {
+			if top>>uint(i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+
+	for _, c := range []byte(prefix) {
+		step(c & 31)
+	}
+	step(0)
+	for _, d := range data {
+		step(d)
+	}
+	return chk ^ 1
+}
@@ -0,0 +1,100 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package cashaddr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Test vector ref: https://github.com/bitcoincashorg/bitcoincash.org/blob/master/spec/cashaddr.md#examples-of-address-translation
+func TestEncode(t *testing.T) {
+	vectors := []struct {
+		prefix   string
+		addrType AddressType
+		hash     string
+		expected string
+	}{
+		{MainnetPrefix, P2KH, "76a04053bda0a88bda5177b86a15c3b29f559873", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+		{MainnetPrefix, P2SH, "76a04053bda0a88bda5177b86a15c3b29f559873", "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq"},
+		{MainnetPrefix, P2KH, "f5bf48b397dae70be82b3cca4793f8eb2b6cdac9", "bitcoincash:qr6m7j9njldwwzlg9v7v53unlr4jkmx6eylep8ekg2"},
+		{"bchtest", P2SH, "f5bf48b397dae70be82b3cca4793f8eb2b6cdac9", "bchtest:pr6m7j9njldwwzlg9v7v53unlr4jkmx6eyvwc0uz5t"},
+		{"pref", P2SH, "f5bf48b397dae70be82b3cca4793f8eb2b6cdac9", "pref:pr6m7j9njldwwzlg9v7v53unlr4jkmx6ey65nvtks5"},
+		{"prefix", 15, "f5bf48b397dae70be82b3cca4793f8eb2b6cdac9", "prefix:0r6m7j9njldwwzlg9v7v53unlr4jkmx6ey3qnjwsrf"},
+		{MainnetPrefix, P2KH, "7adbf6c17084bc86c1706827b41a56f5ca32865925e946ea", "bitcoincash:q9adhakpwzztepkpwp5z0dq62m6u5v5xtyj7j3h2ws4mr9g0"},
+		{MainnetPrefix, P2KH, "3a84f9cf51aae98a3bb3a78bf16a6183790b18719126325bfc0c075b", "bitcoincash:qgagf7w02x4wnz3mkwnchut2vxphjzccwxgjvvjmlsxqwkcw59jxxuz"},
+		{MainnetPrefix, P2KH, "3173ef6623c6b48ffd1a3dcc0cc6489b0a07bb47a37f47cfef4fe69de825c060", "bitcoincash:qvch8mmxy0rtfrlarg7ucrxxfzds5pamg73h7370aa87d80gyhqxq5nlegake"},
+	}
+
+	for _, v := range vectors {
+		hash, _ := hex.DecodeString(v.hash)
+		addr, err := Encode(v.prefix, v.addrType, hash)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if addr != v.expected {
+			t.Errorf("address is not expected value want %s got %s", v.expected, addr)
+		}
+
+		prefix, addrType, decoded, err := Decode(v.expected, "")
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if prefix != v.prefix || addrType != v.addrType || !bytes.Equal(decoded, hash) {
+			t.Errorf("%s decoded to %s type %d hash %x", v.expected, prefix, addrType, decoded)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	if _, _, _, err := Decode("qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", MainnetPrefix); err != nil {
+		t.Error("address without prefix did not decode with the default prefix")
+	}
+
+	invalid := []struct {
+		addr string
+		err  error
+	}{
+		{"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", ErrInvalidPrefix},
+		{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6q", ErrInvalidChecksum},
+		{"bchtest:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", ErrInvalidChecksum},
+		{"bitcoincash:Qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", ErrMixedCase},
+		{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6o", ErrInvalidCharacter},
+		{"bitcoincash:", ErrInvalidChecksum},
+	}
+
+	for _, v := range invalid {
+		if _, _, _, err := Decode(v.addr, ""); err != v.err {
+			t.Errorf("%s did not fail with expected error, got %v", v.addr, err)
+		}
+	}
+
+	if _, err := Encode(MainnetPrefix, P2KH, make([]byte, 21)); err != ErrInvalidHashLength {
+		t.Error("invalid hash length did not fail where expected")
+	}
+
+	if _, err := Encode("Bitcoin:Cash", P2KH, make([]byte, 20)); err != ErrInvalidPrefix {
+		t.Error("invalid prefix did not fail where expected")
+	}
+}
@@ -21,95 +21,138 @@ package main
 import (
 	"encoding/hex"
 	"fmt"
+	"runtime"
 	"strings"
 
 	"github.com/sanscentral/sanswallet"
+	"github.com/sanscentral/sanswallet/mnemonic"
+	"github.com/sanscentral/sanswallet/network"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 var (
 	seed         = kingpin.Flag("seed", "root seed in hexadecimal form").Default().Short('s').String()
-	addressType  = kingpin.Flag("type", "address type must be 'p2sh','p2pkh' or 'p2wpkh'").Default("p2pkh").Short('t').String()
+	words        = kingpin.Flag("mnemonic", "BIP39 mnemonic sentence in any supported language (used instead of seed)").Default().Short('m').String()
+	passphrase   = kingpin.Flag("passphrase", "optional BIP39 passphrase for mnemonic").Default().String()
+	addressType  = kingpin.Flag("type", "address type must be 'p2sh','p2pkh','p2wpkh' or 'p2tr'").Default("p2pkh").Short('t').String()
 	addressIndex = kingpin.Flag("index", "address index").Default("0").Short('i').Int()
 	count        = kingpin.Flag("count", "number of addresses to retrieve starting from index").Default("1").Short('c').Int()
 	testnet      = kingpin.Flag("testnet", "use testnet").Default("false").Short('d').Bool()
-	prvKey       = kingpin.Flag("pub", "prints the address private key").Default("false").Short('x').Bool()
-	pubKey       = kingpin.Flag("prv", "prints the address public key").Default("false").Short('p').Bool()
+	networkName  = kingpin.Flag("network", "network must be 'mainnet','testnet','regtest','signet','litecoin','dogecoin' or 'bitcoincash' (overrides testnet)").Default().Short('n').String()
+	prvKey       = kingpin.Flag("prv", "prints the account extended private key").Default("false").Short('x').Bool()
+	pubKey       = kingpin.Flag("pub", "prints the account extended public key").Default("false").Short('p').Bool()
+	wif          = kingpin.Flag("wif", "prints the WIF private key of each address").Default("false").Short('w').Bool()
+
+	addressCmd = kingpin.Command("address", "generate addresses from a seed or mnemonic").Default()
+	convertCmd = kingpin.Command("convert", "convert an extended key between SLIP-132 formats (xpub/ypub/zpub, tpub/upub/vpub, ...)")
+	convertKey = convertCmd.Arg("key", "extended key to convert").Required().String()
+	convertTo  = convertCmd.Arg("prefix", "target prefix e.g. 'xpub', 'ypub', 'zpub' or 'zprv'").Required().String()
+	importCmd  = kingpin.Command("import", "print the P2PKH, P2SH-P2WPKH and P2WPKH addresses of a WIF private key")
+	importWIF  = importCmd.Arg("wif", "WIF private key to import").Required().String()
+	verifyCmd  = kingpin.Command("verify", "verify a BIP137 or BIP322 message signature for an address")
+	verifyAddr = verifyCmd.Arg("address", "address the message was signed with").Required().String()
+	verifyMsg  = verifyCmd.Arg("message", "signed message").Required().String()
+	verifySig  = verifyCmd.Arg("signature", "base64 signature").Required().String()
 )
 
 func main() {
 	kingpin.Version("1.0.0")
-	kingpin.Parse()
+	switch kingpin.Parse() {
+	case convertCmd.FullCommand():
+		k, err := sanswallet.ConvertExtendedKey(*convertKey, *convertTo)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(k)
+		return
+
+	case importCmd.FullCommand():
+		printImportedKey(*importWIF, selectedNetwork())
+		return
+
+	case verifyCmd.FullCommand():
+		if err := sanswallet.VerifyMessageOnNetwork(*verifyAddr, *verifyMsg, *verifySig, selectedNetwork()); err != nil {
+			panic(err)
+		}
+		fmt.Println("Signature is valid")
+		return
+	}
 
 	seed, err := hex.DecodeString(*seed)
 	if err != nil {
 		panic(err)
 	}
 
+	if *words != "" {
+		seed, err = mnemonic.GetSeedFromMnemonic(*words, *passphrase)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	net := selectedNetwork()
 	prv := ""
 	pub := ""
-	address := []string{}
+	purpose := 0
 	switch strings.ToLower(*addressType) {
 	case "p2pkh":
-		var err error
-		prv, err = sanswallet.GetExtPrvForP2PKHAccount(seed, 0, *testnet)
+		purpose = 44
+		prv, err = sanswallet.GetExtPrvForP2PKHAccountOnNetwork(seed, 0, net)
 		if err != nil {
 			panic(err)
 		}
-		pub, err = sanswallet.GetExtPubForP2PKHAccount(seed, 0, *testnet)
+		pub, err = sanswallet.GetExtPubForP2PKHAccountOnNetwork(seed, 0, net)
 		if err != nil {
 			panic(err)
 		}
 
-		for index := 0; index < *count; index++ {
-			a := ""
-			a, err = sanswallet.GetP2PKHAddressForIndex(prv, *addressIndex+index, false, *testnet)
-			if err != nil {
-				panic(err)
-			}
-			address = append(address, a)
-		}
-
 	case "p2sh":
-		var err error
-		prv, err = sanswallet.GetExtPrvForP2SHAccount(seed, 0, *testnet)
+		purpose = 49
+		prv, err = sanswallet.GetExtPrvForP2SHAccountOnNetwork(seed, 0, net)
 		if err != nil {
 			panic(err)
 		}
-		pub, err = sanswallet.GetExtPubForP2SHAccount(seed, 0, *testnet)
+		pub, err = sanswallet.GetExtPubForP2SHAccountOnNetwork(seed, 0, net)
 		if err != nil {
 			panic(err)
 		}
 
-		for index := 0; index < *count; index++ {
-			a := ""
-			a, err = sanswallet.GetP2SHAddressForIndex(prv, *addressIndex+index, false, *testnet)
-			if err != nil {
-				panic(err)
-			}
-			address = append(address, a)
-		}
-
 	case "p2wpkh":
-		var err error
-		prv, err = sanswallet.GetExtPrvForP2WPKHAccount(seed, 0, *testnet)
+		purpose = 84
+		prv, err = sanswallet.GetExtPrvForP2WPKHAccountOnNetwork(seed, 0, net)
 		if err != nil {
 			panic(err)
 		}
-		pub, err = sanswallet.GetExtPubForP2WPKHAccount(seed, 0, *testnet)
+		pub, err = sanswallet.GetExtPubForP2WPKHAccountOnNetwork(seed, 0, net)
 		if err != nil {
 			panic(err)
 		}
 
-		for index := 0; index < *count; index++ {
-			a := ""
-			a, err = sanswallet.GetP2WPKHAddressForIndex(prv, *addressIndex+index, false, *testnet)
-			if err != nil {
-				panic(err)
-			}
-			address = append(address, a)
+	case "p2tr":
+		purpose = 86
+		prv, err = sanswallet.GetExtPrvForP2TRAccountOnNetwork(seed, 0, net)
+		if err != nil {
+			panic(err)
 		}
+		pub, err = sanswallet.GetExtPubForP2TRAccountOnNetwork(seed, 0, net)
+		if err != nil {
+			panic(err)
+		}
+
+	default:
+		panic(fmt.Sprintf("unknown address type %q", *addressType))
+	}
+
+	// The account keeps the parsed key and chain node so the whole range is derived in one pass
+	account, err := sanswallet.NewAccountFromSeedOnNetwork(seed, purpose, 0, net)
+	if err != nil {
+		panic(err)
+	}
+
+	address, err := account.AddressRange(*addressIndex, *count, false, runtime.NumCPU())
+	if err != nil {
+		panic(err)
 	}
 
 	if *prvKey {
@@ -121,6 +164,58 @@ func main() {
 	}
 
 	for i, s := range address {
-		fmt.Printf("%d.	%s\n", i+*addressIndex, s)
+		if !*wif {
+			fmt.Printf("%d.	%s\n", i+*addressIndex, s)
+			continue
+		}
+
+		w, err := account.AddressWIF(i+*addressIndex, false, true)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%d.	%s	%s\n", i+*addressIndex, s, w)
+	}
+}
+
+// selectedNetwork returns the network of the --network flag, or of --testnet when it is not set
+func selectedNetwork() network.Network {
+	if *networkName == "" {
+		if *testnet {
+			return network.BTCTestnet
+		}
+		return network.BTCMainnet
+	}
+
+	net, err := network.Lookup(*networkName)
+	if err != nil {
+		panic(fmt.Sprintf("unknown network %q", *networkName))
+	}
+	return net
+}
+
+// printImportedKey prints the public key and addresses of a WIF private key
+func printImportedKey(s string, net network.Network) {
+	k, err := sanswallet.NewImportedKeyFromWIFOnNetwork(s, net)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Public Key is:%s\n", k.PublicKey())
+	for _, a := range []struct {
+		name    string
+		address func() (string, error)
+	}{
+		{"p2pkh", k.P2PKHAddress},
+		{"p2sh", k.P2SHAddress},
+		{"p2wpkh", k.P2WPKHAddress},
+	} {
+		address, err := a.address()
+		if err == sanswallet.ErrUncompressedSegwit {
+			continue
+		}
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s	%s\n", a.name, address)
 	}
 }
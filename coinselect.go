@@ -0,0 +1,178 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/sanscentral/sanswallet/coinselect"
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+// Coin selection strategies of SelectUTXOs
+const (
+	// CoinSelectionAuto runs branch-and-bound, knapsack and single random draw and keeps the least waste
+	CoinSelectionAuto = "auto"
+
+	// CoinSelectionBranchAndBound finds a selection without change when one exists
+	CoinSelectionBranchAndBound = "bnb"
+
+	// CoinSelectionKnapsack is the knapsack solver of Bitcoin Core
+	CoinSelectionKnapsack = "knapsack"
+
+	// CoinSelectionSingleRandomDraw spends UTXOs in random order until they cover the payment
+	CoinSelectionSingleRandomDraw = "srd"
+
+	// CoinSelectionLargestFirst spends the largest UTXOs first
+	CoinSelectionLargestFirst = "largest-first"
+
+	// longTermFeeRate is the sat/vB rate the waste metric expects change outputs to be spent at
+	longTermFeeRate = 10
+)
+
+// ErrUnknownCoinSelection is returned for a coin selection strategy that is not supported
+var ErrUnknownCoinSelection = errors.New("Unknown coin selection strategy")
+
+// CoinSelection is the UTXOs chosen to pay recipients with the resulting fee and change
+type CoinSelection struct {
+	UTXOs []*UTXO
+
+	// Fee is the amount in satoshis left to miners, ChangeAmount is 0 when the transaction has no change output
+	Fee          int64
+	ChangeAmount int64
+
+	// Waste is the fee paid above spending the UTXOs at the long term fee rate plus the cost of change
+	// (or the excess given up as fee without change), lower is better
+	Waste int64
+}
+
+// SelectUTXOs chooses the UTXOs to pay recipients at feeRate sat/vB using strategy (auto, bnb, knapsack, srd or largest-first).
+// The selected UTXOs are then spent with BuildTransaction or CreatePSBT
+func (a *Account) SelectUTXOs(utxos []*UTXO, recipients []*Recipient, feeRate int64, strategy string) (*CoinSelection, error) {
+	return a.selectUTXOs(utxos, recipients, feeRate, strategy, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// selectUTXOs chooses UTXOs with rng drawing the random selections
+func (a *Account) selectUTXOs(utxos []*UTXO, recipients []*Recipient, feeRate int64, strategy string, rng *rand.Rand) (*CoinSelection, error) {
+	if len(utxos) == 0 {
+		return nil, ErrNoInputs
+	}
+
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	if feeRate < 0 {
+		return nil, ErrInvalidFeeRate
+	}
+
+	inputWeight, err := txsize.InputWeight(a.scriptType)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make([]*wire.TxOut, len(recipients))
+	var target int64
+	for i, r := range recipients {
+		if r.Amount <= 0 {
+			return nil, ErrInvalidAmount
+		}
+
+		script, err := a.addressScript(r.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Amount < txsize.ScriptDustThreshold(script) {
+			return nil, ErrDustOutput
+		}
+
+		target += r.Amount
+		outputs[i] = wire.NewTxOut(r.Amount, script)
+	}
+
+	// The change script only sets the change output size, any change index gives the same
+	changeAddress, err := a.ChangeAddress(0)
+	if err != nil {
+		return nil, err
+	}
+
+	changeScript, err := a.addressScript(changeAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// The weight without inputs keeps the segwit marker of the transaction spending them
+	weight, err := estimateWeight(a.scriptType, 1, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &coinselect.Params{
+		Target:            target,
+		FeeRate:           feeRate,
+		LongTermFeeRate:   longTermFeeRate,
+		BaseWeight:        weight - inputWeight,
+		ChangeWeight:      int64(wire.NewTxOut(0, changeScript).SerializeSize() * 4),
+		ChangeSpendWeight: inputWeight,
+		MinChange:         txsize.ScriptDustThreshold(changeScript),
+	}
+
+	coins := make([]*coinselect.Coin, len(utxos))
+	byCoin := make(map[*coinselect.Coin]*UTXO, len(utxos))
+	for i, u := range utxos {
+		if u.Amount <= 0 {
+			return nil, ErrInvalidAmount
+		}
+
+		coins[i] = &coinselect.Coin{Amount: u.Amount, Weight: inputWeight}
+		byCoin[coins[i]] = u
+	}
+
+	var r *coinselect.Result
+	switch strategy {
+	case CoinSelectionAuto:
+		r, err = coinselect.Select(coins, p, rng)
+	case CoinSelectionBranchAndBound:
+		r, err = coinselect.BranchAndBound(coins, p)
+	case CoinSelectionKnapsack:
+		r, err = coinselect.Knapsack(coins, p, rng)
+	case CoinSelectionSingleRandomDraw:
+		r, err = coinselect.SingleRandomDraw(coins, p, rng)
+	case CoinSelectionLargestFirst:
+		r, err = coinselect.LargestFirst(coins, p)
+	default:
+		return nil, ErrUnknownCoinSelection
+	}
+	if err == coinselect.ErrInsufficientFunds {
+		return nil, ErrInsufficientFunds
+	} else if err != nil {
+		return nil, err
+	}
+
+	s := &CoinSelection{Fee: r.Fee, ChangeAmount: r.Change, Waste: r.Waste}
+	for _, c := range r.Coins {
+		s.UTXOs = append(s.UTXOs, byCoin[c])
+	}
+	return s, nil
+}
@@ -0,0 +1,121 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package coinselect
+
+import (
+	"sort"
+
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+// bnbMaxTries bounds the branches BranchAndBound explores, as in Bitcoin Core
+const bnbMaxTries = 100000
+
+// BranchAndBound searches for the selection without change with the least waste (Bitcoin Core's algorithm):
+// a depth first search of coins by decreasing effective value for a total between the target
+// and the target plus the cost of change, the excess then being paid as fee
+func BranchAndBound(coins []*Coin, p *Params) (*Result, error) {
+	pool, err := p.pool(coins)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(pool, func(i, j int) bool { return p.effectiveValue(pool[i]) > p.effectiveValue(pool[j]) })
+
+	target := p.Target + txsize.Fee(p.BaseWeight, p.FeeRate)
+	costOfChange := p.costOfChange()
+
+	var available int64
+	for _, c := range pool {
+		available += p.effectiveValue(c)
+	}
+
+	if available < target {
+		return nil, ErrInsufficientFunds
+	}
+
+	// Inputs cost more now than later, a selection with more waste than the best cannot improve by adding inputs
+	feeRateHigh := p.FeeRate > p.LongTermFeeRate
+
+	var selection []int
+	var best *Result
+	var value, waste int64
+	bestWaste := int64(-1)
+	index := 0
+	for try := 0; try < bnbMaxTries; try, index = try+1, index+1 {
+		backtrack := false
+		switch {
+		case value+available < target || value > target+costOfChange || (feeRateHigh && bestWaste >= 0 && waste > bestWaste):
+			backtrack = true
+		case value >= target:
+			// The fee of the whole transaction can be below the sum of its parts, leaving enough for change
+			if excessWaste := waste + value - target; bestWaste < 0 || excessWaste <= bestWaste {
+				if r, err := p.result(bnbSelected(pool, selection)); err == nil && r.Change == 0 && (best == nil || r.Waste <= best.Waste) {
+					best = r
+					bestWaste = excessWaste
+				}
+			}
+			backtrack = true
+		}
+
+		if backtrack {
+			if len(selection) == 0 {
+				break
+			}
+
+			// Walk back to the last included coin and explore the branch omitting it
+			for index--; index > selection[len(selection)-1]; index-- {
+				available += p.effectiveValue(pool[index])
+			}
+
+			c := pool[index]
+			value -= p.effectiveValue(c)
+			waste -= txsize.Fee(c.Weight, p.FeeRate) - txsize.Fee(c.Weight, p.LongTermFeeRate)
+			selection = selection[:len(selection)-1]
+			continue
+		}
+
+		c := pool[index]
+		available -= p.effectiveValue(c)
+
+		// Skip a coin equivalent to the previous omitted one, that branch was already explored
+		if len(selection) != 0 && selection[len(selection)-1] != index-1 &&
+			p.effectiveValue(c) == p.effectiveValue(pool[index-1]) && c.Weight == pool[index-1].Weight {
+			continue
+		}
+
+		selection = append(selection, index)
+		value += p.effectiveValue(c)
+		waste += txsize.Fee(c.Weight, p.FeeRate) - txsize.Fee(c.Weight, p.LongTermFeeRate)
+	}
+
+	if best == nil {
+		return nil, ErrNoChangelessSolution
+	}
+	return best, nil
+}
+
+// bnbSelected returns the coins of pool at the selected indexes
+func bnbSelected(pool []*Coin, selection []int) []*Coin {
+	selected := make([]*Coin, len(selection))
+	for i, index := range selection {
+		selected[i] = pool[index]
+	}
+	return selected
+}
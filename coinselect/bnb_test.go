@@ -0,0 +1,107 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package coinselect
+
+import (
+	"testing"
+
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+func TestBranchAndBound(t *testing.T) {
+	coins := testCoins(100000, 200000, 300000, 400000)
+
+	// Without fees an exact match spends the coins summing to the target
+	for target, count := range map[int64]int{100000: 1, 300000: 2, 600000: 3, 1000000: 4} {
+		p := testParams(target, 0, 10)
+		r, err := BranchAndBound(coins, p)
+		if err != nil {
+			t.Errorf("target %d: %s", target, err.Error())
+			continue
+		}
+
+		if r.Change != 0 || r.Fee != 0 {
+			t.Errorf("target %d selection has change %d and fee %d", target, r.Change, r.Fee)
+		}
+
+		// At a fee rate below the long term one, spending more coins wastes less
+		if len(r.Coins) != count {
+			t.Errorf("target %d selected %d coins want %d", target, len(r.Coins), count)
+		}
+		checkResult(t, "branch and bound", p, r)
+	}
+
+	// Excess too small for a change output is paid as fee
+	p := testParams(100000-200, 0, 10)
+	r, err := BranchAndBound(coins, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Fee != 200 || r.Change != 0 {
+		t.Errorf("excess below the minimum change gave fee %d and change %d", r.Fee, r.Change)
+	}
+	checkResult(t, "branch and bound", p, r)
+
+	// Within the cost of change but enough for a change output, the transaction would have change
+	if _, err := BranchAndBound(coins, testParams(100000-600, 0, 10)); err != ErrNoChangelessSolution {
+		t.Error("excess above the minimum change did not fail where expected")
+	}
+
+	if _, err := BranchAndBound(testCoins(100000, 300000), testParams(150000, 0, 10)); err != ErrNoChangelessSolution {
+		t.Error("target without changeless selection did not fail where expected")
+	}
+
+	if _, err := BranchAndBound(coins, testParams(1000001, 0, 10)); err != ErrInsufficientFunds {
+		t.Error("target above the coin total did not fail where expected")
+	}
+}
+
+func TestBranchAndBoundFees(t *testing.T) {
+	amounts := make([]int64, 50)
+	for i := range amounts {
+		amounts[i] = 10000
+	}
+	coins := testCoins(amounts...)
+
+	// Equal coins must not be explored as separate branches
+	p := testParams(10*(10000-txsize.Fee(testInputWeight, 20))-txsize.Fee(p0BaseWeight(), 20), 20, 10)
+	r, err := BranchAndBound(coins, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Coins) != 10 || r.Change != 0 {
+		t.Errorf("selected %d equal coins with change %d want 10 without change", len(r.Coins), r.Change)
+	}
+	checkResult(t, "branch and bound", p, r)
+
+	// At a fee rate above the long term one every extra input is waste
+	coins = testCoins(50000, 30000, 20000, 10000)
+	p = testParams(50000-txsize.Fee(p0BaseWeight(), 20)-txsize.Fee(testInputWeight, 20), 20, 10)
+	r, err = BranchAndBound(coins, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Coins) != 1 || r.Coins[0] != coins[0] {
+		t.Errorf("high fee rate selected %d coins want the single exact coin", len(r.Coins))
+	}
+	checkResult(t, "branch and bound", p, r)
+}
@@ -0,0 +1,198 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package coinselect chooses the coins a transaction spends: branch-and-bound, single random draw,
+// knapsack and largest-first, scored with the waste metric of Bitcoin Core
+package coinselect
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+var (
+	// ErrInsufficientFunds is returned when the coins do not cover the target and the fee of spending them
+	ErrInsufficientFunds = errors.New("Coins do not cover the target and fee")
+
+	// ErrNoChangelessSolution is returned by BranchAndBound when no selection avoids change
+	ErrNoChangelessSolution = errors.New("No selection without change found")
+
+	// ErrInvalidTarget is returned for targets that are not positive
+	ErrInvalidTarget = errors.New("Selection target must be positive")
+
+	// ErrInvalidFeeRate is returned for negative fee rates
+	ErrInvalidFeeRate = errors.New("Fee rate must not be negative")
+)
+
+// Coin is an unspent output and the weight of the input spending it
+type Coin struct {
+	// Amount is the output value in satoshis
+	Amount int64
+
+	// Weight is the weight of the input spending the coin, see txsize.InputWeight
+	Weight int64
+}
+
+// Params describes the transaction coins are selected for
+type Params struct {
+	// Target is the amount paid to the recipients in satoshis
+	Target int64
+
+	// FeeRate is the fee rate of the transaction and LongTermFeeRate the expected future rate to spend coins at,
+	// both in sat/vB. Spending more inputs when FeeRate is below LongTermFeeRate lowers the waste
+	FeeRate         int64
+	LongTermFeeRate int64
+
+	// BaseWeight is the weight of the transaction without inputs (version, locktime, counts, recipient outputs and segwit marker)
+	BaseWeight int64
+
+	// ChangeWeight is the weight of the change output and ChangeSpendWeight of the input that will later spend it
+	ChangeWeight      int64
+	ChangeSpendWeight int64
+
+	// MinChange is the smallest change amount worth an output, e.g. its dust threshold
+	MinChange int64
+}
+
+// Result is a selection of coins with the fee, change and waste of the transaction spending them
+type Result struct {
+	Coins []*Coin
+
+	// Fee is the amount left to miners and Change the change output amount, 0 for changeless selections
+	Fee    int64
+	Change int64
+
+	// Waste is the cost of the selection compared to spending the coins at the long term fee rate with exact change:
+	// the input fees above their long term cost plus the cost of change, or the excess paid as fee without change
+	Waste int64
+}
+
+// Select runs branch-and-bound, knapsack and single random draw and returns the result with the least waste,
+// the one spending more coins when the waste is equal
+func Select(coins []*Coin, p *Params, rng *rand.Rand) (*Result, error) {
+	var best *Result
+	for _, strategy := range []func() (*Result, error){
+		func() (*Result, error) { return BranchAndBound(coins, p) },
+		func() (*Result, error) { return Knapsack(coins, p, rng) },
+		func() (*Result, error) { return SingleRandomDraw(coins, p, rng) },
+	} {
+		r, err := strategy()
+		if err == ErrNoChangelessSolution || err == ErrInsufficientFunds {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if best == nil || r.Waste < best.Waste || (r.Waste == best.Waste && len(r.Coins) > len(best.Coins)) {
+			best = r
+		}
+	}
+
+	if best == nil {
+		return nil, ErrInsufficientFunds
+	}
+	return best, nil
+}
+
+// LargestFirst selects the coins with the largest effective values until they cover the target and fee
+func LargestFirst(coins []*Coin, p *Params) (*Result, error) {
+	pool, err := p.pool(coins)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(pool, func(i, j int) bool { return p.effectiveValue(pool[i]) > p.effectiveValue(pool[j]) })
+
+	target := p.Target + txsize.Fee(p.BaseWeight, p.FeeRate)
+	var selected []*Coin
+	var value int64
+	for _, c := range pool {
+		selected = append(selected, c)
+		value += p.effectiveValue(c)
+		if value >= target {
+			return p.result(selected)
+		}
+	}
+	return nil, ErrInsufficientFunds
+}
+
+// pool checks the parameters and returns the coins worth spending at the fee rate (positive effective value)
+func (p *Params) pool(coins []*Coin) ([]*Coin, error) {
+	if p.Target <= 0 {
+		return nil, ErrInvalidTarget
+	}
+
+	if p.FeeRate < 0 || p.LongTermFeeRate < 0 {
+		return nil, ErrInvalidFeeRate
+	}
+
+	pool := make([]*Coin, 0, len(coins))
+	for _, c := range coins {
+		if p.effectiveValue(c) > 0 {
+			pool = append(pool, c)
+		}
+	}
+	return pool, nil
+}
+
+// result returns the fee, change and waste of spending selected. The fee is the one of the whole transaction weight,
+// with a change output when the change would be at least MinChange
+func (p *Params) result(selected []*Coin) (*Result, error) {
+	weight := p.BaseWeight
+	var total, waste int64
+	for _, c := range selected {
+		total += c.Amount
+		weight += c.Weight
+		waste += txsize.Fee(c.Weight, p.FeeRate) - txsize.Fee(c.Weight, p.LongTermFeeRate)
+	}
+
+	feeNoChange := txsize.Fee(weight, p.FeeRate)
+	excess := total - p.Target - feeNoChange
+	if excess < 0 {
+		return nil, ErrInsufficientFunds
+	}
+
+	r := &Result{Coins: selected}
+	feeChange := txsize.Fee(weight+p.ChangeWeight, p.FeeRate)
+	if change := total - p.Target - feeChange; change >= p.MinChange {
+		r.Change = change
+		r.Fee = feeChange
+		r.Waste = waste + p.costOfChange()
+		return r, nil
+	}
+
+	r.Fee = total - p.Target
+	r.Waste = waste + excess
+	return r, nil
+}
+
+// effectiveValue returns the coin amount less the fee of the input spending it. Fees of transaction parts
+// are rounded up to whole vbytes, their sum is at least the fee of the whole transaction
+func (p *Params) effectiveValue(c *Coin) int64 {
+	return c.Amount - txsize.Fee(c.Weight, p.FeeRate)
+}
+
+// costOfChange returns the fee of the change output now and of spending it later at the long term fee rate
+func (p *Params) costOfChange() int64 {
+	return txsize.Fee(p.ChangeWeight, p.FeeRate) + txsize.Fee(p.ChangeSpendWeight, p.LongTermFeeRate)
+}
@@ -0,0 +1,186 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package coinselect
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+const (
+	// testInputWeight and testChangeWeight are the weights of a P2WPKH input and output
+	testInputWeight  = 272
+	testChangeWeight = 31 * 4
+
+	// testSeed seeds the random strategies so their selections are reproducible
+	testSeed = 42
+)
+
+// testCoins returns P2WPKH coins of amounts
+func testCoins(amounts ...int64) []*Coin {
+	coins := make([]*Coin, len(amounts))
+	for i, amount := range amounts {
+		coins[i] = &Coin{Amount: amount, Weight: testInputWeight}
+	}
+	return coins
+}
+
+// testParams returns parameters of a transaction paying target to one P2WPKH output
+func testParams(target int64, feeRate int64, longTermFeeRate int64) *Params {
+	return &Params{
+		Target:            target,
+		FeeRate:           feeRate,
+		LongTermFeeRate:   longTermFeeRate,
+		BaseWeight:        (4+4+1+1+31)*4 + 2,
+		ChangeWeight:      testChangeWeight,
+		ChangeSpendWeight: testInputWeight,
+		MinChange:         294,
+	}
+}
+
+// checkResult checks the amounts of a result balance at the fee of the whole transaction
+// and its waste matches the selection
+func checkResult(t *testing.T, name string, p *Params, r *Result) {
+	weight := p.BaseWeight
+	var total, waste int64
+	for _, c := range r.Coins {
+		total += c.Amount
+		weight += c.Weight
+		waste += txsize.Fee(c.Weight, p.FeeRate) - txsize.Fee(c.Weight, p.LongTermFeeRate)
+	}
+
+	if total != p.Target+r.Fee+r.Change {
+		t.Errorf("%s selection of %d does not balance target %d fee %d change %d", name, total, p.Target, r.Fee, r.Change)
+	}
+
+	changeFee := txsize.Fee(weight+p.ChangeWeight, p.FeeRate)
+	if r.Change != 0 {
+		if r.Fee != changeFee || r.Change < p.MinChange {
+			t.Errorf("%s fee %d with change %d is not the fee %d of weight %d", name, r.Fee, r.Change, changeFee, weight+p.ChangeWeight)
+		}
+		waste += p.costOfChange()
+	} else {
+		if r.Fee < txsize.Fee(weight, p.FeeRate) || total-p.Target-changeFee >= p.MinChange {
+			t.Errorf("%s fee %d without change is below the fee of weight %d or leaves enough for change", name, r.Fee, weight)
+		}
+		waste += r.Fee - txsize.Fee(weight, p.FeeRate)
+	}
+
+	if r.Waste != waste {
+		t.Errorf("%s waste is %d want %d", name, r.Waste, waste)
+	}
+}
+
+func TestEffectiveValue(t *testing.T) {
+	p := testParams(10000, 10, 10)
+	coins := []*Coin{{Amount: 10000, Weight: 592}, {Amount: 10000, Weight: 230}, {Amount: 1000, Weight: 592}}
+
+	// Legacy inputs cost 148 vbytes and taproot inputs 57.5, rounded up to whole vbytes
+	if v := p.effectiveValue(coins[0]); v != 8520 {
+		t.Errorf("P2PKH effective value is %d want 8520", v)
+	}
+
+	if v := p.effectiveValue(coins[1]); v != 9420 {
+		t.Errorf("P2TR effective value is %d want 9420", v)
+	}
+
+	pool, err := p.pool(coins)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pool) != 2 {
+		t.Error("coin costing more than its amount to spend was not left out")
+	}
+
+	if _, err := testParams(0, 1, 1).pool(coins); err != ErrInvalidTarget {
+		t.Error("zero target did not fail where expected")
+	}
+
+	if _, err := testParams(1, -1, 1).pool(coins); err != ErrInvalidFeeRate {
+		t.Error("negative fee rate did not fail where expected")
+	}
+}
+
+func TestLargestFirst(t *testing.T) {
+	coins := testCoins(1000, 50000, 20000, 80000)
+	p := testParams(100000, 2, 10)
+
+	r, err := LargestFirst(coins, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Coins) != 2 || r.Coins[0] != coins[3] || r.Coins[1] != coins[1] {
+		t.Error("largest coins were not selected first")
+	}
+
+	if r.Change == 0 {
+		t.Error("largest first selection has no change")
+	}
+	checkResult(t, "largest first", p, r)
+
+	if _, err := LargestFirst(coins, testParams(151000, 2, 10)); err != ErrInsufficientFunds {
+		t.Error("target above the coin total did not fail where expected")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	coins := testCoins(30000, 45000, 60000, 80000, 120000, 200000)
+
+	// An exact match has less waste than any selection with change
+	p := testParams(45000-txsize.Fee(p0BaseWeight(), 5)-txsize.Fee(testInputWeight, 5), 5, 10)
+	r, err := Select(coins, p, rand.New(rand.NewSource(testSeed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Coins) != 1 || r.Coins[0] != coins[1] || r.Change != 0 {
+		t.Errorf("exact match was not selected, got %d coins with change %d", len(r.Coins), r.Change)
+	}
+	checkResult(t, "select", p, r)
+
+	p = testParams(100000, 5, 10)
+	r, err = Select(coins, p, rand.New(rand.NewSource(testSeed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResult(t, "select", p, r)
+
+	for _, strategy := range []func() (*Result, error){
+		func() (*Result, error) { return BranchAndBound(coins, p) },
+		func() (*Result, error) { return Knapsack(coins, p, rand.New(rand.NewSource(testSeed))) },
+		func() (*Result, error) { return SingleRandomDraw(coins, p, rand.New(rand.NewSource(testSeed))) },
+	} {
+		if other, err := strategy(); err == nil && other.Waste < r.Waste {
+			t.Errorf("selection waste %d is above another strategy's %d", r.Waste, other.Waste)
+		}
+	}
+
+	if _, err := Select(coins, testParams(600000, 5, 10), rand.New(rand.NewSource(testSeed))); err != ErrInsufficientFunds {
+		t.Error("target above the coin total did not fail where expected")
+	}
+}
+
+// p0BaseWeight returns the base weight of the test parameters
+func p0BaseWeight() int64 {
+	return testParams(1, 0, 0).BaseWeight
+}
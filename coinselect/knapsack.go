@@ -0,0 +1,135 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package coinselect
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+// knapsackIterations is the number of random subsets the knapsack solver tries, as in Bitcoin Core
+const knapsackIterations = 1000
+
+// Knapsack selects coins as Bitcoin Core's knapsack solver: an exact match if one exists, otherwise the smallest
+// random subset found covering the target with enough change, or the smallest single coin larger than that
+func Knapsack(coins []*Coin, p *Params, rng *rand.Rand) (*Result, error) {
+	pool, err := p.pool(coins)
+	if err != nil {
+		return nil, err
+	}
+
+	target := p.Target + txsize.Fee(p.BaseWeight, p.FeeRate)
+	changeTarget := txsize.Fee(p.ChangeWeight, p.FeeRate) + p.MinChange
+
+	var lowestLarger *Coin
+	var applicable []*Coin
+	var totalLower int64
+	for _, i := range rng.Perm(len(pool)) {
+		c := pool[i]
+		v := p.effectiveValue(c)
+		switch {
+		case v == target:
+			return p.result([]*Coin{c})
+		case v < target+changeTarget:
+			applicable = append(applicable, c)
+			totalLower += v
+		case lowestLarger == nil || v < p.effectiveValue(lowestLarger):
+			lowestLarger = c
+		}
+	}
+
+	if totalLower == target {
+		return p.result(applicable)
+	}
+
+	if totalLower < target {
+		if lowestLarger == nil {
+			return nil, ErrInsufficientFunds
+		}
+		return p.result([]*Coin{lowestLarger})
+	}
+
+	sort.SliceStable(applicable, func(i, j int) bool { return p.effectiveValue(applicable[i]) > p.effectiveValue(applicable[j]) })
+
+	values := make([]int64, len(applicable))
+	for i, c := range applicable {
+		values[i] = p.effectiveValue(c)
+	}
+
+	best, bestValue := approximateBestSubset(values, totalLower, target, rng)
+	if bestValue != target && totalLower >= target+changeTarget {
+		best, bestValue = approximateBestSubset(values, totalLower, target+changeTarget, rng)
+	}
+
+	// A single larger coin is preferred to a subset without exact match or enough change, or one worth more
+	if lowestLarger != nil && ((bestValue != target && bestValue < target+changeTarget) || p.effectiveValue(lowestLarger) <= bestValue) {
+		return p.result([]*Coin{lowestLarger})
+	}
+
+	var selected []*Coin
+	for i, included := range best {
+		if included {
+			selected = append(selected, applicable[i])
+		}
+	}
+	return p.result(selected)
+}
+
+// approximateBestSubset returns the smallest subset of values found reaching target by random inclusion,
+// values being sorted in decreasing order and summing to total
+func approximateBestSubset(values []int64, total int64, target int64, rng *rand.Rand) ([]bool, int64) {
+	best := make([]bool, len(values))
+	for i := range best {
+		best[i] = true
+	}
+	bestValue := total
+
+	included := make([]bool, len(values))
+	for rep := 0; rep < knapsackIterations && bestValue != target; rep++ {
+		for i := range included {
+			included[i] = false
+		}
+
+		var sum int64
+		reached := false
+		for pass := 0; pass < 2 && !reached; pass++ {
+			for i, v := range values {
+				// The first pass includes values at random, the second adds the remaining ones in order
+				if pass == 0 && rng.Intn(2) == 0 || pass == 1 && included[i] {
+					continue
+				}
+
+				sum += v
+				included[i] = true
+				if sum >= target {
+					reached = true
+					if sum < bestValue {
+						bestValue = sum
+						copy(best, included)
+					}
+					sum -= v
+					included[i] = false
+				}
+			}
+		}
+	}
+	return best, bestValue
+}
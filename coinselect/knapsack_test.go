@@ -0,0 +1,81 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package coinselect
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+func TestKnapsack(t *testing.T) {
+	coins := testCoins(1000, 2000, 5000, 10000, 20000, 50000)
+
+	// A single coin matching the target is always selected
+	p := testParams(20000-txsize.Fee(p0BaseWeight(), 1)-txsize.Fee(testInputWeight, 1), 1, 10)
+	r, err := Knapsack(coins, p, rand.New(rand.NewSource(testSeed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Coins) != 1 || r.Coins[0] != coins[4] || r.Change != 0 {
+		t.Errorf("exact match was not selected, got %d coins with change %d", len(r.Coins), r.Change)
+	}
+	checkResult(t, "knapsack", p, r)
+
+	// The same seed gives the same selection
+	p = testParams(31000, 2, 10)
+	var first *Result
+	for i := 0; i < 3; i++ {
+		r, err := Knapsack(coins, p, rand.New(rand.NewSource(testSeed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkResult(t, "knapsack", p, r)
+
+		if first == nil {
+			first = r
+			continue
+		}
+
+		if len(r.Coins) != len(first.Coins) || r.Change != first.Change {
+			t.Error("knapsack selection with the same seed differs")
+		}
+	}
+
+	if _, err := Knapsack(coins, testParams(100000, 2, 10), rand.New(rand.NewSource(testSeed))); err != ErrInsufficientFunds {
+		t.Error("target above the coin total did not fail where expected")
+	}
+}
+
+func TestKnapsackLowestLarger(t *testing.T) {
+	// The small coins cannot cover the target so the smallest larger coin is spent alone
+	coins := testCoins(5000, 6000, 1000000, 2000000)
+	p := testParams(20000, 2, 10)
+	r, err := Knapsack(coins, p, rand.New(rand.NewSource(testSeed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Coins) != 1 || r.Coins[0] != coins[2] {
+		t.Errorf("smallest larger coin was not selected, got %d coins", len(r.Coins))
+	}
+	checkResult(t, "knapsack", p, r)
+}
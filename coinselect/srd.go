@@ -0,0 +1,46 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package coinselect
+
+import (
+	"math/rand"
+
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+// SingleRandomDraw selects coins in random order until they cover the target, the fee and a change output
+// of at least MinChange
+func SingleRandomDraw(coins []*Coin, p *Params, rng *rand.Rand) (*Result, error) {
+	pool, err := p.pool(coins)
+	if err != nil {
+		return nil, err
+	}
+
+	target := p.Target + txsize.Fee(p.BaseWeight, p.FeeRate) + txsize.Fee(p.ChangeWeight, p.FeeRate) + p.MinChange
+	var selected []*Coin
+	var value int64
+	for _, i := range rng.Perm(len(pool)) {
+		selected = append(selected, pool[i])
+		value += p.effectiveValue(pool[i])
+		if value >= target {
+			return p.result(selected)
+		}
+	}
+	return nil, ErrInsufficientFunds
+}
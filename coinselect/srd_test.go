@@ -0,0 +1,64 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package coinselect
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+func TestSingleRandomDraw(t *testing.T) {
+	coins := testCoins(10000, 20000, 30000, 40000, 50000, 60000)
+	p := testParams(45000, 5, 10)
+
+	var first *Result
+	for i := 0; i < 3; i++ {
+		r, err := SingleRandomDraw(coins, p, rand.New(rand.NewSource(testSeed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.Change < p.MinChange {
+			t.Errorf("single random draw change %d is below the minimum", r.Change)
+		}
+		checkResult(t, "single random draw", p, r)
+
+		if first == nil {
+			first = r
+			continue
+		}
+
+		if len(r.Coins) != len(first.Coins) || r.Change != first.Change {
+			t.Error("single random draw with the same seed differs")
+		}
+		for j := range r.Coins {
+			if r.Coins[j] != first.Coins[j] {
+				t.Error("single random draw with the same seed selected other coins")
+			}
+		}
+	}
+
+	// The coins cover the target and fee but leave no room for change
+	p = testParams(210000-txsize.Fee(p0BaseWeight(), 5)-6*txsize.Fee(testInputWeight, 5), 5, 10)
+	if _, err := SingleRandomDraw(coins, p, rand.New(rand.NewSource(testSeed))); err != ErrInsufficientFunds {
+		t.Error("selection without room for change did not fail where expected")
+	}
+}
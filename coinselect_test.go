@@ -0,0 +1,154 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"github.com/sanscentral/sanswallet/coinselect"
+)
+
+func TestSelectUTXOs(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	utxos := []*UTXO{
+		{TxID: testFundingTxID, Vout: 0, Amount: 20000, AddressIndex: 0},
+		{TxID: testFundingTxID, Vout: 1, Amount: 60000, AddressIndex: 1},
+		{TxID: testFundingTxID, Vout: 2, Amount: 45000, AddressIndex: 2, IsChange: true},
+		{TxID: testFundingTxID, Vout: 3, Amount: 150000, AddressIndex: 3},
+	}
+	recipients := []*Recipient{{testP2PK1, 30000}, {testP2WPKH10, 40000}}
+
+	strategies := []string{CoinSelectionAuto, CoinSelectionBranchAndBound, CoinSelectionKnapsack, CoinSelectionSingleRandomDraw, CoinSelectionLargestFirst}
+	for _, purpose := range []int{44, 49, 84, 86} {
+		account, err := NewAccountFromSeed(seed, purpose, 0, testIsTestnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, strategy := range strategies {
+			s, err := account.selectUTXOs(utxos, recipients, testFeeRate, strategy, rand.New(rand.NewSource(1)))
+			if err == coinselect.ErrNoChangelessSolution {
+				continue
+			}
+			if err != nil {
+				t.Errorf("purpose %d %s selection failed: %s", purpose, strategy, err.Error())
+				continue
+			}
+
+			checkSelection(t, account, s, recipients, testFeeRate, strategy)
+		}
+
+		// Largest first spends the 150000 coin alone
+		s, err := account.SelectUTXOs(utxos, recipients, testFeeRate, CoinSelectionLargestFirst)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(s.UTXOs) != 1 || s.UTXOs[0] != utxos[3] {
+			t.Errorf("purpose %d largest first selected %d UTXOs", purpose, len(s.UTXOs))
+		}
+	}
+
+	account, err := NewAccountFromSeed(seed, 84, 0, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := account.SelectUTXOs(utxos, []*Recipient{{testP2WPKH10, 300000}}, testFeeRate, CoinSelectionAuto); err != ErrInsufficientFunds {
+		t.Error("payment above the UTXO total did not fail where expected")
+	}
+
+	if _, err := account.SelectUTXOs(utxos, recipients, testFeeRate, "oldest"); err != ErrUnknownCoinSelection {
+		t.Error("unknown strategy did not fail where expected")
+	}
+}
+
+func TestSelectUTXOsRandom(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Selections of random UTXOs must be spent by BuildTransaction at the fee they were selected for
+	rng := rand.New(rand.NewSource(7))
+	strategies := []string{CoinSelectionBranchAndBound, CoinSelectionKnapsack, CoinSelectionSingleRandomDraw, CoinSelectionLargestFirst}
+	for _, purpose := range []int{44, 49, 84, 86} {
+		account, err := NewAccountFromSeed(seed, purpose, 0, testIsTestnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, feeRate := range []int64{1, 2, 3, 6, 11} {
+			for n := 0; n < 5; n++ {
+				utxos := make([]*UTXO, 4+rng.Intn(6))
+				var total int64
+				for i := range utxos {
+					utxos[i] = &UTXO{TxID: testFundingTxID, Vout: i, Amount: 5000 + rng.Int63n(100000), AddressIndex: i}
+					total += utxos[i].Amount
+				}
+				recipients := []*Recipient{{testP2WPKH10, 1000 + rng.Int63n(total/2)}}
+
+				for _, strategy := range strategies {
+					s, err := account.selectUTXOs(utxos, recipients, feeRate, strategy, rand.New(rand.NewSource(int64(n))))
+					if err == coinselect.ErrNoChangelessSolution {
+						continue
+					}
+					if err != nil {
+						t.Errorf("purpose %d %s selection at %d sat/vB failed: %s", purpose, strategy, feeRate, err.Error())
+						continue
+					}
+					checkSelection(t, account, s, recipients, feeRate, strategy)
+				}
+			}
+		}
+	}
+}
+
+// checkSelection checks BuildTransaction spends the selected UTXOs with the fee and change of the selection
+func checkSelection(t *testing.T, account *Account, s *CoinSelection, recipients []*Recipient, feeRate int64, strategy string) {
+	var total, spent int64
+	for _, u := range s.UTXOs {
+		total += u.Amount
+	}
+
+	for _, r := range recipients {
+		spent += r.Amount
+	}
+
+	if total != spent+s.Fee+s.ChangeAmount {
+		t.Errorf("%s %s selection of %d does not balance fee %d change %d", account.ScriptType(), strategy, total, s.Fee, s.ChangeAmount)
+	}
+
+	signed, err := account.BuildTransaction(s.UTXOs, recipients, feeRate, 0)
+	if err != nil {
+		t.Errorf("%s %s selection at %d sat/vB did not build: %s", account.ScriptType(), strategy, feeRate, err.Error())
+		return
+	}
+
+	if s.Fee != signed.Fee || s.ChangeAmount != signed.ChangeAmount {
+		t.Errorf("%s %s selection fee %d and change %d differ from transaction fee %d and change %d",
+			account.ScriptType(), strategy, s.Fee, s.ChangeAmount, signed.Fee, signed.ChangeAmount)
+	}
+}
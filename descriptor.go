@@ -0,0 +1,118 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"errors"
+
+	"github.com/sanscentral/sanswallet/descriptor"
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// ErrUnsupportedDescriptorPath is returned when a descriptor does not derive addresses as /0/* or /1/* of its key
+var ErrUnsupportedDescriptorPath = errors.New("Descriptor key must be an account key followed by /0/* or /1/*")
+
+// GetDescriptorForAccount returns the watch-only output descriptor with key origin and checksum for the receive or change chain
+// of the account at accountIndex for a BIP purpose (44, 49, 84 or 86) e.g. wpkh([73c5da0a/84'/0'/0']xpub.../0/*)#checksum
+func GetDescriptorForAccount(seed []byte, purpose int, accountIndex int, isChange bool, testnet bool) (string, error) {
+	return GetDescriptorForAccountOnNetwork(seed, purpose, accountIndex, isChange, testnetToNetwork(testnet))
+}
+
+// GetDescriptorForAccountOnNetwork returns the watch-only output descriptor for the receive or change chain of an account on net
+func GetDescriptorForAccountOnNetwork(seed []byte, purpose int, accountIndex int, isChange bool, net network.Network) (string, error) {
+	a, err := NewAccountFromSeedOnNetwork(seed, purpose, accountIndex, net)
+	if err != nil {
+		return "", err
+	}
+	return a.Descriptor(isChange)
+}
+
+// NewAccountFromDescriptor returns the mainnet or testnet account of a descriptor, see NewAccountFromDescriptorOnNetwork
+func NewAccountFromDescriptor(desc string) (*Account, error) {
+	d, err := descriptor.Parse(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := keys.GetExtendedKeyInfo(d.Key)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountFromDescriptor(d, v.Network)
+}
+
+// NewAccountFromDescriptorOnNetwork returns the account of a pkh(), sh(wpkh()), wpkh() or tr() descriptor ranging over /0/* or /1/* on net.
+// The extended key in the descriptor is taken as the account key, Address and AddressRange with isChange false
+// return the addresses of the descriptor chain. Regtest and signet keys share the testnet versions so their network cannot be inferred from the key
+func NewAccountFromDescriptorOnNetwork(desc string, net network.Network) (*Account, error) {
+	d, err := descriptor.Parse(desc)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountFromDescriptor(d, net)
+}
+
+// newAccountFromDescriptor returns the account of a parsed descriptor after checking its key version is of net
+func newAccountFromDescriptor(d *descriptor.Descriptor, net network.Network) (*Account, error) {
+	if !d.Ranged || len(d.Path) != 1 || d.Path[0] > uint32(keys.ChangeAddress) {
+		return nil, ErrUnsupportedDescriptorPath
+	}
+
+	v, isPrivate, err := keys.GetExtendedKeyInfo(d.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAccountKeyVersion(d.Key, v.ScriptType, net); err != nil {
+		return nil, err
+	}
+
+	a, err := newAccount(d.Key, d.ScriptType, net, isPrivate)
+	if err != nil {
+		return nil, err
+	}
+
+	a.origin = d.Origin
+	a.chain = keys.AddressType(d.Path[0])
+	return a, nil
+}
+
+// Descriptor returns the watch-only output descriptor with checksum for the receive or change chain of the account.
+// The key origin is included when the account was created from a seed or from a descriptor carrying one
+func (a *Account) Descriptor(isChange bool) (string, error) {
+	pub, err := a.key.Neuter()
+	if err != nil {
+		return "", err
+	}
+
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	d := &descriptor.Descriptor{
+		ScriptType: a.scriptType,
+		Origin:     a.origin,
+		Key:        pub.String(),
+		Path:       keys.DerivationPath{uint32(addt)},
+		Ranged:     true,
+	}
+	return d.String(), nil
+}
@@ -0,0 +1,139 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package descriptor
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// inputCharset lists the characters allowed in a descriptor, ordered so checksums detect common errors (BIP380)
+	inputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+	// checksumCharset maps 5 bit values to checksum characters (same as bech32)
+	checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// ChecksumLength is the number of characters in a descriptor checksum
+	ChecksumLength = 8
+
+	// checksumSeparator divides a descriptor from its checksum
+	checksumSeparator = "#"
+)
+
+var (
+	// ErrInvalidChecksum is returned when a descriptor checksum does not match the descriptor
+	ErrInvalidChecksum = errors.New("Descriptor checksum is invalid")
+
+	// ErrInvalidCharacter is returned when a descriptor contains a character outside the BIP380 charset
+	ErrInvalidCharacter = errors.New("Descriptor contains an invalid character")
+)
+
+// Checksum returns the 8 character BIP380 checksum of a descriptor without checksum
+func Checksum(desc string) (string, error) {
+	symbols, err := expand(desc)
+	if err != nil {
+		return "", err
+	}
+
+	c := polymod(append(symbols, make([]uint64, ChecksumLength)...)) ^ 1
+	result := make([]byte, ChecksumLength)
+	for i := range result {
+		result[i] = checksumCharset[c>>uint(5*(ChecksumLength-1-i))&31]
+	}
+	return string(result), nil
+}
+
+// AddChecksum returns desc followed by # and its checksum
+func AddChecksum(desc string) (string, error) {
+	c, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+	return desc + checksumSeparator + c, nil
+}
+
+// StripChecksum returns a descriptor without its checksum after verifying it.
+// Descriptors without a checksum are returned unchanged
+func StripChecksum(s string) (string, error) {
+	pos := strings.LastIndex(s, checksumSeparator)
+	if pos < 0 {
+		if _, err := expand(s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+
+	desc := s[:pos]
+	c, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+
+	if s[pos+1:] != c {
+		return "", ErrInvalidChecksum
+	}
+	return desc, nil
+}
+
+// expand converts descriptor characters into the symbols fed to the checksum
+func expand(desc string) ([]uint64, error) {
+	symbols := make([]uint64, 0, len(desc)+len(desc)/3+1)
+	groups := make([]uint64, 0, 3)
+	for i := 0; i < len(desc); i++ {
+		v := strings.IndexByte(inputCharset, desc[i])
+		if v < 0 {
+			return nil, ErrInvalidCharacter
+		}
+
+		// The low 5 bits are a symbol on their own, the classes (v >> 5) of every three characters are packed into one more
+		symbols = append(symbols, uint64(v&31))
+		groups = append(groups, uint64(v>>5))
+		if len(groups) == 3 {
+			symbols = append(symbols, groups[0]*9+groups[1]*3+groups[2])
+			groups = groups[:0]
+		}
+	}
+
+	switch len(groups) {
+	case 1:
+		symbols = append(symbols, groups[0])
+	case 2:
+		symbols = append(symbols, groups[0]*3+groups[1])
+	}
+	return symbols, nil
+}
+
+// polymod computes the BCH code over GF(32) used by descriptor checksums
+func polymod(symbols []uint64) uint64 {
+	gen := [5]uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+	chk := uint64(1)
+	for _, v := range symbols {
+		top := chk >> 35
+		chk = (chk&0x7ffffffff)<<5 ^ v
+		for i := 0; i < 5; i++ {
+			if top>>uint(i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
@@ -0,0 +1,68 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package descriptor
+
+import (
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	// Test vector ref: https://github.com/bitcoin/bips/blob/master/bip-0380.mediawiki#test-vectors
+	valid := map[string]string{
+		"raw(deadbeef)": "89f8spxm",
+		"pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)": "ml40v0wf",
+	}
+
+	for desc, checksum := range valid {
+		c, err := Checksum(desc)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if c != checksum {
+			t.Errorf("checksum of %s is not expected value want %s got %s", desc, checksum, c)
+		}
+
+		s, err := StripChecksum(desc + "#" + checksum)
+		if err != nil || s != desc {
+			t.Errorf("checksum of %s did not verify", desc)
+		}
+	}
+
+	invalid := []string{
+		"raw(deadbeef)#",
+		"raw(deadbeef)#89f8spxmx",
+		"raw(deadbeef)#89f8spx",
+		"raw(deadbeef)#89f8spxn",
+		"raw(deedbeef)#89f8spxm",
+		"raw(deadbeef)##9f8spxm",
+		"raw(Ü)#00000000",
+	}
+
+	for _, s := range invalid {
+		if _, err := StripChecksum(s); err == nil {
+			t.Errorf("%s verified where failure was expected", s)
+		}
+	}
+
+	if s, err := StripChecksum("raw(deadbeef)"); err != nil || s != "raw(deadbeef)" {
+		t.Error("descriptor without checksum was not returned unchanged")
+	}
+}
@@ -0,0 +1,208 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package descriptor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sanscentral/sanswallet/keys"
+)
+
+const (
+	// wildcard marks the last path step of a ranged descriptor (BIP380)
+	wildcard = "*"
+
+	// fingerprintLength is the number of hex characters of a key origin fingerprint
+	fingerprintLength = 8
+)
+
+var (
+	// ErrUnsupportedDescriptor is returned for descriptors other than pkh, sh(wpkh), wpkh and key-path only tr
+	ErrUnsupportedDescriptor = errors.New("Only pkh(), sh(wpkh()), wpkh() and tr() descriptors of a single extended key are supported")
+
+	// ErrInvalidKeyOrigin is returned when a key origin is not [fingerprint/path] with an 8 hex character fingerprint
+	ErrInvalidKeyOrigin = errors.New("Descriptor key origin is invalid")
+
+	// ErrInvalidExtendedKey is returned when a descriptor key is not an xpub/tpub (or xprv/tprv) extended key
+	ErrInvalidExtendedKey = errors.New("Descriptor key must be an xpub, tpub, xprv or tprv extended key")
+)
+
+// scriptFunctions maps script types to the descriptor functions wrapping their key, outermost first
+var scriptFunctions = map[keys.ScriptType][]string{
+	keys.ScriptP2PKH:        {"pkh"},
+	keys.ScriptP2WPKHInP2SH: {"sh", "wpkh"},
+	keys.ScriptP2WPKH:       {"wpkh"},
+	keys.ScriptP2TR:         {"tr"},
+}
+
+// KeyOrigin is the master key fingerprint and derivation path of a descriptor key
+type KeyOrigin struct {
+	Fingerprint uint32
+	Path        keys.DerivationPath
+}
+
+// Descriptor is a single key output descriptor such as wpkh([d34db33f/84'/0'/0']xpub.../0/*)
+type Descriptor struct {
+	ScriptType keys.ScriptType
+
+	// Origin is nil when the descriptor has no key origin
+	Origin *KeyOrigin
+
+	// Key is the base58 extended key (xpub/tpub or xprv/tprv)
+	Key string
+
+	// Path is derived from Key before the wildcard (e.g. 0 for /0/*)
+	Path keys.DerivationPath
+
+	// Ranged is true for descriptors ending in /*
+	Ranged bool
+}
+
+// String returns the descriptor with its checksum
+func (d *Descriptor) String() string {
+	s, _ := AddChecksum(d.format())
+	return s
+}
+
+// format returns the descriptor without checksum
+func (d *Descriptor) format() string {
+	key := d.Key + formatPath(d.Path)
+	if d.Ranged {
+		key += "/" + wildcard
+	}
+
+	if d.Origin != nil {
+		key = fmt.Sprintf("[%08x%s]%s", d.Origin.Fingerprint, formatPath(d.Origin.Path), key)
+	}
+
+	functions := scriptFunctions[d.ScriptType]
+	return strings.Join(functions, "(") + "(" + key + strings.Repeat(")", len(functions))
+}
+
+// Parse parses a pkh(), sh(wpkh()), wpkh() or tr() descriptor of one extended key.
+// The checksum is verified when present
+func Parse(s string) (*Descriptor, error) {
+	desc, err := StripChecksum(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+
+	for scriptType, functions := range scriptFunctions {
+		inner, ok := unwrap(desc, functions)
+		if !ok {
+			continue
+		}
+
+		d, err := parseKeyExpression(inner)
+		if err != nil {
+			return nil, err
+		}
+
+		d.ScriptType = scriptType
+		return d, nil
+	}
+	return nil, ErrUnsupportedDescriptor
+}
+
+// unwrap strips the nested functions from desc and returns the key expression inside them
+func unwrap(desc string, functions []string) (string, bool) {
+	for _, f := range functions {
+		if !strings.HasPrefix(desc, f+"(") || !strings.HasSuffix(desc, ")") {
+			return "", false
+		}
+		desc = desc[len(f)+1 : len(desc)-1]
+	}
+
+	// A remaining function or a second argument (e.g. a tr() script tree) is not a single key
+	if strings.ContainsAny(desc, "(),") {
+		return "", false
+	}
+	return desc, true
+}
+
+// parseKeyExpression parses [fingerprint/origin/path]xpub/path/* into a descriptor without script type
+func parseKeyExpression(expr string) (*Descriptor, error) {
+	d := &Descriptor{}
+	if strings.HasPrefix(expr, "[") {
+		end := strings.Index(expr, "]")
+		if end < 0 {
+			return nil, ErrInvalidKeyOrigin
+		}
+
+		origin, err := parseKeyOrigin(expr[1:end])
+		if err != nil {
+			return nil, err
+		}
+
+		d.Origin = origin
+		expr = expr[end+1:]
+	}
+
+	steps := strings.Split(expr, "/")
+	d.Key, steps = steps[0], steps[1:]
+
+	v, _, err := keys.GetExtendedKeyInfo(d.Key)
+	if err != nil || v.ScriptType != keys.ScriptP2PKH {
+		return nil, ErrInvalidExtendedKey
+	}
+
+	if len(steps) > 0 && steps[len(steps)-1] == wildcard {
+		d.Ranged = true
+		steps = steps[:len(steps)-1]
+	}
+
+	d.Path = keys.DerivationPath{}
+	if len(steps) > 0 {
+		d.Path, err = keys.ParseDerivationPath(strings.Join(steps, "/"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// parseKeyOrigin parses the fingerprint/path contents of a key origin
+func parseKeyOrigin(origin string) (*KeyOrigin, error) {
+	steps := strings.SplitN(origin, "/", 2)
+	if len(steps[0]) != fingerprintLength {
+		return nil, ErrInvalidKeyOrigin
+	}
+
+	fingerprint, err := strconv.ParseUint(steps[0], 16, 32)
+	if err != nil {
+		return nil, ErrInvalidKeyOrigin
+	}
+
+	o := &KeyOrigin{Fingerprint: uint32(fingerprint), Path: keys.DerivationPath{}}
+	if len(steps) == 2 {
+		o.Path, err = keys.ParseDerivationPath(steps[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// formatPath formats path steps relative to a key (e.g. /84'/0'/0'), empty for an empty path
+func formatPath(p keys.DerivationPath) string {
+	return strings.TrimPrefix(p.String(), "m")
+}
@@ -0,0 +1,109 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package descriptor
+
+import (
+	"testing"
+
+	"github.com/sanscentral/sanswallet/keys"
+)
+
+const (
+	// BIP84 account 0 of the abandon ... about mnemonic, master fingerprint 73c5da0a
+	testAccountPub  = "xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V"
+	testDescriptor  = "wpkh([73c5da0a/84'/0'/0']" + testAccountPub + "/0/*)#wc3n3van"
+	testFingerprint = 0x73c5da0a
+)
+
+func TestParse(t *testing.T) {
+	d, err := Parse(testDescriptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.ScriptType != keys.ScriptP2WPKH || d.Key != testAccountPub || !d.Ranged {
+		t.Errorf("parsed descriptor is not expected value, got %s %s ranged %t", d.ScriptType, d.Key, d.Ranged)
+	}
+
+	if d.Origin == nil || d.Origin.Fingerprint != testFingerprint || d.Origin.Path.String() != "m/84'/0'/0'" {
+		t.Errorf("parsed key origin is not expected value, got %+v", d.Origin)
+	}
+
+	if len(d.Path) != 1 || d.Path[0] != 0 {
+		t.Errorf("parsed key path is not expected value, got %v", d.Path)
+	}
+
+	if d.String() != testDescriptor {
+		t.Errorf("descriptor formatted again as %s", d.String())
+	}
+
+	// Every script type must survive a round trip, with and without key origin
+	for scriptType, functions := range scriptFunctions {
+		for _, origin := range []*KeyOrigin{nil, {Fingerprint: 0xd34db33f, Path: keys.DerivationPath{keys.HardenedKeyZeroIndex + 44, 1}}} {
+			in := &Descriptor{ScriptType: scriptType, Origin: origin, Key: testAccountPub, Path: keys.DerivationPath{1}, Ranged: true}
+			out, err := Parse(in.String())
+			if err != nil {
+				t.Errorf("%s descriptor did not parse: %s", functions[0], err.Error())
+				continue
+			}
+
+			if out.String() != in.String() {
+				t.Errorf("%s descriptor changed in round trip want %s got %s", functions[0], in.String(), out.String())
+			}
+		}
+	}
+
+	// h is accepted as hardened marker and checksums are optional
+	d, err = Parse("sh(wpkh([73C5DA0A/49h/0h/0h]" + testAccountPub + "))")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.ScriptType != keys.ScriptP2WPKHInP2SH || d.Ranged || len(d.Path) != 0 || d.Origin.Path.String() != "m/49'/0'/0'" {
+		t.Errorf("unranged descriptor is not expected value, got %s", d.String())
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"raw(deadbeef)",
+		"wpkh(" + testAccountPub + "/0/*)#00000000",
+		"wpkh(" + testAccountPub + "/0/*",
+		"wsh(" + testAccountPub + "/0/*)",
+		"sh(" + testAccountPub + "/0/*)",
+		"wpkh(sh(" + testAccountPub + "/0/*))",
+		"tr(" + testAccountPub + "/0/*,pk(" + testAccountPub + "/1/*))",
+		"wpkh([73c5da0/84'/0'/0']" + testAccountPub + "/0/*)",
+		"wpkh([73c5da0g/84'/0'/0']" + testAccountPub + "/0/*)",
+		"wpkh([73c5da0a/84'/0'/0'" + testAccountPub + "/0/*)",
+		"wpkh([73c5da0a/84'/x/0']" + testAccountPub + "/0/*)",
+		"wpkh(" + testAccountPub[:len(testAccountPub)-1] + "/0/*)",
+		"wpkh(zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs/0/*)",
+		"wpkh(0330d54fd0dd420a6e5f8d3624f5f3482cae350f79d5f0753bf5beef9c2d91af3c)",
+		"wpkh(" + testAccountPub + "/0//*)",
+		"wpkh(" + testAccountPub + "/0/*/1)",
+	}
+
+	for _, s := range invalid {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%s parsed where failure was expected", s)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sanscentral/sanswallet/descriptor"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+const (
+	// Descriptors of account 0 for the test seed (master fingerprint 73c5da0a)
+	testP2WPKHDescriptor = "wpkh([73c5da0a/84'/0'/0']xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/0/*)#wc3n3van"
+	testP2TRDescriptor   = "tr([73c5da0a/86'/0'/0']xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ/0/*)#rg247h69"
+)
+
+func TestGetDescriptorForAccount(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	d, err := GetDescriptorForAccount(seed, 84, 0, false, testIsTestnet)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if d != testP2WPKHDescriptor {
+		t.Errorf("P2WPKH descriptor is not expected value want\n%s \ngot \n%s", testP2WPKHDescriptor, d)
+	}
+
+	d, err = GetDescriptorForAccount(seed, 86, 0, false, testIsTestnet)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if d != testP2TRDescriptor {
+		t.Errorf("P2TR descriptor is not expected value want\n%s \ngot \n%s", testP2TRDescriptor, d)
+	}
+
+	// Descriptors must lead back to the same addresses as the seed account
+	for _, purpose := range []int{44, 49, 84, 86} {
+		for _, isChange := range []bool{false, true} {
+			d, err := GetDescriptorForAccount(seed, purpose, 0, isChange, testIsTestnet)
+			if err != nil {
+				t.Error(err.Error())
+				continue
+			}
+
+			account, err := NewAccountFromDescriptor(d)
+			if err != nil {
+				t.Errorf("descriptor %s did not parse: %s", d, err.Error())
+				continue
+			}
+
+			// Address derives from the chain of the descriptor
+			seedAccount, _ := NewAccountFromSeed(seed, purpose, 0, testIsTestnet)
+			for _, index := range []int{0, 1, 10} {
+				a, _ := account.Address(index)
+				expected, _ := seedAccount.AddressRange(index, 1, isChange, 1)
+				if len(expected) != 1 {
+					t.Fatalf("seed account gave no address %d", index)
+				}
+				if a != expected[0] {
+					t.Errorf("descriptor %s address %d is not expected value want %s got %s", d, index, expected[0], a)
+				}
+			}
+
+			if again, _ := account.Descriptor(isChange); again != d {
+				t.Errorf("descriptor changed in round trip want %s got %s", d, again)
+			}
+
+			if account.IsPrivate() {
+				t.Errorf("descriptor %s gave a private account", d)
+			}
+		}
+	}
+}
+
+func TestNewAccountFromDescriptor(t *testing.T) {
+	account, err := NewAccountFromDescriptor(testP2WPKHDescriptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address, err := account.Address(1)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if address != testP2WPKH1 {
+		t.Errorf("descriptor address 1 is not expected value want %s got %s", testP2WPKH1, address)
+	}
+
+	// Accounts created from a bare extended key have no key origin
+	account, err = NewAccountFromExtendedKey(testP2WPKHPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := account.Descriptor(true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if d != "wpkh(xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/1/*)#"+mustChecksum(t, "wpkh(xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/1/*)") {
+		t.Errorf("descriptor without key origin is not expected value, got %s", d)
+	}
+
+	invalid := []string{
+		"wpkh([73c5da0a/84'/0'/0']xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/2/*)",
+		"wpkh([73c5da0a/84'/0'/0']xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/0)",
+		"wpkh([73c5da0a/84'/0'/0']xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/*)",
+		"wpkh([73c5da0a/84'/0'/0']xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/0/1/*)",
+		testP2WPKHDescriptor[:len(testP2WPKHDescriptor)-1] + "x",
+	}
+
+	for _, d := range invalid {
+		if _, err := NewAccountFromDescriptor(d); err == nil {
+			t.Errorf("descriptor %s did not fail where expected", d)
+		}
+	}
+}
+
+func TestNewAccountFromDescriptorOnNetwork(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Regtest and signet descriptors carry tpub keys and must not come back as testnet accounts
+	for _, net := range []network.Network{network.BTCRegtest, network.BTCSignet} {
+		for _, purpose := range []int{44, 49, 84, 86} {
+			seedAccount, err := NewAccountFromSeedOnNetwork(seed, purpose, 0, net)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			d, err := seedAccount.Descriptor(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			account, err := NewAccountFromDescriptorOnNetwork(d, net)
+			if err != nil {
+				t.Errorf("descriptor %s did not parse on %s: %s", d, net, err.Error())
+				continue
+			}
+
+			if account.Network() != net {
+				t.Errorf("descriptor %s account network is %s want %s", d, account.Network(), net)
+			}
+
+			expected, err := seedAccount.AddressRange(0, 5, false, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			addresses, err := account.AddressRange(0, 5, false, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range expected {
+				if addresses[i] != expected[i] {
+					t.Errorf("descriptor %s address %d is not expected value want %s got %s", d, i, expected[i], addresses[i])
+				}
+			}
+		}
+	}
+
+	account, err := NewAccountFromSeedOnNetwork(seed, 84, 0, network.BTCRegtest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := account.Descriptor(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if address, _ := account.Address(0); address != testRegtestP2WPKH0 {
+		t.Errorf("regtest address 0 is not expected value want %s got %s", testRegtestP2WPKH0, address)
+	}
+
+	if _, err := NewAccountFromDescriptorOnNetwork(d, network.BTCMainnet); err == nil {
+		t.Error("tpub descriptor on mainnet did not fail where expected")
+	}
+
+	if _, err := NewAccountFromDescriptorOnNetwork(testP2WPKHDescriptor, network.BTCRegtest); err == nil {
+		t.Error("xpub descriptor on regtest did not fail where expected")
+	}
+}
+
+func TestNewAccountFromChangeDescriptor(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seedAccount, err := NewAccountFromSeed(seed, 84, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := seedAccount.Descriptor(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := NewAccountFromDescriptor(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := seedAccount.AddressRange(0, 5, true, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A /1/* descriptor gives the change addresses whichever chain is asked for
+	for _, isChange := range []bool{false, true} {
+		addresses, err := account.AddressRange(0, 5, isChange, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range expected {
+			if addresses[i] != expected[i] {
+				t.Errorf("change descriptor address %d is not expected value want %s got %s", i, expected[i], addresses[i])
+			}
+		}
+	}
+
+	if address, _ := account.Address(2); address != expected[2] {
+		t.Errorf("change descriptor address 2 is not expected value want %s got %s", expected[2], address)
+	}
+
+	// Only the change chain of a /1/* descriptor account is scanned
+	backend := NewMemoryChainBackend()
+	backend.AddTransaction(expected[1], "tx-change")
+	scan, err := account.Scan(backend, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scan.Used) != 1 || !scan.Used[0].IsChange || scan.Used[0].Index != 1 || scan.NextChangeIndex != 2 || scan.NextExternalIndex != 0 {
+		t.Errorf("change descriptor scan is not expected value, got %d used addresses next change index %d", len(scan.Used), scan.NextChangeIndex)
+	}
+}
+
+// mustChecksum returns the descriptor checksum of desc
+func mustChecksum(t *testing.T, desc string) string {
+	c, err := descriptor.Checksum(desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
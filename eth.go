@@ -0,0 +1,81 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"github.com/sanscentral/sanswallet/ethereum"
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// GetExtPrvForETHAccount returns extended private key for Ethereum BIP44 account (m/44'/60'/account').
+// The key uses the Bitcoin mainnet xprv version as Ethereum defines none of its own
+func GetExtPrvForETHAccount(seed []byte, accountIndex int) (string, error) {
+	return getETHAccountKey(seed, accountIndex, true)
+}
+
+// GetExtPubForETHAccount returns extended public key for Ethereum BIP44 account (m/44'/60'/account')
+func GetExtPubForETHAccount(seed []byte, accountIndex int) (string, error) {
+	return getETHAccountKey(seed, accountIndex, false)
+}
+
+// GetETHAddressForIndex returns the EIP-55 checksummed Ethereum address for account extended key at given index (m/44'/60'/account'/0/index)
+func GetETHAddressForIndex(accountKey string, addressIndex int) (string, error) {
+	if err := checkAccountKeyVersion(accountKey, keys.ScriptP2PKH, network.BTCMainnet); err != nil {
+		return "", err
+	}
+
+	index, err := intToUint32(addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	k, err := keys.GetAccountAddressKey(accountKey, keys.ExternalAddress, index)
+	if err != nil {
+		return "", err
+	}
+
+	pk, err := k.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+
+	return ethereum.PublicKeyToAddress(pk), nil
+}
+
+// ValidateETHAddress returns an error unless address is a valid Ethereum address.
+// Mixed case addresses must match their EIP-55 checksum
+func ValidateETHAddress(address string) error {
+	return ethereum.ValidateAddress(address)
+}
+
+// getETHAccountKey returns the extended key of the Ethereum account at accountIndex
+func getETHAccountKey(seed []byte, accountIndex int, includePrivateKey bool) (string, error) {
+	index, err := intToUint32(accountIndex)
+	if err != nil {
+		return "", err
+	}
+
+	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, network.BTCMainnet)
+	if err != nil {
+		return "", err
+	}
+
+	return keys.GetAccountKey(m, keys.BIP44Purpose, keys.ETHCoinType, index, includePrivateKey)
+}
@@ -0,0 +1,76 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+const (
+	// Ethereum m/44'/60'/0'/0/i ref: MetaMask and Ledger accounts of the test mnemonic
+	testETH0 = "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"
+	testETH1 = "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"
+)
+
+func TestETHAddressGeneration(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	pub, err := GetExtPubForETHAccount(seed, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, err := GetExtPrvForETHAccount(seed, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{pub, priv} {
+		for i, expected := range []string{testETH0, testETH1} {
+			address, err := GetETHAddressForIndex(key, i)
+			if err != nil {
+				t.Error(err.Error())
+			}
+
+			if address != expected {
+				t.Errorf("ETH address %d is not expected value want %s got %s", i, expected, address)
+			}
+
+			if err := ValidateETHAddress(address); err != nil {
+				t.Error(err.Error())
+			}
+		}
+	}
+
+	if err := ValidateETHAddress("0x9858effd232b4033e47d90003d41ec34ecaeda94"); err != nil {
+		t.Error("lower case address did not validate")
+	}
+
+	if err := ValidateETHAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEdA94"); err == nil {
+		t.Error("address with wrong checksum did not fail where expected")
+	}
+
+	if _, err := GetETHAddressForIndex(testP2WPKHPub, 0); err == nil {
+		t.Error("zpub key did not fail for ETH address where expected")
+	}
+}
@@ -0,0 +1,114 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ethereum
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+
+	"github.com/sanscentral/sanswallet/internal/keccak"
+)
+
+const (
+	// addressLength is the number of bytes of an address, the last 20 bytes of the keccak-256 of the public key
+	addressLength = 20
+
+	// prefix starts every hex encoded address
+	prefix = "0x"
+)
+
+var (
+	// ErrInvalidAddress is returned when an address is not 0x followed by 40 hex characters
+	ErrInvalidAddress = errors.New("Ethereum address must be 0x followed by 40 hex characters")
+
+	// ErrInvalidChecksum is returned when the mixed case of an address does not match its EIP-55 checksum
+	ErrInvalidChecksum = errors.New("Ethereum address EIP-55 checksum is invalid")
+)
+
+// PublicKeyToAddress returns the EIP-55 checksummed address of a secp256k1 public key
+func PublicKeyToAddress(pub *btcec.PublicKey) string {
+	// The hash covers the 64 byte X || Y coordinates, without the 0x04 uncompressed marker
+	h := keccak.Sum256(pub.SerializeUncompressed()[1:])
+	return checksum(hex.EncodeToString(h[len(h)-addressLength:]))
+}
+
+// ToChecksumAddress returns an address with the EIP-55 mixed case checksum, whatever case it is given in
+func ToChecksumAddress(address string) (string, error) {
+	h, err := addressHex(address)
+	if err != nil {
+		return "", err
+	}
+	return checksum(strings.ToLower(h)), nil
+}
+
+// ValidateAddress returns an error unless address is 0x followed by 40 hex characters.
+// Mixed case addresses must match their EIP-55 checksum, all lower or all upper case addresses carry no checksum and are accepted
+func ValidateAddress(address string) error {
+	h, err := addressHex(address)
+	if err != nil {
+		return err
+	}
+
+	if h == strings.ToLower(h) || h == strings.ToUpper(h) {
+		return nil
+	}
+
+	if checksum(strings.ToLower(h)) != prefix+h {
+		return ErrInvalidChecksum
+	}
+	return nil
+}
+
+// addressHex returns the 40 hex characters of an address after checking them
+func addressHex(address string) (string, error) {
+	if !strings.HasPrefix(address, prefix) {
+		return "", ErrInvalidAddress
+	}
+
+	h := address[len(prefix):]
+	if len(h) != addressLength*2 {
+		return "", ErrInvalidAddress
+	}
+
+	if _, err := hex.DecodeString(h); err != nil {
+		return "", ErrInvalidAddress
+	}
+	return h, nil
+}
+
+// checksum applies EIP-55 to 40 lower case hex characters: a letter is upper cased when the matching
+// nibble of the keccak-256 of the lower case hex string is 8 or more
+func checksum(lower string) string {
+	h := keccak.Sum256([]byte(lower))
+	out := []byte(lower)
+	for i, c := range out {
+		nibble := h[i/2] >> 4
+		if i%2 == 1 {
+			nibble = h[i/2] & 0x0f
+		}
+
+		if c >= 'a' && nibble >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return prefix + string(out)
+}
@@ -0,0 +1,89 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ethereum
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// Test vector ref: https://github.com/ethereum/EIPs/blob/master/EIPS/eip-55.md#test-cases
+var testChecksumAddresses = []string{
+	"0x52908400098527886E0F7030069857D2E4169EE7",
+	"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+	"0xde709f2102306220921060314715629080e2fb77",
+	"0x27b1fdb04752bbc536007a920d24acb045561c26",
+	"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+}
+
+func TestToChecksumAddress(t *testing.T) {
+	for _, a := range testChecksumAddresses {
+		for _, input := range []string{a, "0x" + strings.ToLower(a[2:]), "0x" + strings.ToUpper(a[2:])} {
+			c, err := ToChecksumAddress(input)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			if c != a {
+				t.Errorf("checksum address of %s is not expected value want %s got %s", input, a, c)
+			}
+		}
+
+		if err := ValidateAddress(a); err != nil {
+			t.Errorf("%s did not validate: %s", a, err)
+		}
+	}
+}
+
+func TestValidateAddressInvalid(t *testing.T) {
+	invalid := []struct {
+		address string
+		err     error
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", ErrInvalidChecksum},
+		{"0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ErrInvalidChecksum},
+		{"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ErrInvalidAddress},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", ErrInvalidAddress},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", ErrInvalidAddress},
+		{"", ErrInvalidAddress},
+	}
+
+	for _, v := range invalid {
+		if err := ValidateAddress(v.address); err != v.err {
+			t.Errorf("%q did not fail with expected error, got %v", v.address, err)
+		}
+	}
+}
+
+func TestPublicKeyToAddress(t *testing.T) {
+	// Private key 1 is the generator point, its address is well known
+	b, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	_, pub := btcec.PrivKeyFromBytes(btcec.S256(), b)
+
+	if a := PublicKeyToAddress(pub); a != "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf" {
+		t.Errorf("address of private key 1 is not expected value, got %s", a)
+	}
+}
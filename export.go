@@ -0,0 +1,107 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// AccountKeyExport is an account extended key with the key origin metadata hardware wallets, PSBTs and descriptors need
+type AccountKeyExport struct {
+	// ExtendedKey is the account key with the SLIP-132 version of its script type (e.g. zpub for P2WPKH)
+	ExtendedKey string
+
+	// Fingerprint is the hex encoded master key fingerprint, empty when unknown
+	Fingerprint string
+
+	// Path is the full derivation path of the account key (e.g. m/84'/0'/0'), empty when unknown
+	Path string
+
+	// ScriptType is the output script of the account addresses (P2PKH, P2SH-P2WPKH, P2WPKH or P2TR)
+	ScriptType string
+
+	// Network is the name of the account network (mainnet, testnet, regtest, signet, litecoin, dogecoin or bitcoincash)
+	Network string
+}
+
+// GetMasterFingerprint returns the hex encoded BIP32 fingerprint of the master key of seed (e.g. 73c5da0a)
+func GetMasterFingerprint(seed []byte) (string, error) {
+	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, network.BTCMainnet)
+	if err != nil {
+		return "", err
+	}
+
+	fingerprint, err := keys.GetMasterFingerprint(m)
+	if err != nil {
+		return "", err
+	}
+	return formatFingerprint(fingerprint), nil
+}
+
+// GetAccountKeyExport returns the extended key of the account at accountIndex for a BIP purpose (44, 49, 84 or 86)
+// together with its master fingerprint, derivation path, script type and network
+func GetAccountKeyExport(seed []byte, purpose int, accountIndex int, includePrivateKey bool, testnet bool) (*AccountKeyExport, error) {
+	return GetAccountKeyExportOnNetwork(seed, purpose, accountIndex, includePrivateKey, testnetToNetwork(testnet))
+}
+
+// GetAccountKeyExportOnNetwork returns the extended key and key origin metadata of the account at accountIndex on net
+func GetAccountKeyExportOnNetwork(seed []byte, purpose int, accountIndex int, includePrivateKey bool, net network.Network) (*AccountKeyExport, error) {
+	a, err := NewAccountFromSeedOnNetwork(seed, purpose, accountIndex, net)
+	if err != nil {
+		return nil, err
+	}
+	return a.KeyExport(includePrivateKey)
+}
+
+// KeyExport returns the account extended key with its key origin, script type and network.
+// Fingerprint and Path are empty for accounts created from a bare extended key
+func (a *Account) KeyExport(includePrivateKey bool) (*AccountKeyExport, error) {
+	k := a.key
+	if !includePrivateKey {
+		var err error
+		k, err = k.Neuter()
+		if err != nil {
+			return nil, err
+		}
+	} else if !k.IsPrivate() {
+		return nil, hdkeychain.ErrNotPrivExtKey
+	}
+
+	xKey, err := getVersionedAccountKey(k.String(), a.scriptType, a.net, includePrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &AccountKeyExport{ExtendedKey: xKey, ScriptType: a.scriptType.String(), Network: a.net.String()}
+	if a.origin != nil {
+		e.Fingerprint = formatFingerprint(a.origin.Fingerprint)
+		e.Path = a.origin.Path.String()
+	}
+	return e, nil
+}
+
+// formatFingerprint returns a key fingerprint as 8 hex characters
+func formatFingerprint(fingerprint uint32) string {
+	return fmt.Sprintf("%08x", fingerprint)
+}
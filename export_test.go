@@ -0,0 +1,100 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+const (
+	// Master fingerprint of the test seed
+	testFingerprint = "73c5da0a"
+)
+
+func TestGetMasterFingerprint(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	fingerprint, err := GetMasterFingerprint(seed)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if fingerprint != testFingerprint {
+		t.Errorf("master fingerprint is not expected value want %s got %s", testFingerprint, fingerprint)
+	}
+
+	if _, err := GetMasterFingerprint([]byte{1, 2, 3}); err == nil {
+		t.Error("short seed did not fail where expected")
+	}
+}
+
+func TestGetAccountKeyExport(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	exports := []struct {
+		purpose           int
+		includePrivateKey bool
+		testnet           bool
+		expected          AccountKeyExport
+	}{
+		{44, false, false, AccountKeyExport{testP2PKHPub, testFingerprint, "m/44'/0'/0'", "P2PKH", "mainnet"}},
+		{49, true, false, AccountKeyExport{testP2SHPriv, testFingerprint, "m/49'/0'/0'", "P2SH-P2WPKH", "mainnet"}},
+		{84, false, false, AccountKeyExport{testP2WPKHPub, testFingerprint, "m/84'/0'/0'", "P2WPKH", "mainnet"}},
+		{84, true, true, AccountKeyExport{testTestnetP2WPKHPriv, testFingerprint, "m/84'/0'/0'", "P2WPKH", "testnet"}},
+		{86, false, false, AccountKeyExport{testP2TRPub, testFingerprint, "m/86'/0'/0'", "P2TR", "mainnet"}},
+	}
+
+	for _, e := range exports {
+		export, err := GetAccountKeyExport(seed, e.purpose, 0, e.includePrivateKey, e.testnet)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		if *export != e.expected {
+			t.Errorf("account export for purpose %d is not expected value want\n%+v \ngot \n%+v", e.purpose, e.expected, *export)
+		}
+	}
+
+	// Bare extended keys carry no key origin and public accounts cannot export a private key
+	account, err := NewAccountFromExtendedKey(testP2WPKHPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	export, err := account.KeyExport(false)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if export.ExtendedKey != testP2WPKHPub || export.Fingerprint != "" || export.Path != "" {
+		t.Errorf("account export without key origin is not expected value, got %+v", *export)
+	}
+
+	if _, err := account.KeyExport(true); err == nil {
+		t.Error("private export of a public account did not fail where expected")
+	}
+}
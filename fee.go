@@ -0,0 +1,135 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/sanscentral/sanswallet/network"
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+// ErrUnknownScriptType is returned for input or output type names the fee estimator does not know
+var ErrUnknownScriptType = errors.New("Unknown script type")
+
+// FeeEstimator estimates the virtual size and fee of a transaction from the types of its inputs and outputs
+type FeeEstimator struct {
+	e txsize.Estimator
+}
+
+// NewFeeEstimator returns an estimator of a transaction without inputs and outputs
+func NewFeeEstimator() *FeeEstimator {
+	return &FeeEstimator{}
+}
+
+// AddInput adds the input spending a single key output of scriptType (P2PKH, P2SH-P2WPKH, P2WPKH or P2TR)
+func (f *FeeEstimator) AddInput(scriptType string) error {
+	s, err := parseScriptType(scriptType)
+	if err != nil {
+		return err
+	}
+	return f.e.AddInput(s)
+}
+
+// AddMultisigInput adds the input spending an m-of-n multisig output of scriptType (P2SH, P2SH-P2WSH or P2WSH)
+func (f *FeeEstimator) AddMultisigInput(scriptType string, m int, n int) error {
+	if scriptType == AddressP2SH {
+		return f.e.AddMultisigInput(network.ScriptP2PKH, m, n)
+	}
+
+	s, err := parseScriptType(scriptType)
+	if err != nil {
+		return err
+	}
+	return f.e.AddMultisigInput(s, m, n)
+}
+
+// AddOutput adds an output of outputType (P2PKH, P2SH, P2WPKH, P2WSH or P2TR)
+func (f *FeeEstimator) AddOutput(outputType string) error {
+	t, err := parseOutputType(outputType)
+	if err != nil {
+		return err
+	}
+	return f.e.AddOutput(t)
+}
+
+// AddOutputAddress adds an output paying to a mainnet or testnet address
+func (f *FeeEstimator) AddOutputAddress(address string, testnet bool) error {
+	info, err := DecodeAddress(address, testnet)
+	if err != nil {
+		return err
+	}
+
+	script, err := hex.DecodeString(info.ScriptPubKey)
+	if err != nil {
+		return err
+	}
+
+	f.e.AddOutputScript(script)
+	return nil
+}
+
+// Weight returns the weight of the transaction, assuming the largest (72 byte) ECDSA signatures
+func (f *FeeEstimator) Weight() int64 {
+	return f.e.Weight()
+}
+
+// VSize returns the virtual size of the transaction in vbytes
+func (f *FeeEstimator) VSize() int64 {
+	return f.e.VSize()
+}
+
+// Fee returns the fee in satoshis of the transaction at feeRate sat/vB
+func (f *FeeEstimator) Fee(feeRate int64) (int64, error) {
+	if feeRate < 0 {
+		return 0, ErrInvalidFeeRate
+	}
+	return f.e.Fee(feeRate), nil
+}
+
+// GetDustThreshold returns the smallest amount in satoshis an output of outputType (P2PKH, P2SH, P2WPKH, P2WSH or P2TR)
+// may hold under the default relay policy
+func GetDustThreshold(outputType string) (int64, error) {
+	t, err := parseOutputType(outputType)
+	if err != nil {
+		return 0, err
+	}
+	return txsize.DustThreshold(t)
+}
+
+// parseScriptType returns the script type named name (P2PKH, P2SH-P2WPKH, P2WPKH, P2SH-P2WSH, P2WSH or P2TR)
+func parseScriptType(name string) (network.ScriptType, error) {
+	for s := network.ScriptP2PKH; s <= network.ScriptP2TR; s++ {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+	return 0, ErrUnknownScriptType
+}
+
+// parseOutputType returns the output type of an address type name
+func parseOutputType(name string) (txsize.OutputType, error) {
+	for t := txsize.OutputP2PKH; t <= txsize.OutputP2TR; t++ {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return 0, ErrUnknownScriptType
+}
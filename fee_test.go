@@ -0,0 +1,148 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestFeeEstimator(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	utxos := []*UTXO{
+		{TxID: testFundingTxID, Vout: 0, Amount: 60000, AddressIndex: 0},
+		{TxID: testFundingTxID, Vout: 3, Amount: 45000, AddressIndex: 2, IsChange: true},
+	}
+	recipients := []*Recipient{{testP2PK1, 30000}, {testP2WPKH10, 40000}}
+
+	// The estimate of the account transaction gives the fee BuildTransaction pays and bounds its size
+	for purpose, scriptType := range map[int]string{44: "P2PKH", 49: "P2SH-P2WPKH", 84: "P2WPKH", 86: "P2TR"} {
+		account, err := NewAccountFromSeed(seed, purpose, 0, testIsTestnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signed, err := account.BuildTransaction(utxos, recipients, testFeeRate, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f := NewFeeEstimator()
+		for range utxos {
+			if err := f.AddInput(scriptType); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, r := range recipients {
+			if err := f.AddOutputAddress(r.Address, testIsTestnet); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		change, _ := account.ChangeAddress(0)
+		if err := f.AddOutputAddress(change, testIsTestnet); err != nil {
+			t.Fatal(err)
+		}
+
+		fee, err := f.Fee(testFeeRate)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if fee != signed.Fee {
+			t.Errorf("%s estimated fee is %d want %d", scriptType, fee, signed.Fee)
+		}
+
+		if f.VSize() < int64(signed.VSize) || f.VSize() > int64(signed.VSize)+2 {
+			t.Errorf("%s estimated vsize %d is not an upper bound of %d", scriptType, f.VSize(), signed.VSize)
+		}
+	}
+
+	// A 2-of-3 P2WSH and a P2TR input paying a P2WSH and a P2SH output
+	f := NewFeeEstimator()
+	if err := f.AddMultisigInput("P2WSH", 2, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.AddInput("P2TR"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, outputType := range []string{AddressP2WSH, AddressP2SH} {
+		if err := f.AddOutput(outputType); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if f.Weight() != 990 || f.VSize() != 248 {
+		t.Errorf("multisig transaction weight is %d and vsize %d want 990 and 248", f.Weight(), f.VSize())
+	}
+
+	if fee, _ := f.Fee(2); fee != 496 {
+		t.Errorf("multisig transaction fee is %d want 496", fee)
+	}
+
+	if _, err := f.Fee(-1); err != ErrInvalidFeeRate {
+		t.Error("negative fee rate did not fail where expected")
+	}
+
+	if err := f.AddInput("P2WSH"); err == nil {
+		t.Error("multisig script type as single key input did not fail where expected")
+	}
+
+	if err := f.AddMultisigInput("P2SH", 2, 16); err == nil {
+		t.Error("redeem script above the P2SH limit did not fail where expected")
+	}
+
+	if err := f.AddOutput("P2PK"); err != ErrUnknownScriptType {
+		t.Error("unknown output type did not fail where expected")
+	}
+}
+
+func TestDustThreshold(t *testing.T) {
+	thresholds := map[string]int64{testP2PK0: 546, testP2SH0: 540, testP2WPKH0: 294, testP2TR0: 330}
+	for address, expected := range thresholds {
+		info, err := DecodeAddress(address, testIsTestnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d, err := GetDustThreshold(info.Type)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if d != expected {
+			t.Errorf("%s dust threshold is %d want %d", info.Type, d, expected)
+		}
+	}
+
+	if d, _ := GetDustThreshold(AddressP2WSH); d != 330 {
+		t.Errorf("P2WSH dust threshold is %d want 330", d)
+	}
+
+	if _, err := GetDustThreshold(AddressWitnessUnknown); err != ErrUnknownScriptType {
+		t.Error("unknown output type did not fail where expected")
+	}
+}
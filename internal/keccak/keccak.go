@@ -0,0 +1,136 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package keccak implements the original Keccak-256 hash used by Ethereum.
+// It differs from the standardized SHA3-256 only in the padding byte (0x01 instead of 0x06)
+package keccak
+
+const (
+	// Size is the length of a Keccak-256 digest in bytes
+	Size = 32
+
+	// rate is the number of bytes absorbed per permutation (1600 - 2 * 256 bits)
+	rate = 136
+
+	// rounds of the Keccak-f[1600] permutation
+	rounds = 24
+)
+
+// roundConstants are XORed into the first lane in the iota step of each round
+var roundConstants = [rounds]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// rotations are the rho step rotation offsets and piLanes the lane order of the pi step
+var (
+	rotations = [24]uint{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	piLanes   = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// Sum256 returns the Keccak-256 digest of the concatenated data
+func Sum256(data ...[]byte) [Size]byte {
+	var state [25]uint64
+	var block [rate]byte
+	n := 0
+	for _, d := range data {
+		for len(d) > 0 {
+			c := copy(block[n:], d)
+			n += c
+			d = d[c:]
+			if n == rate {
+				absorb(&state, &block)
+				n = 0
+			}
+		}
+	}
+
+	// Keccak padding: 0x01 after the message and 0x80 in the last byte of the block
+	for i := n; i < rate; i++ {
+		block[i] = 0
+	}
+	block[n] |= 0x01
+	block[rate-1] |= 0x80
+	absorb(&state, &block)
+
+	var digest [Size]byte
+	for i := 0; i < Size/8; i++ {
+		for b := 0; b < 8; b++ {
+			digest[i*8+b] = byte(state[i] >> uint(8*b))
+		}
+	}
+	return digest
+}
+
+// absorb XORs a block into the state as little endian lanes and permutes it
+func absorb(state *[25]uint64, block *[rate]byte) {
+	for i := 0; i < rate/8; i++ {
+		var lane uint64
+		for b := 0; b < 8; b++ {
+			lane |= uint64(block[i*8+b]) << uint(8*b)
+		}
+		state[i] ^= lane
+	}
+	permute(state)
+}
+
+// permute applies Keccak-f[1600] to the state
+func permute(a *[25]uint64) {
+	var c [5]uint64
+	for round := 0; round < rounds; round++ {
+		// theta
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ rotl(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+
+		// rho and pi
+		t := a[1]
+		for i := 0; i < 24; i++ {
+			j := piLanes[i]
+			t, a[j] = a[j], rotl(t, rotations[i])
+		}
+
+		// chi
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				c[x] = a[y+x]
+			}
+			for x := 0; x < 5; x++ {
+				a[y+x] = c[x] ^ (^c[(x+1)%5] & c[(x+2)%5])
+			}
+		}
+
+		// iota
+		a[0] ^= roundConstants[round]
+	}
+}
+
+// rotl rotates a lane left by n bits
+func rotl(v uint64, n uint) uint64 {
+	return v<<n | v>>(64-n)
+}
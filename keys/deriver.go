@@ -0,0 +1,153 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package keys
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+// ErrAddressRangeOverflow is returned when an address range extends into hardened indexes
+var ErrAddressRangeOverflow = errors.New("Address range exceeds the non-hardened index space")
+
+// AccountDeriver derives address keys of one account (m / purpose' / coin_type' / account') while keeping the parsed
+// account key and its change/external chain keys, so each address only costs the final child derivation.
+// It is safe for concurrent use
+type AccountDeriver struct {
+	account *hdkeychain.ExtendedKey
+
+	mu     sync.Mutex
+	chains map[AddressType]*hdkeychain.ExtendedKey
+}
+
+// NewAccountDeriver returns a deriver for an account extended key
+func NewAccountDeriver(account *hdkeychain.ExtendedKey) *AccountDeriver {
+	return &AccountDeriver{account: account, chains: make(map[AddressType]*hdkeychain.ExtendedKey, 2)}
+}
+
+// NewAccountDeriverFromString returns a deriver for a base58-encoded account extended key
+func NewAccountDeriverFromString(xKey string) (*AccountDeriver, error) {
+	account, err := GetExtendedKeyFromString(xKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewAccountDeriver(account), nil
+}
+
+// AddressKey returns the key at addressIndex on the change or external chain (same result as GetAccountAddressKey)
+func (d *AccountDeriver) AddressKey(change AddressType, addressIndex uint32) (*hdkeychain.ExtendedKey, error) {
+	chain, err := d.chain(change)
+	if err != nil {
+		return nil, err
+	}
+	return chain.Child(addressIndex)
+}
+
+// AddressKeys returns count keys starting at start on the change or external chain.
+// Derivation is split across workers goroutines, a value below 2 derives serially
+func (d *AccountDeriver) AddressKeys(change AddressType, start uint32, count int, workers int) ([]*hdkeychain.ExtendedKey, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+
+	if uint64(start)+uint64(count) > HardenedKeyZeroIndex {
+		return nil, ErrAddressRangeOverflow
+	}
+
+	chain, err := d.chain(change)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*hdkeychain.ExtendedKey, count)
+	if workers < 2 {
+		return result, deriveRange(chain, start, result)
+	}
+
+	if workers > count {
+		workers = count
+	}
+
+	// Each worker fills a contiguous part of result so no locking is needed
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	size := (count + workers - 1) / workers
+	for w := 0; w < workers; w++ {
+		from := w * size
+		if from >= count {
+			break
+		}
+		to := from + size
+		if to > count {
+			to = count
+		}
+
+		wg.Add(1)
+		go func(w int, from int, to int) {
+			defer wg.Done()
+			errs[w] = deriveRange(chain, start+uint32(from), result[from:to])
+		}(w, from, to)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// chain returns the cached change or external chain key, deriving it on first use
+func (d *AccountDeriver) chain(change AddressType) (*hdkeychain.ExtendedKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if chain, ok := d.chains[change]; ok {
+		return chain, nil
+	}
+
+	chain, err := d.account.Child(uint32(change))
+	if err != nil {
+		return nil, err
+	}
+
+	// Private keys compute their public key lazily on the first non-hardened child derivation.
+	// Deriving one child here fills that cache so later concurrent derivations only read the chain key
+	if _, err := chain.Child(0); err != nil && err != hdkeychain.ErrInvalidChild {
+		return nil, err
+	}
+
+	d.chains[change] = chain
+	return chain, nil
+}
+
+// deriveRange fills out with consecutive children of chain starting at start
+func deriveRange(chain *hdkeychain.ExtendedKey, start uint32, out []*hdkeychain.ExtendedKey) error {
+	for i := range out {
+		k, err := chain.Child(start + uint32(i))
+		if err != nil {
+			return err
+		}
+		out[i] = k
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+const (
+	// benchmarkRange is the number of address keys derived per benchmark operation
+	benchmarkRange = 100
+)
+
+// testAccountKey returns the BIP44 account 0 private key of test seed A
+func testAccountKey(t testing.TB) string {
+	m, err := GetExtendedMasterPrivateKeyFromSeedHex(testSeedHexA, network.BTCMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := GetBIP44AccountKey(m, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestAccountDeriver(t *testing.T) {
+	account := testAccountKey(t)
+	d, err := NewAccountDeriverFromString(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, change := range []AddressType{ExternalAddress, ChangeAddress} {
+		for _, workers := range []int{0, 1, 3, 8, 64} {
+			ks, err := d.AddressKeys(change, 5, 20, workers)
+			if err != nil {
+				t.Errorf("range with %d workers failed: %s", workers, err.Error())
+				continue
+			}
+
+			if len(ks) != 20 {
+				t.Errorf("range with %d workers returned %d keys want 20", workers, len(ks))
+				continue
+			}
+
+			for i, k := range ks {
+				expected, err := GetAccountAddressKey(account, change, uint32(5+i))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if k.String() != expected.String() {
+					t.Errorf("key %d/%d with %d workers is not expected value", change, 5+i, workers)
+				}
+			}
+		}
+
+		k, err := d.AddressKey(change, 7)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		expected, _ := GetAccountAddressKey(account, change, 7)
+		if k.String() != expected.String() {
+			t.Errorf("key %d/7 is not expected value", change)
+		}
+	}
+
+	// Public account keys derive the same public address keys
+	pub, err := d.account.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := NewAccountDeriver(pub).AddressKeys(ExternalAddress, 0, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, k := range ks {
+		expected, _ := GetAccountAddressKey(account, ExternalAddress, uint32(i))
+		expectedPub, _ := expected.Neuter()
+		if k.String() != expectedPub.String() {
+			t.Errorf("public key 0/%d is not expected value", i)
+		}
+	}
+}
+
+func TestAccountDeriverRanges(t *testing.T) {
+	d, err := NewAccountDeriverFromString(testAccountKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := d.AddressKeys(ExternalAddress, 0, 0, 4)
+	if err != nil || len(ks) != 0 {
+		t.Errorf("empty range should return no keys")
+	}
+
+	ks, err = d.AddressKeys(ExternalAddress, HardenedKeyZeroIndex-2, 2, 1)
+	if err != nil || len(ks) != 2 {
+		t.Errorf("range ending at the last non-hardened index should be allowed")
+	}
+
+	if _, err := d.AddressKeys(ExternalAddress, HardenedKeyZeroIndex-2, 3, 1); err != ErrAddressRangeOverflow {
+		t.Errorf("range crossing into hardened indexes should return ErrAddressRangeOverflow")
+	}
+
+	if _, err := NewAccountDeriverFromString("xpub-invalid"); err == nil {
+		t.Errorf("invalid account key should not create a deriver")
+	}
+}
+
+func BenchmarkGetAccountAddressKey(b *testing.B) {
+	account := testAccountKey(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for i := uint32(0); i < benchmarkRange; i++ {
+			if _, err := GetAccountAddressKey(account, ExternalAddress, i); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkAccountDeriver(b *testing.B) {
+	benchmarkAccountDeriver(b, 1)
+}
+
+func BenchmarkAccountDeriverParallel(b *testing.B) {
+	benchmarkAccountDeriver(b, 4)
+}
+
+func benchmarkAccountDeriver(b *testing.B, workers int) {
+	d, err := NewAccountDeriverFromString(testAccountKey(b))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := d.AddressKeys(ExternalAddress, 0, benchmarkRange, workers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
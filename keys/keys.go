@@ -19,10 +19,13 @@
 package keys
 
 import (
+	"encoding/binary"
 	"encoding/hex"
-	"errors"
+	"sync"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
 
 	"github.com/sanscentral/sanswallet/network"
@@ -44,9 +47,15 @@ const (
 	// BIP84Purpose P2WPKH purpose
 	BIP84Purpose uint32 = 84
 
+	// BIP86Purpose P2TR (Taproot single key) purpose
+	BIP86Purpose uint32 = 86
+
 	// BTCCoinType (Full list of coin types available here: https://github.com/satoshilabs/slips/blob/master/slip-0044.md)
 	BTCCoinType uint32 = 0
 
+	// ETHCoinType Ethereum coin type (m/44'/60'/account'/0/address_index as used by MetaMask)
+	ETHCoinType uint32 = 60
+
 	ExternalAddress AddressType = 0
 	ChangeAddress   AddressType = 1
 )
@@ -71,6 +80,12 @@ func GetExtendedKeyFromString(xKey string) (key *hdkeychain.ExtendedKey, err err
 	return hdkeychain.NewKeyFromString(xKey)
 }
 
+// GetBIP86AccountKey retreives BIP86 account key for BIP32 path using BIP44 standard (m / purpose' / coin_type' / --->account'<--- / change / address_index)
+// This is primarily used for P2TR
+func GetBIP86AccountKey(masterKey *hdkeychain.ExtendedKey, accountIndex uint32, includePrivateKey bool) (key string, err error) {
+	return getAccountKeyWithPurpose(masterKey, BIP86Purpose, accountIndex, includePrivateKey)
+}
+
 // GetBIP84AccountKey retreives BIP49 account key for BIP32 path using BIP44 standard (m / purpose' / coin_type' / --->account'<--- / change / address_index)
 // This is primarily used for P2SH
 func GetBIP84AccountKey(masterKey *hdkeychain.ExtendedKey, accountIndex uint32, includePrivateKey bool) (key string, err error) {
@@ -89,33 +104,57 @@ func GetBIP44AccountKey(masterKey *hdkeychain.ExtendedKey, accountIndex uint32,
 	return getAccountKeyWithPurpose(masterKey, BIP44Purpose, accountIndex, includePrivateKey)
 }
 
-// getAccountKeyWithPurpose retrieves account key with specified BIP32 purpose
-func getAccountKeyWithPurpose(masterKey *hdkeychain.ExtendedKey, purpose uint32, accountIndex uint32, includePrivateKey bool) (key string, err error) {
-	purposeK, err := masterKey.Child(HardenedKeyZeroIndex + purpose)
+// GetAccountKey retreives the account key for BIP32 path m / purpose' / coin_type' / account'
+func GetAccountKey(masterKey *hdkeychain.ExtendedKey, purpose uint32, coinType uint32, accountIndex uint32, includePrivateKey bool) (key string, err error) {
+	r, err := DeriveKeyForPath(masterKey, NewAccountPath(purpose, coinType, accountIndex))
 	if err != nil {
 		return "", err
 	}
 
-	coinType, err := purposeK.Child(HardenedKeyZeroIndex + BTCCoinType)
+	if includePrivateKey {
+		return r.String(), nil
+	}
+
+	pub, err := r.Neuter()
 	if err != nil {
 		return "", err
 	}
 
-	r, err := coinType.Child(HardenedKeyZeroIndex + accountIndex)
+	return pub.String(), nil
+}
+
+// GetAccountKeyOnNetwork retreives the account key for BIP32 path m / purpose' / coin_type' / account' using the SLIP-44 coin type of net.
+// Networks sharing version bytes with Bitcoin (e.g. Bitcoin Cash xpub) need this as their coin type cannot be inferred from the master key
+func GetAccountKeyOnNetwork(masterKey *hdkeychain.ExtendedKey, purpose uint32, net network.Network, accountIndex uint32, includePrivateKey bool) (key string, err error) {
+	def, err := network.GetDefinition(net)
 	if err != nil {
 		return "", err
 	}
+	return GetAccountKey(masterKey, purpose, def.CoinType, accountIndex, includePrivateKey)
+}
 
-	if includePrivateKey {
-		return r.String(), nil
+// getAccountKeyWithPurpose retrieves account key with specified BIP32 purpose
+// and the SLIP-44 coin type of the network the master key is encoded for
+func getAccountKeyWithPurpose(masterKey *hdkeychain.ExtendedKey, purpose uint32, accountIndex uint32, includePrivateKey bool) (key string, err error) {
+	coinType, err := GetCoinType(masterKey)
+	if err != nil {
+		return "", err
 	}
+	return GetAccountKey(masterKey, purpose, coinType, accountIndex, includePrivateKey)
+}
 
-	pub, err := r.Neuter()
+// GetCoinType returns the SLIP-44 coin type of the network whose version bytes a key is encoded with
+func GetCoinType(key *hdkeychain.ExtendedKey) (uint32, error) {
+	v, _, err := GetExtendedKeyInfo(key.String())
 	if err != nil {
-		return "", err
+		return 0, err
 	}
 
-	return pub.String(), nil
+	def, err := network.GetDefinition(v.Network)
+	if err != nil {
+		return 0, err
+	}
+	return def.CoinType, nil
 }
 
 // GetAccountAddressKey retreives key for BIP32 path using BIP44 standard (m / purpose' / coin_type' / account' / --->change / address_index <---)
@@ -125,12 +164,67 @@ func GetAccountAddressKey(xKey string, change AddressType, addressIndex uint32)
 		return nil, err
 	}
 
-	changeK, err := account.Child(uint32(change))
+	return DeriveKeyForPath(account, DerivationPath{uint32(change), addressIndex})
+}
+
+// GetWIF returns the private key of a derived key in Wallet Import Format for net.
+// Compressed WIFs are for compressed public keys, the form every address type derived by this wallet uses
+func GetWIF(key *hdkeychain.ExtendedKey, net network.Network, compressed bool) (string, error) {
+	priv, err := key.ECPrivKey()
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	netParam, err := networkToChainCfg(net)
+	if err != nil {
+		return "", err
 	}
 
-	return changeK.Child(addressIndex)
+	wif, err := btcutil.NewWIF(priv, netParam, compressed)
+	if err != nil {
+		return "", err
+	}
+	return wif.String(), nil
+}
+
+// GetMasterFingerprint returns the BIP32 fingerprint of a key, the first 4 bytes of HASH160 of its compressed public key.
+// For the master key this is the fingerprint used in key origins (descriptors, PSBTs and hardware wallets)
+func GetMasterFingerprint(masterKey *hdkeychain.ExtendedKey) (uint32, error) {
+	pk, err := masterKey.ECPubKey()
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(btcutil.Hash160(pk.SerializeCompressed())[:4]), nil
+}
+
+var (
+	// regressionNetParams are the btcd regtest parameters with the bcrt segwit prefix used by Bitcoin Core
+	regressionNetParams = func() chaincfg.Params {
+		p := chaincfg.RegressionNetParams
+		p.Bech32HRPSegwit = "bcrt"
+		return p
+	}()
+
+	// signetParams are the default signet (BIP325) parameters. Signet shares the testnet address prefixes and key versions,
+	// only the fields needed for keys and addresses are set (not the genesis block or consensus rules)
+	signetParams = func() chaincfg.Params {
+		p := chaincfg.TestNet3Params
+		p.Name = "signet"
+		p.Net = wire.BitcoinNet(0x40cf030a)
+		p.DefaultPort = "38333"
+		p.DNSSeeds = nil
+		p.Bech32HRPSegwit = "tb"
+		return p
+	}()
+
+	// coinParams caches the chain parameters built for networks without btcd parameters (Litecoin, Dogecoin, ...)
+	coinParamsMu sync.Mutex
+	coinParams   = map[network.Network]*chaincfg.Params{}
+)
+
+// GetChainParams returns the btcd chain parameters used to encode keys and addresses for a network
+func GetChainParams(net network.Network) (*chaincfg.Params, error) {
+	return networkToChainCfg(net)
 }
 
 func networkToChainCfg(net network.Network) (*chaincfg.Params, error) {
@@ -139,6 +233,61 @@ func networkToChainCfg(net network.Network) (*chaincfg.Params, error) {
 		return &chaincfg.MainNetParams, nil
 	case network.BTCTestnet:
 		return &chaincfg.TestNet3Params, nil
+	case network.BTCRegtest:
+		return &regressionNetParams, nil
+	case network.BTCSignet:
+		return &signetParams, nil
+	}
+	return coinToChainCfg(net)
+}
+
+// coinToChainCfg builds and registers chain parameters from a network definition.
+// Like signet only the fields needed for keys and addresses are set, registering them and the
+// BIP32 HD key IDs with btcd lets hdkeychain neuter private keys of the network
+func coinToChainCfg(net network.Network) (*chaincfg.Params, error) {
+	def, err := network.GetDefinition(net)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("Unknown network specified")
+
+	coinParamsMu.Lock()
+	defer coinParamsMu.Unlock()
+
+	if p, ok := coinParams[net]; ok {
+		return p, nil
+	}
+
+	v, err := GetKeyVersion(ScriptP2PKH, net)
+	if err != nil {
+		return nil, err
+	}
+
+	p := chaincfg.MainNetParams
+	if def.Testnet {
+		p = chaincfg.TestNet3Params
+	}
+	p.Name = def.Name
+	p.Net = wire.BitcoinNet(def.Magic)
+	p.DefaultPort = ""
+	p.DNSSeeds = nil
+	p.PubKeyHashAddrID = def.PubKeyHashAddrID
+	p.ScriptHashAddrID = def.ScriptHashAddrID
+	p.PrivateKeyID = def.PrivateKeyID
+	p.Bech32HRPSegwit = def.Bech32HRP
+	p.HDPrivateKeyID = v.Private
+	p.HDPublicKeyID = v.Public
+	p.HDCoinType = def.CoinType
+
+	if err := chaincfg.Register(&p); err != nil && err != chaincfg.ErrDuplicateNet {
+		return nil, err
+	}
+
+	// Register skips the HD key IDs of networks whose magic is already known to btcd (e.g. one sharing the regtest magic),
+	// so they are registered separately for hdkeychain to neuter private keys of the network
+	if err := chaincfg.RegisterHDKeyID(v.Public[:], v.Private[:]); err != nil {
+		return nil, err
+	}
+
+	coinParams[net] = &p
+	return &p, nil
 }
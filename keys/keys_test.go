@@ -19,8 +19,12 @@
 package keys
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
 	"github.com/sanscentral/sanswallet/network"
 )
 
@@ -118,3 +122,165 @@ func TestExtendedKeyChildren(t *testing.T) {
 	}
 
 }
+
+func TestGetMasterFingerprint(t *testing.T) {
+	key, err := GetExtendedMasterPrivateKeyFromSeedHex(testSeedHexA, network.BTCMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprint, err := GetMasterFingerprint(key)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Children record the fingerprint of their parent, BIP32 test vector 2 m/0 has parent fingerprint bd16bee5
+	if fingerprint != 0xbd16bee5 {
+		t.Errorf("master fingerprint is not expected value, got %08x", fingerprint)
+	}
+
+	child, err := key.Child(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if child.ParentFingerprint() != fingerprint {
+		t.Errorf("master fingerprint %08x does not match child parent fingerprint %08x", fingerprint, child.ParentFingerprint())
+	}
+
+	pub, err := key.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f, _ := GetMasterFingerprint(pub); f != fingerprint {
+		t.Errorf("public master key fingerprint is not expected value, got %08x", f)
+	}
+}
+
+func TestGetChainParams(t *testing.T) {
+	hrps := map[network.Network]string{
+		network.BTCMainnet: "bc",
+		network.BTCTestnet: "tb",
+		network.BTCRegtest: "bcrt",
+		network.BTCSignet:  "tb",
+		network.LTCMainnet: "ltc",
+	}
+
+	for net, hrp := range hrps {
+		p, err := GetChainParams(net)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if p.Bech32HRPSegwit != hrp {
+			t.Errorf("network %s segwit prefix is %s want %s", net, p.Bech32HRPSegwit, hrp)
+		}
+	}
+
+	// Litecoin parameters are registered with btcd so private keys of the network can be neutered
+	m, err := GetExtendedMasterPrivateKeyFromSeedHex(testSeedHexA, network.LTCMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := m.Neuter()
+	if err != nil {
+		t.Error(err)
+	} else if pub.String()[:4] != "Ltub" {
+		t.Errorf("litecoin master public key does not start with Ltub, got %s", pub.String()[:4])
+	}
+
+	if coinType, err := GetCoinType(m); err != nil || coinType != 2 {
+		t.Errorf("litecoin master key coin type is not expected value, got %d", coinType)
+	}
+
+	if _, err := GetChainParams(network.Network(99)); err == nil {
+		t.Error("unknown network did not fail where expected")
+	}
+}
+
+func TestRegisteredNetworkKeys(t *testing.T) {
+	// The magic of Bitcoin regtest is already registered with btcd, the key versions are not
+	net, err := network.Register(network.Definition{
+		Name:             "keystest",
+		CoinType:         28,
+		Magic:            0xdab5bffa,
+		PubKeyHashAddrID: 0x47,
+		ScriptHashAddrID: 0x05,
+		PrivateKeyID:     0x80,
+		Bech32HRP:        "kt",
+		ScriptTypes:      []network.ScriptType{network.ScriptP2PKH},
+		KeyVersions: []network.KeyVersion{
+			{ScriptType: network.ScriptP2PKH, Private: [4]byte{0x0a, 0x1b, 0x2c, 0x3d}, Public: [4]byte{0x0a, 0x1b, 0x2c, 0x3e}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := GetExtendedMasterPrivateKeyFromSeedHex(testSeedHexA, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := GetAccountKeyOnNetwork(m, BIP44Purpose, net, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := GetExtendedKeyVersion(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != [4]byte{0x0a, 0x1b, 0x2c, 0x3e} {
+		t.Errorf("registered network public account key version is not expected value, got %x", version)
+	}
+}
+
+func TestGetWIF(t *testing.T) {
+	key, err := GetExtendedMasterPrivateKeyFromSeedHex(testSeedHexA, network.BTCMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := key.ECPubKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, compressed := range []bool{true, false} {
+		for net, prefix := range map[network.Network]string{network.BTCMainnet: "5", network.BTCTestnet: "9", network.LTCMainnet: "6"} {
+			s, err := GetWIF(key, net, compressed)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			wif, err := btcutil.DecodeWIF(s)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			if !wif.PrivKey.PubKey().IsEqual(pub) || wif.CompressPubKey != compressed {
+				t.Errorf("%s WIF %s does not hold the key", net, s)
+			}
+
+			if !compressed && !strings.HasPrefix(s, prefix) {
+				t.Errorf("%s uncompressed WIF %s does not start with %s", net, s, prefix)
+			}
+		}
+	}
+
+	neutered, err := key.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetWIF(neutered, network.BTCMainnet, true); err != hdkeychain.ErrNotPrivExtKey {
+		t.Error("public key did not fail for WIF where expected")
+	}
+}
@@ -0,0 +1,136 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+const (
+	// HardenedMarker is the suffix used to format hardened path components (m/44'/0'/0')
+	HardenedMarker = "'"
+
+	// HardenedMarkerH is the alternative suffix for hardened path components (m/44h/0h/0h)
+	HardenedMarkerH = "h"
+
+	// pathRoot is the first component of an absolute derivation path
+	pathRoot = "m"
+)
+
+// ErrEmptyPathComponent is returned when a derivation path contains an empty component (e.g. m//0)
+var ErrEmptyPathComponent = errors.New("Derivation path contains an empty component")
+
+// DerivationPath is a BIP32 path of child indexes. Hardened indexes include HardenedKeyZeroIndex
+type DerivationPath []uint32
+
+// NewAccountPath returns the BIP44 style account path (m / purpose' / coin_type' / account')
+func NewAccountPath(purpose uint32, coinType uint32, accountIndex uint32) DerivationPath {
+	return DerivationPath{HardenedKeyZeroIndex + purpose, HardenedKeyZeroIndex + coinType, HardenedKeyZeroIndex + accountIndex}
+}
+
+// ParseDerivationPath parses a derivation path such as m/48'/1'/0'/2'/0/5 or m/0h/1/2h.
+// The leading "m/" is optional so paths relative to an account (e.g. 0/5) are also accepted
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	components := strings.Split(path, "/")
+	if components[0] == pathRoot || components[0] == strings.ToUpper(pathRoot) {
+		components = components[1:]
+	}
+
+	p := make(DerivationPath, 0, len(components))
+	for _, c := range components {
+		index, err := parsePathComponent(c)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, index)
+	}
+	return p, nil
+}
+
+// parsePathComponent parses a single (optionally hardened) path index
+func parsePathComponent(c string) (uint32, error) {
+	if c == "" {
+		return 0, ErrEmptyPathComponent
+	}
+
+	hardened := false
+	if last := c[len(c)-1:]; last == HardenedMarker || strings.ToLower(last) == HardenedMarkerH {
+		hardened = true
+		c = c[:len(c)-1]
+	}
+
+	// ParseUint accepts no sign or whitespace, only check the leading digit is present
+	if c == "" || c[0] < '0' || c[0] > '9' {
+		return 0, fmt.Errorf("Invalid derivation path component %q", c)
+	}
+
+	index, err := strconv.ParseUint(c, 10, 32)
+	if err != nil || index >= HardenedKeyZeroIndex {
+		return 0, fmt.Errorf("Derivation path component %q is not a valid index (must be below 2^31)", c)
+	}
+
+	if hardened {
+		index += HardenedKeyZeroIndex
+	}
+	return uint32(index), nil
+}
+
+// Child returns a copy of the path extended with index
+func (p DerivationPath) Child(index uint32) DerivationPath {
+	c := make(DerivationPath, len(p), len(p)+1)
+	copy(c, p)
+	return append(c, index)
+}
+
+// String formats the path using ' to mark hardened indexes
+func (p DerivationPath) String() string {
+	return p.Format(HardenedMarker)
+}
+
+// Format formats the path as an absolute path using marker (HardenedMarker or HardenedMarkerH) for hardened indexes
+func (p DerivationPath) Format(marker string) string {
+	components := make([]string, 0, len(p)+1)
+	components = append(components, pathRoot)
+	for _, index := range p {
+		if index >= HardenedKeyZeroIndex {
+			components = append(components, strconv.FormatUint(uint64(index-HardenedKeyZeroIndex), 10)+marker)
+		} else {
+			components = append(components, strconv.FormatUint(uint64(index), 10))
+		}
+	}
+	return strings.Join(components, "/")
+}
+
+// DeriveKeyForPath derives the descendant of key at path
+func DeriveKeyForPath(key *hdkeychain.ExtendedKey, path DerivationPath) (*hdkeychain.ExtendedKey, error) {
+	k := key
+	for _, index := range path {
+		var err error
+		k, err = k.Child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
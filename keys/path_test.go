@@ -0,0 +1,141 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package keys
+
+import (
+	"testing"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+func TestParseDerivationPath(t *testing.T) {
+	valid := []struct {
+		path     string
+		expected DerivationPath
+		format   string
+	}{
+		{"m", DerivationPath{}, "m"},
+		{"m/48'/1'/0'/2'/0/5", DerivationPath{HardenedKeyZeroIndex + 48, HardenedKeyZeroIndex + 1, HardenedKeyZeroIndex, HardenedKeyZeroIndex + 2, 0, 5}, "m/48'/1'/0'/2'/0/5"},
+		{"m/0h/1/2h", DerivationPath{HardenedKeyZeroIndex, 1, HardenedKeyZeroIndex + 2}, "m/0'/1/2'"},
+		{"M/0H/1", DerivationPath{HardenedKeyZeroIndex, 1}, "m/0'/1"},
+		{"0/5", DerivationPath{0, 5}, "m/0/5"},
+		{"m/2147483647'/2147483647", DerivationPath{0xffffffff, 2147483647}, "m/2147483647'/2147483647"},
+	}
+
+	for _, v := range valid {
+		p, err := ParseDerivationPath(v.path)
+		if err != nil {
+			t.Errorf("path %q did not parse: %s", v.path, err.Error())
+			continue
+		}
+
+		if len(p) != len(v.expected) {
+			t.Errorf("path %q has %d components want %d", v.path, len(p), len(v.expected))
+			continue
+		}
+
+		for i := range p {
+			if p[i] != v.expected[i] {
+				t.Errorf("path %q component %d is %d want %d", v.path, i, p[i], v.expected[i])
+			}
+		}
+
+		if p.String() != v.format {
+			t.Errorf("path %q formatted as %s want %s", v.path, p.String(), v.format)
+		}
+	}
+
+	p, _ := ParseDerivationPath("m/84'/0'/0'/1/3")
+	if p.Format(HardenedMarkerH) != "m/84h/0h/0h/1/3" {
+		t.Errorf("path formatted with h notation is not expected value, got %s", p.Format(HardenedMarkerH))
+	}
+
+	invalid := []string{
+		"",
+		"m/",
+		"m//1",
+		"m/1/",
+		"/1",
+		"m/-1",
+		"m/+1",
+		"m/ 1",
+		"m/1''",
+		"m/1h'",
+		"m/'",
+		"m/a",
+		"m/1x",
+		"m/2147483648",
+		"m/2147483648'",
+		"m/4294967296",
+		"n/1",
+	}
+
+	for _, path := range invalid {
+		if _, err := ParseDerivationPath(path); err == nil {
+			t.Errorf("path %q did not fail to parse where expected", path)
+		}
+	}
+}
+
+func TestDeriveKeyForPath(t *testing.T) {
+	key, err := GetExtendedMasterPrivateKeyFromSeedHex(testSeedHexA, network.BTCMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Test vector ref: https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki#Test_vector_2
+	p, err := ParseDerivationPath("m/0/2147483647'/1/2147483646'/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := DeriveKeyForPath(key, p)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if k.String() != "xprvA2nrNbFZABcdryreWet9Ea4LvTJcGsqrMzxHx98MMrotbir7yrKCEXw7nadnHM8Dq38EGfSh6dqA9QWTyefMLEcBYJUuekgW4BYPJcr9E7j" {
+		t.Errorf("m/0/2147483647'/1/2147483646'/2 Private key is not expected value")
+	}
+
+	// Account path helper must match the BIP44 account key
+	account, err := DeriveKeyForPath(key, NewAccountPath(BIP44Purpose, BTCCoinType, 0))
+	if err != nil {
+		t.Error(err)
+	}
+
+	bip44, err := GetBIP44AccountKey(key, 0, true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if account.String() != bip44 {
+		t.Errorf("account path key is not expected value want %s got %s", bip44, account.String())
+	}
+
+	// Hardened children cannot be derived from a public key
+	pub, err := key.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DeriveKeyForPath(pub, DerivationPath{HardenedKeyZeroIndex}); err == nil {
+		t.Error("hardened derivation from public key did not fail where expected")
+	}
+}
@@ -0,0 +1,156 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package keys
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil/base58"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// ScriptType is the output script an extended key is intended for (SLIP-132)
+type ScriptType = network.ScriptType
+
+const (
+	// ScriptP2PKH pay-to-public-key-hash (and legacy P2SH multisig), xpub/tpub
+	ScriptP2PKH = network.ScriptP2PKH
+
+	// ScriptP2WPKHInP2SH P2WPKH nested in P2SH, ypub/upub
+	ScriptP2WPKHInP2SH = network.ScriptP2WPKHInP2SH
+
+	// ScriptP2WPKH native segwit pay-to-witness-public-key-hash, zpub/vpub
+	ScriptP2WPKH = network.ScriptP2WPKH
+
+	// ScriptP2WSHInP2SH multisig P2WSH nested in P2SH, Ypub/Upub
+	ScriptP2WSHInP2SH = network.ScriptP2WSHInP2SH
+
+	// ScriptP2WSH native segwit multisig pay-to-witness-script-hash, Zpub/Vpub
+	ScriptP2WSH = network.ScriptP2WSH
+
+	// ScriptP2TR Taproot pay-to-taproot single key (BIP86), serialized as xpub/tpub
+	ScriptP2TR = network.ScriptP2TR
+)
+
+// serializedExtendedKeyLen is the length of a base58-decoded extended key (78 byte payload + 4 byte checksum)
+const serializedExtendedKeyLen = 82
+
+var (
+	// ErrUnknownKeyVersion is returned when extended key version bytes are not in the SLIP-132 registry
+	ErrUnknownKeyVersion = errors.New("Extended key version is not a known SLIP-132 version")
+
+	// ErrInvalidExtendedKey is returned when a string is not a base58check-encoded extended key
+	ErrInvalidExtendedKey = errors.New("Extended key is not a valid base58check-encoded key")
+)
+
+// KeyVersion holds the SLIP-132 version bytes of one script type on one network
+type KeyVersion struct {
+	ScriptType    ScriptType
+	Network       network.Network
+	Private       [4]byte
+	Public        [4]byte
+	PrivatePrefix string
+	PublicPrefix  string
+}
+
+// keyVersions returns the SLIP-132 registry (https://github.com/satoshilabs/slips/blob/master/slip-0132.md) built from the
+// registered networks in ascending order, so networks sharing version bytes (regtest, signet) resolve to the first of them
+func keyVersions() []KeyVersion {
+	var versions []KeyVersion
+	for _, net := range network.Networks() {
+		def, err := network.GetDefinition(net)
+		if err != nil {
+			continue
+		}
+
+		for _, v := range def.KeyVersions {
+			versions = append(versions, KeyVersion{v.ScriptType, net, v.Private, v.Public, v.PrivatePrefix, v.PublicPrefix})
+		}
+	}
+	return versions
+}
+
+// GetKeyVersion returns the SLIP-132 version bytes for a script type on a network.
+// BIP86 defines no versions of its own, Taproot account keys use the BIP32 versions of their network
+func GetKeyVersion(scriptType ScriptType, net network.Network) (KeyVersion, error) {
+	def, err := network.GetDefinition(net)
+	if err != nil {
+		return KeyVersion{}, err
+	}
+
+	v, ok := def.KeyVersion(scriptType)
+	if !ok {
+		return KeyVersion{}, ErrUnknownKeyVersion
+	}
+	return KeyVersion{v.ScriptType, net, v.Private, v.Public, v.PrivatePrefix, v.PublicPrefix}, nil
+}
+
+// LookupKeyVersion returns the registry entry matching version bytes and whether they denote a private key
+func LookupKeyVersion(version [4]byte) (v KeyVersion, isPrivate bool, err error) {
+	for _, v := range keyVersions() {
+		if v.Private == version {
+			return v, true, nil
+		}
+		if v.Public == version {
+			return v, false, nil
+		}
+	}
+	return KeyVersion{}, false, ErrUnknownKeyVersion
+}
+
+// LookupKeyPrefix returns the registry entry for a human readable prefix (e.g. zpub, Yprv) and whether it denotes a private key
+func LookupKeyPrefix(prefix string) (v KeyVersion, isPrivate bool, err error) {
+	for _, v := range keyVersions() {
+		if v.PrivatePrefix == prefix {
+			return v, true, nil
+		}
+		if v.PublicPrefix == prefix {
+			return v, false, nil
+		}
+	}
+	return KeyVersion{}, false, ErrUnknownKeyVersion
+}
+
+// GetExtendedKeyVersion returns the version bytes of a base58check-encoded extended key
+func GetExtendedKeyVersion(xKey string) (version [4]byte, err error) {
+	decoded := base58.Decode(xKey)
+	if len(decoded) != serializedExtendedKeyLen {
+		return version, ErrInvalidExtendedKey
+	}
+
+	payload := decoded[:len(decoded)-4]
+	if !bytes.Equal(decoded[len(decoded)-4:], chainhash.DoubleHashB(payload)[:4]) {
+		return version, ErrInvalidExtendedKey
+	}
+
+	copy(version[:], payload[:4])
+	return version, nil
+}
+
+// GetExtendedKeyInfo returns the SLIP-132 registry entry of a base58check-encoded extended key and whether it is private
+func GetExtendedKeyInfo(xKey string) (v KeyVersion, isPrivate bool, err error) {
+	version, err := GetExtendedKeyVersion(xKey)
+	if err != nil {
+		return KeyVersion{}, false, err
+	}
+	return LookupKeyVersion(version)
+}
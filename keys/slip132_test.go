@@ -0,0 +1,112 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package keys
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+func TestKeyVersionPrefixes(t *testing.T) {
+	key, err := GetExtendedMasterPrivateKeyFromSeedHex(testSeedHexA, network.BTCMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := key.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every registered version must encode to its human readable prefix and be found again by lookup.
+	// Versions shared between networks (regtest, signet, Litecoin zpub) resolve to the first network using them
+	for _, v := range keyVersions() {
+		for _, private := range []bool{true, false} {
+			k, version, prefix := pub.String(), v.Public, v.PublicPrefix
+			if private {
+				k, version, prefix = key.String(), v.Private, v.PrivatePrefix
+			}
+
+			s, err := hdkeychain.VersionedStringFromExtendedKeyString(k, version)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !strings.HasPrefix(s, prefix) {
+				t.Errorf("network %d %s key version does not encode to prefix %s, got %s", v.Network, v.ScriptType, prefix, s[:4])
+			}
+
+			found, isPrivate, err := GetExtendedKeyInfo(s)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if found.Private != v.Private || found.Public != v.Public || found.ScriptType != v.ScriptType || isPrivate != private {
+				t.Errorf("lookup of %s returned %s %s (private %t)", prefix, found.PublicPrefix, found.ScriptType, isPrivate)
+			}
+		}
+	}
+}
+
+func TestGetKeyVersion(t *testing.T) {
+	v, err := GetKeyVersion(ScriptP2WPKH, network.BTCTestnet)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if v.PublicPrefix != "vpub" || v.PrivatePrefix != "vprv" {
+		t.Errorf("testnet P2WPKH version is not expected value, got %s/%s", v.PublicPrefix, v.PrivatePrefix)
+	}
+
+	v, err = GetKeyVersion(ScriptP2TR, network.BTCMainnet)
+	if err != nil || v.PublicPrefix != "xpub" || v.PrivatePrefix != "xprv" {
+		t.Errorf("mainnet P2TR version is not expected value, got %s/%s", v.PublicPrefix, v.PrivatePrefix)
+	}
+
+	if _, err := GetKeyVersion(ScriptType(99), network.BTCMainnet); err != ErrUnknownKeyVersion {
+		t.Error("unknown script type did not fail where expected")
+	}
+
+	v, err = GetKeyVersion(ScriptP2WPKHInP2SH, network.LTCMainnet)
+	if err != nil || v.PublicPrefix != "Mtub" || v.PrivatePrefix != "Mtpv" {
+		t.Errorf("litecoin P2SH-P2WPKH version is not expected value, got %s/%s", v.PublicPrefix, v.PrivatePrefix)
+	}
+
+	if _, err := GetKeyVersion(ScriptP2WPKH, network.DOGEMainnet); err != ErrUnknownKeyVersion {
+		t.Error("dogecoin P2WPKH version did not fail where expected")
+	}
+
+	if _, err := GetKeyVersion(ScriptP2PKH, network.Network(999)); err != network.ErrUnknownNetwork {
+		t.Error("unknown network did not fail where expected")
+	}
+
+	if _, _, err := LookupKeyVersion([4]byte{0x00, 0x00, 0x00, 0x00}); err != ErrUnknownKeyVersion {
+		t.Error("unknown version bytes did not fail where expected")
+	}
+
+	for _, k := range []string{"", "xpub", "xpub661MyMwAqRbcFW31YEwpkMuc5THy2PSt5bDMsktWQcFF8syAmRUapSCGu8ED9W6oDMSgv6Zz8idoc4a6mr8BDzTJY47LJhkJ8UB7WEGuduC"} {
+		if _, err := GetExtendedKeyVersion(k); err != ErrInvalidExtendedKey {
+			t.Errorf("invalid key %q did not fail where expected", k)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/message"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// SignMessageForIndex returns the BIP137 signature of msg with the key of the address at given index.
+// The address type the signature is for follows the account key version (xprv P2PKH, yprv P2SH-P2WPKH, zprv P2WPKH)
+func SignMessageForIndex(accountKey string, addressIndex int, isChange bool, msg string, testnet bool) (string, error) {
+	return SignMessageForIndexOnNetwork(accountKey, addressIndex, isChange, msg, testnetToNetwork(testnet))
+}
+
+// SignMessageForIndexOnNetwork returns the BIP137 signature of msg with the key of the address at given index on net
+func SignMessageForIndexOnNetwork(accountKey string, addressIndex int, isChange bool, msg string, net network.Network) (string, error) {
+	v, _, err := keys.GetExtendedKeyInfo(accountKey)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkAccountKeyVersion(accountKey, v.ScriptType, net); err != nil {
+		return "", err
+	}
+
+	index, err := intToUint32(addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := keys.GetAccountAddressKey(accountKey, addt, index)
+	if err != nil {
+		return "", err
+	}
+
+	return signMessage(k, v.ScriptType, net, msg)
+}
+
+// SignMessage returns the BIP137 signature of msg with the key of the address at addressIndex.
+// The account must hold the extended private key and be P2PKH, P2SH-P2WPKH or P2WPKH, see SignMessageBIP322 for P2TR
+func (a *Account) SignMessage(addressIndex int, isChange bool, msg string) (string, error) {
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := a.addressKey(addt, addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	return signMessage(k, a.scriptType, a.net, msg)
+}
+
+// SignMessageBIP322 returns the BIP322 simple signature of msg with the key of the address at addressIndex.
+// The account must hold the extended private key and be P2WPKH or P2TR
+func (a *Account) SignMessageBIP322(addressIndex int, isChange bool, msg string) (string, error) {
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := a.addressKey(addt, addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	address, err := a.encodeAddress(k)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := DecodeAddressOnNetwork(address, a.net)
+	if err != nil {
+		return "", err
+	}
+
+	script, err := hex.DecodeString(info.ScriptPubKey)
+	if err != nil {
+		return "", err
+	}
+
+	priv, err := k.ECPrivKey()
+	if err != nil {
+		return "", err
+	}
+
+	return message.SignSimple(priv, script, msg)
+}
+
+// VerifyMessage returns an error unless signature is a valid signature of msg for a mainnet or testnet address.
+// Both BIP137 and BIP322 simple signatures are accepted
+func VerifyMessage(address string, msg string, signature string, testnet bool) error {
+	return VerifyMessageOnNetwork(address, msg, signature, testnetToNetwork(testnet))
+}
+
+// VerifyMessageOnNetwork returns an error unless signature is a valid BIP137 or BIP322 simple signature of msg for an address of net.
+// BIP137 signatures with a compressed P2PKH header are also accepted for segwit addresses, as Electrum and Trezor sign them
+func VerifyMessageOnNetwork(address string, msg string, signature string, net network.Network) error {
+	info, err := DecodeAddressOnNetwork(address, net)
+	if err != nil {
+		return err
+	}
+
+	if !isCompactSignature(signature) {
+		script, err := hex.DecodeString(info.ScriptPubKey)
+		if err != nil {
+			return err
+		}
+		return message.VerifySimple(script, msg, signature)
+	}
+
+	prefix, err := messagePrefix(net)
+	if err != nil {
+		return err
+	}
+
+	pub, scriptType, compressed, err := message.RecoverCompact(signature, prefix, msg)
+	if err != nil {
+		return err
+	}
+
+	candidates := []keys.ScriptType{scriptType}
+	if scriptType == keys.ScriptP2PKH && compressed {
+		candidates = append(candidates, keys.ScriptP2WPKHInP2SH, keys.ScriptP2WPKH)
+	}
+
+	for _, s := range candidates {
+		script, err := keyScriptPubKey(pub, s, compressed)
+		if err != nil {
+			return err
+		}
+
+		if script == info.ScriptPubKey {
+			return nil
+		}
+	}
+	return message.ErrInvalidSignature
+}
+
+// signMessage returns the BIP137 signature of msg with an address key for an address of scriptType on net
+func signMessage(k *hdkeychain.ExtendedKey, scriptType keys.ScriptType, net network.Network, msg string) (string, error) {
+	prefix, err := messagePrefix(net)
+	if err != nil {
+		return "", err
+	}
+
+	priv, err := k.ECPrivKey()
+	if err != nil {
+		return "", err
+	}
+
+	return message.SignCompact(priv, scriptType, true, prefix, msg)
+}
+
+// messagePrefix returns the signed message magic of net
+func messagePrefix(net network.Network) (string, error) {
+	def, err := network.GetDefinition(net)
+	if err != nil {
+		return "", err
+	}
+
+	if def.MessagePrefix == "" {
+		return message.BitcoinPrefix, nil
+	}
+	return def.MessagePrefix, nil
+}
+
+// isCompactSignature returns true if signature is 65 bytes with a BIP137 header byte (27 to 42)
+func isCompactSignature(signature string) bool {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	return err == nil && len(sig) == 65 && sig[0] >= 27 && sig[0] <= 42
+}
+
+// keyScriptPubKey returns the hex encoded output script of scriptType paying to a public key
+func keyScriptPubKey(pub *btcec.PublicKey, scriptType keys.ScriptType, compressed bool) (string, error) {
+	serialized := pub.SerializeUncompressed()
+	if compressed {
+		serialized = pub.SerializeCompressed()
+	}
+
+	keyHash := btcutil.Hash160(serialized)
+	witnessScript := append([]byte{0x00, 0x14}, keyHash...)
+
+	var info *AddressInfo
+	var err error
+	switch scriptType {
+	case keys.ScriptP2WPKHInP2SH:
+		info, err = hashAddressInfo(AddressP2SH, btcutil.Hash160(witnessScript))
+	case keys.ScriptP2WPKH:
+		return hex.EncodeToString(witnessScript), nil
+	default:
+		info, err = hashAddressInfo(AddressP2PKH, keyHash)
+	}
+	if err != nil {
+		return "", err
+	}
+	return info.ScriptPubKey, nil
+}
@@ -0,0 +1,132 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package message signs and verifies messages with address keys (BIP137 compact signatures and BIP322 simple signatures)
+package message
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// BitcoinPrefix is the signed message magic of Bitcoin
+const BitcoinPrefix = "Bitcoin Signed Message:\n"
+
+// Compact signature header bytes, each followed by 4 recovery ids
+const (
+	compactSignatureLength = 65
+
+	headerP2PKHUncompressed = 27
+	headerP2PKH             = 31
+	headerP2WPKHInP2SH      = 35
+	headerP2WPKH            = 39
+	headerMax               = 42
+)
+
+var (
+	// ErrInvalidSignature is returned when a signature is malformed or does not match the address
+	ErrInvalidSignature = errors.New("Message signature is invalid")
+
+	// ErrUnsupportedScriptType is returned when messages cannot be signed for a script type
+	ErrUnsupportedScriptType = errors.New("Messages cannot be signed for this script type")
+
+	// ErrUncompressedSegwit is returned when signing for a segwit address with an uncompressed key
+	ErrUncompressedSegwit = errors.New("Segwit message signatures require a compressed public key")
+)
+
+// Hash returns the double SHA256 message hash signed by BIP137 signatures, prefix is the network message magic
+func Hash(prefix string, msg string) []byte {
+	var b bytes.Buffer
+	wire.WriteVarString(&b, 0, prefix)
+	wire.WriteVarString(&b, 0, msg)
+	return chainhash.DoubleHashB(b.Bytes())
+}
+
+// SignCompact returns the base64 BIP137 signature of msg for the address of scriptType (P2PKH, P2SH-P2WPKH or P2WPKH).
+// The header byte of the signature records the script type, as BIP137 defines for segwit addresses
+func SignCompact(priv *btcec.PrivateKey, scriptType network.ScriptType, compressed bool, prefix string, msg string) (string, error) {
+	var header byte
+	switch scriptType {
+	case network.ScriptP2PKH:
+		header = headerP2PKHUncompressed
+		if compressed {
+			header = headerP2PKH
+		}
+	case network.ScriptP2WPKHInP2SH:
+		header = headerP2WPKHInP2SH
+	case network.ScriptP2WPKH:
+		header = headerP2WPKH
+	default:
+		return "", ErrUnsupportedScriptType
+	}
+
+	if !compressed && scriptType != network.ScriptP2PKH {
+		return "", ErrUncompressedSegwit
+	}
+
+	sig, err := btcec.SignCompact(btcec.S256(), priv, Hash(prefix, msg), compressed)
+	if err != nil {
+		return "", err
+	}
+
+	// btcec sets 27 + recovery id (+ 4 when compressed)
+	sig[0] = header + (sig[0]-headerP2PKHUncompressed)&0x03
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// RecoverCompact returns the public key that made a base64 BIP137 signature of msg, with the script type and
+// compression its header byte records. Headers of compressed P2PKH keys are also used by some wallets for segwit addresses
+func RecoverCompact(signature string, prefix string, msg string) (*btcec.PublicKey, network.ScriptType, bool, error) {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(sig) != compactSignatureLength {
+		return nil, 0, false, ErrInvalidSignature
+	}
+
+	header := sig[0]
+	if header < headerP2PKHUncompressed || header > headerMax {
+		return nil, 0, false, ErrInvalidSignature
+	}
+
+	scriptType := network.ScriptP2PKH
+	switch {
+	case header >= headerP2WPKH:
+		scriptType = network.ScriptP2WPKH
+	case header >= headerP2WPKHInP2SH:
+		scriptType = network.ScriptP2WPKHInP2SH
+	}
+
+	compressed := header >= headerP2PKH
+	btcecHeader := headerP2PKHUncompressed + (header-headerP2PKHUncompressed)&0x03
+	if compressed {
+		btcecHeader += headerP2PKH - headerP2PKHUncompressed
+	}
+
+	compact := append([]byte{btcecHeader}, sig[1:]...)
+	pub, _, err := btcec.RecoverCompact(btcec.S256(), compact, Hash(prefix, msg))
+	if err != nil {
+		return nil, 0, false, ErrInvalidSignature
+	}
+	return pub, scriptType, compressed, nil
+}
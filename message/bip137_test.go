@@ -0,0 +1,108 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package message
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+const (
+	// Key of the BIP322 test vectors ref: https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#test-vectors
+	testWIF = "L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k"
+
+	testMessage = "Hello World"
+)
+
+func TestHash(t *testing.T) {
+	// Hash of an empty message is double SHA256 of 0x18 || "Bitcoin Signed Message:\n" || 0x00
+	h := Hash(BitcoinPrefix, "")
+	if hex.EncodeToString(h) != "80e795d4a4caadd7047af389d9f7f220562feb6196032e2131e10563352c4bcc" {
+		t.Errorf("empty message hash is not expected value, got %x", h)
+	}
+
+	if hex.EncodeToString(Hash(BitcoinPrefix, testMessage)) == hex.EncodeToString(Hash("Litecoin Signed Message:\n", testMessage)) {
+		t.Error("message hash does not depend on the prefix")
+	}
+}
+
+func TestSignCompact(t *testing.T) {
+	wif, err := btcutil.DecodeWIF(testWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signatures := []struct {
+		scriptType network.ScriptType
+		compressed bool
+		minHeader  byte
+	}{
+		{network.ScriptP2PKH, false, 27},
+		{network.ScriptP2PKH, true, 31},
+		{network.ScriptP2WPKHInP2SH, true, 35},
+		{network.ScriptP2WPKH, true, 39},
+	}
+
+	for _, s := range signatures {
+		sig, err := SignCompact(wif.PrivKey, s.scriptType, s.compressed, BitcoinPrefix, testMessage)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		raw, _ := base64.StdEncoding.DecodeString(sig)
+		if len(raw) != compactSignatureLength || raw[0] < s.minHeader || raw[0] > s.minHeader+3 {
+			t.Errorf("%s signature header %d is not in expected range from %d", s.scriptType, raw[0], s.minHeader)
+		}
+
+		pub, scriptType, compressed, err := RecoverCompact(sig, BitcoinPrefix, testMessage)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !pub.IsEqual(wif.PrivKey.PubKey()) || scriptType != s.scriptType || compressed != s.compressed {
+			t.Errorf("%s signature recovered as %s (compressed %t) with key %x", s.scriptType, scriptType, compressed, pub.SerializeCompressed())
+		}
+
+		// A signature of another message recovers another key
+		if pub, _, _, err := RecoverCompact(sig, BitcoinPrefix, testMessage+"!"); err == nil && pub.IsEqual(wif.PrivKey.PubKey()) {
+			t.Errorf("%s signature recovered the signing key for another message", s.scriptType)
+		}
+	}
+
+	if _, err := SignCompact(wif.PrivKey, network.ScriptP2WPKH, false, BitcoinPrefix, testMessage); err != ErrUncompressedSegwit {
+		t.Error("uncompressed segwit signature did not fail where expected")
+	}
+
+	if _, err := SignCompact(wif.PrivKey, network.ScriptP2TR, true, BitcoinPrefix, testMessage); err != ErrUnsupportedScriptType {
+		t.Error("P2TR compact signature did not fail where expected")
+	}
+
+	for _, sig := range []string{"", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, 64)), base64.StdEncoding.EncodeToString(make([]byte, 65))} {
+		if _, _, _, err := RecoverCompact(sig, BitcoinPrefix, testMessage); err != ErrInvalidSignature {
+			t.Errorf("malformed signature %q did not fail where expected", sig)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package message
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+
+	"github.com/sanscentral/sanswallet/taproot"
+)
+
+// BIP322Tag is the BIP340 tagged hash tag of BIP322 message hashes
+const BIP322Tag = "BIP0322-signed-message"
+
+// maxWitnessItemSize bounds the size of a decoded witness stack item
+const maxWitnessItemSize = 10000
+
+var (
+	// ErrKeyMismatch is returned when the signing key does not belong to the address being signed for
+	ErrKeyMismatch = errors.New("Private key does not match the address")
+
+	// ErrUnsupportedScript is returned for addresses BIP322 simple signatures cannot be made for
+	ErrUnsupportedScript = errors.New("BIP322 simple signatures require a P2WPKH or P2TR address")
+)
+
+// BIP322Hash returns the BIP322 message hash, the tagged hash of msg
+func BIP322Hash(msg string) []byte {
+	return taproot.TaggedHash(BIP322Tag, []byte(msg))
+}
+
+// ToSpend returns the BIP322 virtual transaction paying to scriptPubKey that commits to msg
+func ToSpend(scriptPubKey []byte, msg string) *wire.MsgTx {
+	scriptSig := append([]byte{txscript.OP_0, txscript.OP_DATA_32}, BIP322Hash(msg)...)
+
+	tx := wire.NewMsgTx(0)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  scriptSig,
+		Sequence:         0,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, scriptPubKey))
+	return tx
+}
+
+// ToSign returns the unsigned BIP322 virtual transaction spending the ToSpend output of scriptPubKey and msg
+func ToSign(scriptPubKey []byte, msg string) *wire.MsgTx {
+	toSpend := ToSpend(scriptPubKey, msg)
+
+	tx := wire.NewMsgTx(0)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: toSpend.TxHash(), Index: 0},
+		Sequence:         0,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return tx
+}
+
+// SignSimple returns the base64 BIP322 simple signature of msg for a P2WPKH or P2TR scriptPubKey.
+// For P2TR priv is the internal key of a key path only output (BIP86)
+func SignSimple(priv *btcec.PrivateKey, scriptPubKey []byte, msg string) (string, error) {
+	toSpend := ToSpend(scriptPubKey, msg)
+	toSign := ToSign(scriptPubKey, msg)
+
+	var witness wire.TxWitness
+	switch {
+	case isP2WPKH(scriptPubKey):
+		keyHash := btcutil.Hash160(priv.PubKey().SerializeCompressed())
+		if !bytes.Equal(scriptPubKey[2:], keyHash) {
+			return "", ErrKeyMismatch
+		}
+
+		w, err := txscript.WitnessSignature(toSign, txscript.NewTxSigHashes(toSign), 0, 0, scriptPubKey, txscript.SigHashAll, priv, true)
+		if err != nil {
+			return "", err
+		}
+		witness = w
+	case isP2TR(scriptPubKey):
+		outputPriv, err := taproot.TweakPrivateKey(priv, nil)
+		if err != nil {
+			return "", err
+		}
+
+		if !bytes.Equal(scriptPubKey[2:], taproot.XOnlyPubKey(outputPriv.PubKey())) {
+			return "", ErrKeyMismatch
+		}
+
+		hash, err := taproot.KeySpendSigHash(toSign, toSpend.TxOut, 0, taproot.SigHashDefault)
+		if err != nil {
+			return "", err
+		}
+
+		auxRand := make([]byte, 32)
+		if _, err := rand.Read(auxRand); err != nil {
+			return "", err
+		}
+
+		sig, err := taproot.SignSchnorr(outputPriv, hash, auxRand)
+		if err != nil {
+			return "", err
+		}
+		witness = wire.TxWitness{sig}
+	default:
+		return "", ErrUnsupportedScript
+	}
+
+	var b bytes.Buffer
+	if err := writeWitness(&b, witness); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b.Bytes()), nil
+}
+
+// VerifySimple checks a base64 BIP322 simple signature of msg for scriptPubKey.
+// P2TR key path spends are checked against BIP341, other scripts with the btcd script engine
+func VerifySimple(scriptPubKey []byte, msg string, signature string) error {
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	witness, err := readWitness(raw)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	toSpend := ToSpend(scriptPubKey, msg)
+	toSign := ToSign(scriptPubKey, msg)
+	toSign.TxIn[0].Witness = witness
+
+	if isP2TR(scriptPubKey) {
+		return verifyTaprootKeySpend(toSign, toSpend.TxOut, scriptPubKey[2:])
+	}
+
+	vm, err := txscript.NewEngine(scriptPubKey, toSign, 0, txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(toSign), 0)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if err := vm.Execute(); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// verifyTaprootKeySpend checks the key path witness of the first input of tx against the output key
+func verifyTaprootKeySpend(tx *wire.MsgTx, prevOuts []*wire.TxOut, outputKey []byte) error {
+	witness := tx.TxIn[0].Witness
+	if len(witness) != 1 {
+		return ErrInvalidSignature
+	}
+
+	sig := witness[0]
+	hashType := taproot.SigHashDefault
+	switch len(sig) {
+	case taproot.SchnorrSignatureLength:
+	case taproot.SchnorrSignatureLength + 1:
+		// An explicit hash type byte must not restate the default
+		hashType = txscript.SigHashType(sig[taproot.SchnorrSignatureLength])
+		if hashType == taproot.SigHashDefault {
+			return ErrInvalidSignature
+		}
+		sig = sig[:taproot.SchnorrSignatureLength]
+	default:
+		return ErrInvalidSignature
+	}
+
+	hash, err := taproot.KeySpendSigHash(tx, prevOuts, 0, hashType)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if err := taproot.VerifySchnorr(outputKey, hash, sig); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// writeWitness writes a witness stack in the transaction serialization format
+func writeWitness(b *bytes.Buffer, witness wire.TxWitness) error {
+	if err := wire.WriteVarInt(b, 0, uint64(len(witness))); err != nil {
+		return err
+	}
+
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(b, 0, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readWitness decodes a serialized witness stack, which must use all of raw
+func readWitness(raw []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(raw)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil || count == 0 || count > uint64(len(raw)) {
+		return nil, ErrInvalidSignature
+	}
+
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(r, 0, maxWitnessItemSize, "witness item")
+		if err != nil {
+			return nil, ErrInvalidSignature
+		}
+	}
+
+	if r.Len() != 0 {
+		return nil, ErrInvalidSignature
+	}
+	return witness, nil
+}
+
+// isP2WPKH returns true for a version 0 witness script paying to a 20 byte key hash
+func isP2WPKH(script []byte) bool {
+	return len(script) == 22 && script[0] == txscript.OP_0 && script[1] == txscript.OP_DATA_20
+}
+
+// isP2TR returns true for a version 1 witness script paying to a 32 byte output key
+func isP2TR(script []byte) bool {
+	return len(script) == 34 && script[0] == txscript.OP_1 && script[1] == txscript.OP_DATA_32
+}
@@ -0,0 +1,145 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package message
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
+)
+
+// BIP322 test vectors ref: https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#test-vectors
+const (
+	// P2WPKH script of bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l
+	testP2WPKHScript = "00142b05d564e6a7a33c087f16e0f730d1440123799d"
+
+	// P2TR script of bc1ppv609nr0vr25u07u95waq5lucwfm6tde4nydujnu8npg4q75mr5sxq8lt3
+	testP2TRScript = "51200b34f2cc6f60d54e3fdc2d1dd053fcc393bd2db9acc8de4a7c3cc28a83d4d8e9"
+
+	testP2WPKHEmptySig = "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+	testP2WPKHSig      = "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+	testP2TRSig        = "AUHd69PrJQEv+oKTfZ8l+WROBHuy9HKrbFCJu7U1iK2iiEy1vMU5EfMtjc+VSHM7aU0SDbak5IUZRVno2P5mjSafAQ=="
+)
+
+func TestBIP322Hash(t *testing.T) {
+	hashes := map[string]string{
+		"":          "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1",
+		testMessage: "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a",
+	}
+
+	for msg, expected := range hashes {
+		if h := hex.EncodeToString(BIP322Hash(msg)); h != expected {
+			t.Errorf("message hash of %q is not expected value want %s got %s", msg, expected, h)
+		}
+	}
+}
+
+func TestVirtualTransactions(t *testing.T) {
+	script, _ := hex.DecodeString(testP2WPKHScript)
+	txs := []struct {
+		msg     string
+		toSpend string
+		toSign  string
+	}{
+		{"", "c5680aa69bb8d860bf82d4e9cd3504b55dde018de765a91bb566283c545a99a7", "1e9654e951a5ba44c8604c4de6c67fd78a27e81dcadcfe1edf638ba3aaebaed6"},
+		{testMessage, "b79d196740ad5217771c1098fc4a4b51e0535c32236c71f1ea4d61a2d603352b", "88737ae86f2077145f93cc4b153ae9a1cb8d56afa511988c149c5c8c9d93bddf"},
+	}
+
+	for _, tx := range txs {
+		if id := ToSpend(script, tx.msg).TxHash().String(); id != tx.toSpend {
+			t.Errorf("to_spend of %q is not expected value want %s got %s", tx.msg, tx.toSpend, id)
+		}
+
+		if id := ToSign(script, tx.msg).TxHash().String(); id != tx.toSign {
+			t.Errorf("to_sign of %q is not expected value want %s got %s", tx.msg, tx.toSign, id)
+		}
+	}
+}
+
+func TestVerifySimple(t *testing.T) {
+	p2wpkh, _ := hex.DecodeString(testP2WPKHScript)
+	p2tr, _ := hex.DecodeString(testP2TRScript)
+
+	signatures := []struct {
+		script []byte
+		msg    string
+		sig    string
+	}{
+		{p2wpkh, "", testP2WPKHEmptySig},
+		{p2wpkh, testMessage, testP2WPKHSig},
+		{p2tr, testMessage, testP2TRSig},
+	}
+
+	for _, s := range signatures {
+		if err := VerifySimple(s.script, s.msg, s.sig); err != nil {
+			t.Errorf("signature of %q for %x did not verify: %s", s.msg, s.script, err.Error())
+		}
+
+		if err := VerifySimple(s.script, s.msg+"!", s.sig); err != ErrInvalidSignature {
+			t.Errorf("signature of %q for %x verified for another message", s.msg, s.script)
+		}
+	}
+
+	// Signatures are bound to their address
+	if err := VerifySimple(p2wpkh, "", testP2WPKHSig); err != ErrInvalidSignature {
+		t.Error("signature verified for another message")
+	}
+
+	for _, sig := range []string{"", "AA==", "not base64!", testP2TRSig + "AA=="} {
+		if err := VerifySimple(p2tr, testMessage, sig); err != ErrInvalidSignature {
+			t.Errorf("malformed signature %q did not fail where expected", sig)
+		}
+	}
+}
+
+func TestSignSimple(t *testing.T) {
+	wif, err := btcutil.DecodeWIF(testWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2wpkh, _ := hex.DecodeString(testP2WPKHScript)
+	p2tr, _ := hex.DecodeString(testP2TRScript)
+
+	for _, script := range [][]byte{p2wpkh, p2tr} {
+		sig, err := SignSimple(wif.PrivKey, script, testMessage)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if err := VerifySimple(script, testMessage, sig); err != nil {
+			t.Errorf("signature for %x did not verify: %s", script, err.Error())
+		}
+	}
+
+	other, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01})
+	for _, script := range [][]byte{p2wpkh, p2tr} {
+		if _, err := SignSimple(other, script, testMessage); err != ErrKeyMismatch {
+			t.Errorf("signature for %x with another key did not fail where expected", script)
+		}
+	}
+
+	p2pkh, _ := hex.DecodeString("76a9142b05d564e6a7a33c087f16e0f730d1440123799d88ac")
+	if _, err := SignSimple(wif.PrivKey, p2pkh, testMessage); err != ErrUnsupportedScript {
+		t.Error("P2PKH simple signature did not fail where expected")
+	}
+}
@@ -0,0 +1,198 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/message"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+const (
+	testMessage = "Hello World"
+
+	// BIP322 test vector ref: https://github.com/bitcoin/bips/blob/master/bip-0322.mediawiki#test-vectors
+	testBIP322Address   = "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	testBIP322Signature = "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI="
+)
+
+func TestSignMessage(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, purpose := range []int{44, 49, 84} {
+		account, err := NewAccountFromSeed(seed, purpose, 0, testIsTestnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sig, err := account.SignMessage(1, true, testMessage)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		address, _ := account.ChangeAddress(1)
+		if err := VerifyMessage(address, testMessage, sig, testIsTestnet); err != nil {
+			t.Errorf("purpose %d signature did not verify: %s", purpose, err.Error())
+		}
+
+		if err := VerifyMessage(address, testMessage+"!", sig, testIsTestnet); err != message.ErrInvalidSignature {
+			t.Errorf("purpose %d signature verified for another message", purpose)
+		}
+
+		if other, _ := account.ChangeAddress(2); VerifyMessage(other, testMessage, sig, testIsTestnet) != message.ErrInvalidSignature {
+			t.Errorf("purpose %d signature verified for another address", purpose)
+		}
+	}
+
+	// The index functions sign like the account methods
+	account, err := NewAccountFromExtendedKey(testP2WPKHPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := SignMessageForIndex(testP2WPKHPriv, 0, false, testMessage, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if accountSig, _ := account.SignMessage(0, false, testMessage); accountSig != sig {
+		t.Errorf("index signature is not the account signature want %s got %s", accountSig, sig)
+	}
+
+	if err := VerifyMessage(testP2WPKH0, testMessage, sig, testIsTestnet); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := SignMessageForIndex(testP2WPKHPub, 0, false, testMessage, testIsTestnet); err != hdkeychain.ErrNotPrivExtKey {
+		t.Error("signature with a public key did not fail where expected")
+	}
+
+	// Electrum signs for segwit addresses with compressed P2PKH headers
+	wif, err := btcutil.DecodeWIF(testP2WPKH0WIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	electrumSig, err := message.SignCompact(wif.PrivKey, keys.ScriptP2PKH, true, message.BitcoinPrefix, testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyMessage(testP2WPKH0, testMessage, electrumSig, testIsTestnet); err != nil {
+		t.Errorf("P2PKH header signature for P2WPKH address did not verify: %s", err.Error())
+	}
+
+	if err := VerifyMessage(testP2WPKH0, testMessage, sig, true); err != ErrWrongNetwork {
+		t.Error("mainnet address verified on testnet")
+	}
+}
+
+func TestSignMessageLitecoin(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := NewAccountFromSeedOnNetwork(seed, 44, 0, network.LTCMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := account.SignMessage(0, false, testMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyMessageOnNetwork(testLTCP2PKH0, testMessage, sig, network.LTCMainnet); err != nil {
+		t.Error(err)
+	}
+
+	// Bitcoin signed message prefix gives another message hash
+	wif, err := account.AddressWIF(0, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, _ := btcutil.DecodeWIF(wif)
+	bitcoinSig, _ := message.SignCompact(w.PrivKey, keys.ScriptP2PKH, true, message.BitcoinPrefix, testMessage)
+	if err := VerifyMessageOnNetwork(testLTCP2PKH0, testMessage, bitcoinSig, network.LTCMainnet); err != message.ErrInvalidSignature {
+		t.Error("signature with the Bitcoin message prefix verified for Litecoin")
+	}
+}
+
+func TestSignMessageBIP322(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, purpose := range []int{84, 86} {
+		account, err := NewAccountFromSeed(seed, purpose, 0, testIsTestnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sig, err := account.SignMessageBIP322(0, false, testMessage)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		address, _ := account.Address(0)
+		if err := VerifyMessage(address, testMessage, sig, testIsTestnet); err != nil {
+			t.Errorf("purpose %d BIP322 signature did not verify: %s", purpose, err.Error())
+		}
+
+		if err := VerifyMessage(address, "", sig, testIsTestnet); err != message.ErrInvalidSignature {
+			t.Errorf("purpose %d BIP322 signature verified for another message", purpose)
+		}
+	}
+
+	if err := VerifyMessage(testBIP322Address, testMessage, testBIP322Signature, testIsTestnet); err != nil {
+		t.Errorf("BIP322 test vector did not verify: %s", err.Error())
+	}
+
+	account, err := NewAccountFromSeed(seed, 44, 0, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := account.SignMessageBIP322(0, false, testMessage); err != message.ErrUnsupportedScript {
+		t.Error("P2PKH BIP322 signature did not fail where expected")
+	}
+
+	account, err = NewAccountFromSeed(seed, 86, 0, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := account.SignMessage(0, false, testMessage); err != message.ErrUnsupportedScriptType {
+		t.Error("P2TR BIP137 signature did not fail where expected")
+	}
+}
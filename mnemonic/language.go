@@ -0,0 +1,144 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package mnemonic
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Language identifies a BIP39 wordlist
+type Language int
+
+const (
+	// English wordlist (default)
+	English Language = iota
+
+	// Japanese wordlist, words are joined with an ideographic space
+	Japanese
+
+	// Korean wordlist
+	Korean
+
+	// Spanish wordlist
+	Spanish
+
+	// ChineseSimplified wordlist
+	ChineseSimplified
+
+	// ChineseTraditional wordlist
+	ChineseTraditional
+
+	// French wordlist
+	French
+
+	// Italian wordlist
+	Italian
+
+	// Czech wordlist
+	Czech
+)
+
+const (
+	// wordSeparator joins words of a mnemonic sentence
+	wordSeparator = " "
+
+	// japaneseWordSeparator is the ideographic space (U+3000) used to join Japanese mnemonics
+	japaneseWordSeparator = "　"
+)
+
+var (
+	// ErrUnknownLanguage is returned when a language has no wordlist
+	ErrUnknownLanguage = errors.New("Unknown mnemonic language specified")
+
+	// languages lists every supported wordlist in the order used for language detection
+	languages = []Language{English, Japanese, Korean, Spanish, ChineseSimplified, ChineseTraditional, French, Italian, Czech}
+
+	// wordlists holds the words and reverse index for each supported language.
+	// Official wordlists are already NFKD normalized so they can be matched against normalized input directly
+	wordlists = map[Language]*wordlist{
+		English:            newWordlist("English", englishWords, wordSeparator),
+		Japanese:           newWordlist("Japanese", japaneseWords, japaneseWordSeparator),
+		Korean:             newWordlist("Korean", koreanWords, wordSeparator),
+		Spanish:            newWordlist("Spanish", spanishWords, wordSeparator),
+		ChineseSimplified:  newWordlist("Chinese (Simplified)", chineseSimplifiedWords, wordSeparator),
+		ChineseTraditional: newWordlist("Chinese (Traditional)", chineseTraditionalWords, wordSeparator),
+		French:             newWordlist("French", frenchWords, wordSeparator),
+		Italian:            newWordlist("Italian", italianWords, wordSeparator),
+		Czech:              newWordlist("Czech", czechWords, wordSeparator),
+	}
+)
+
+// wordlist is a BIP39 wordlist with its reverse index
+type wordlist struct {
+	name      string
+	words     []string
+	index     map[string]int
+	separator string
+}
+
+// newWordlist indexes words of a wordlist
+func newWordlist(name string, words []string, separator string) *wordlist {
+	index := make(map[string]int, len(words))
+	for i, w := range words {
+		index[w] = i
+	}
+	return &wordlist{name: name, words: words, index: index, separator: separator}
+}
+
+// String returns the name of the language
+func (l Language) String() string {
+	wl, ok := wordlists[l]
+	if !ok {
+		return fmt.Sprintf("Language(%d)", int(l))
+	}
+	return wl.name
+}
+
+// getWordlist returns the wordlist for a language
+func getWordlist(lang Language) (*wordlist, error) {
+	wl, ok := wordlists[lang]
+	if !ok {
+		return nil, ErrUnknownLanguage
+	}
+	return wl, nil
+}
+
+// containsAll returns true if every word is in the wordlist
+func (wl *wordlist) containsAll(words []string) bool {
+	for _, w := range words {
+		if _, ok := wl.index[w]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// firstUnknown returns the position of the first word not in the wordlist and the number of known words
+func (wl *wordlist) firstUnknown(words []string) (position int, known int) {
+	position = -1
+	for i, w := range words {
+		if _, ok := wl.index[w]; ok {
+			known++
+		} else if position < 0 {
+			position = i
+		}
+	}
+	return position, known
+}
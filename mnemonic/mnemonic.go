@@ -0,0 +1,243 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package mnemonic
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// MinEntropyBits is the smallest entropy size allowed by BIP39 (12 words)
+	MinEntropyBits = 128
+
+	// MaxEntropyBits is the largest entropy size allowed by BIP39 (24 words)
+	MaxEntropyBits = 256
+
+	// bitsPerWord is the number of bits encoded by each word of the wordlist (2^11 = 2048 words)
+	bitsPerWord = 11
+
+	// seedIterations and seedLength are the PBKDF2-HMAC-SHA512 parameters used to stretch a mnemonic into a seed
+	seedIterations = 2048
+	seedLength     = 64
+
+	// seedSaltPrefix is prepended to the optional passphrase to form the PBKDF2 salt
+	seedSaltPrefix = "mnemonic"
+)
+
+var (
+	// ErrInvalidEntropyLength is returned when entropy is not 128-256 bits in 32 bit steps
+	ErrInvalidEntropyLength = errors.New("Entropy must be between 128 and 256 bits and a multiple of 32 bits")
+
+	// ErrInvalidWordCount is returned when a mnemonic does not contain 12, 15, 18, 21 or 24 words
+	ErrInvalidWordCount = errors.New("Mnemonic must contain 12, 15, 18, 21 or 24 words")
+
+	// ErrChecksumMismatch is returned when the checksum bits of a mnemonic do not match its entropy
+	ErrChecksumMismatch = errors.New("Mnemonic checksum is invalid")
+)
+
+// GenerateEntropy returns cryptographically secure random entropy of the given size in bits
+func GenerateEntropy(bitSize int) ([]byte, error) {
+	if err := validateEntropyBits(bitSize); err != nil {
+		return nil, err
+	}
+
+	entropy := make([]byte, bitSize/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+// GenerateMnemonic returns a new random English mnemonic backed by entropy of the given size in bits
+func GenerateMnemonic(bitSize int) (string, error) {
+	return GenerateMnemonicInLanguage(bitSize, English)
+}
+
+// GenerateMnemonicInLanguage returns a new random mnemonic using the wordlist of lang
+func GenerateMnemonicInLanguage(bitSize int, lang Language) (string, error) {
+	entropy, err := GenerateEntropy(bitSize)
+	if err != nil {
+		return "", err
+	}
+	return GetMnemonicFromEntropyInLanguage(entropy, lang)
+}
+
+// GetMnemonicFromEntropy returns the English mnemonic sentence encoding entropy and its checksum
+func GetMnemonicFromEntropy(entropy []byte) (string, error) {
+	return GetMnemonicFromEntropyInLanguage(entropy, English)
+}
+
+// GetMnemonicFromEntropyInLanguage returns the mnemonic sentence encoding entropy and its checksum using the wordlist of lang
+func GetMnemonicFromEntropyInLanguage(entropy []byte, lang Language) (string, error) {
+	wl, err := getWordlist(lang)
+	if err != nil {
+		return "", err
+	}
+
+	entropyBits := len(entropy) * 8
+	if err := validateEntropyBits(entropyBits); err != nil {
+		return "", err
+	}
+
+	// The checksum is the first ENT/32 bits of SHA256(entropy), at most 8 bits so one byte is sufficient
+	h := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), h[0])
+
+	words := make([]string, (entropyBits+entropyBits/32)/bitsPerWord)
+	for i := range words {
+		words[i] = wl.words[readBits(data, i*bitsPerWord, bitsPerWord)]
+	}
+
+	return strings.Join(words, wl.separator), nil
+}
+
+// GetEntropyFromMnemonic returns the entropy encoded by a mnemonic in any supported language after validating its words and checksum
+func GetEntropyFromMnemonic(mnemonic string) ([]byte, error) {
+	entropy, _, err := decodeMnemonic(mnemonic)
+	return entropy, err
+}
+
+// DetectLanguage returns the language of a valid mnemonic
+func DetectLanguage(mnemonic string) (Language, error) {
+	_, lang, err := decodeMnemonic(mnemonic)
+	return lang, err
+}
+
+// IsValid returns true if mnemonic has a valid word count, words from a single wordlist and a matching checksum
+func IsValid(mnemonic string) bool {
+	_, _, err := decodeMnemonic(mnemonic)
+	return err == nil
+}
+
+// GetSeedFromMnemonic validates mnemonic and returns the 64 byte BIP39 seed protected by an optional passphrase.
+// Both mnemonic and passphrase are NFKD normalized as required by BIP39.
+// The result can be passed to keys.GetExtendedMasterPrivateKeyFromSeedBytes
+func GetSeedFromMnemonic(mnemonic string, passphrase string) ([]byte, error) {
+	if _, _, err := decodeMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+
+	// Ideographic spaces decompose to plain spaces under NFKD so every language is joined the same way here
+	sentence := strings.Join(normalizedWords(mnemonic), wordSeparator)
+	salt := seedSaltPrefix + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(sentence), []byte(salt), seedIterations, seedLength, sha512.New), nil
+}
+
+// decodeMnemonic detects the wordlist of a mnemonic and returns its entropy.
+// Some words appear in more than one wordlist (e.g. Chinese), so each candidate list is tried until the checksum matches
+func decodeMnemonic(mnemonic string) ([]byte, Language, error) {
+	words := normalizedWords(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, English, ErrInvalidWordCount
+	}
+
+	var candidates []Language
+	for _, lang := range languages {
+		if wordlists[lang].containsAll(words) {
+			candidates = append(candidates, lang)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, English, unknownWordError(words)
+	}
+
+	for _, lang := range candidates {
+		entropy, err := decodeWords(words, wordlists[lang])
+		if err == nil {
+			return entropy, lang, nil
+		}
+	}
+
+	return nil, candidates[0], ErrChecksumMismatch
+}
+
+// decodeWords returns the entropy encoded by words of a wordlist after checking the checksum
+func decodeWords(words []string, wl *wordlist) ([]byte, error) {
+	totalBits := len(words) * bitsPerWord
+	checksumBits := totalBits / 33
+	entropyBits := totalBits - checksumBits
+
+	data := make([]byte, (totalBits+7)/8)
+	for i, w := range words {
+		writeBits(data, i*bitsPerWord, bitsPerWord, wl.index[w])
+	}
+
+	entropy := data[:entropyBits/8]
+	h := sha256.Sum256(entropy)
+	if readBits(data, entropyBits, checksumBits) != readBits(h[:], 0, checksumBits) {
+		return nil, ErrChecksumMismatch
+	}
+
+	return entropy, nil
+}
+
+// unknownWordError reports the first unknown word against the wordlist that recognises the most words
+func unknownWordError(words []string) error {
+	best, bestKnown, position := wordlists[English], -1, 0
+	for _, lang := range languages {
+		p, known := wordlists[lang].firstUnknown(words)
+		if known > bestKnown {
+			best, bestKnown, position = wordlists[lang], known, p
+		}
+	}
+	return fmt.Errorf("Mnemonic word %d (%q) is not in the %s wordlist", position+1, words[position], best.name)
+}
+
+// normalizedWords returns the NFKD normalized words of a mnemonic sentence
+func normalizedWords(mnemonic string) []string {
+	return strings.Fields(norm.NFKD.String(mnemonic))
+}
+
+// validateEntropyBits checks entropy size is 128-256 bits and a multiple of 32
+func validateEntropyBits(bitSize int) error {
+	if bitSize < MinEntropyBits || bitSize > MaxEntropyBits || bitSize%32 != 0 {
+		return ErrInvalidEntropyLength
+	}
+	return nil
+}
+
+// readBits returns n bits of data starting at bit offset (most significant bit first)
+func readBits(data []byte, offset int, n int) int {
+	v := 0
+	for i := offset; i < offset+n; i++ {
+		v = v<<1 | int(data[i/8]>>uint(7-i%8)&1)
+	}
+	return v
+}
+
+// writeBits stores the lowest n bits of v into data starting at bit offset (most significant bit first)
+func writeBits(data []byte, offset int, n int, v int) {
+	for i := 0; i < n; i++ {
+		if v>>uint(n-1-i)&1 == 1 {
+			bit := offset + i
+			data[bit/8] |= 1 << uint(7-bit%8)
+		}
+	}
+}
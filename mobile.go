@@ -21,6 +21,8 @@ package sanswallet
 import (
 	"fmt"
 	"strconv"
+
+	"github.com/sanscentral/sanswallet/network"
 )
 
 // intToUint converts integer type to unsigned integer type
@@ -33,3 +35,11 @@ func intToUint32(i int) (uint32, error) {
 	}
 	return uint32(ui), nil
 }
+
+// testnetToNetwork returns the network selected by the testnet flag of the boolean API
+func testnetToNetwork(testnet bool) network.Network {
+	if testnet {
+		return network.BTCTestnet
+	}
+	return network.BTCMainnet
+}
@@ -0,0 +1,148 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package network
+
+var (
+	// bitcoinScriptTypes are the address script types of every Bitcoin network
+	bitcoinScriptTypes = []ScriptType{ScriptP2PKH, ScriptP2WPKHInP2SH, ScriptP2WPKH, ScriptP2TR}
+
+	// bitcoinMainnetKeyVersions are the SLIP-132 versions of Bitcoin mainnet (https://github.com/satoshilabs/slips/blob/master/slip-0132.md)
+	bitcoinMainnetKeyVersions = []KeyVersion{
+		{ScriptP2PKH, [4]byte{0x04, 0x88, 0xad, 0xe4}, [4]byte{0x04, 0x88, 0xb2, 0x1e}, "xprv", "xpub"},
+		{ScriptP2WPKHInP2SH, [4]byte{0x04, 0x9d, 0x78, 0x78}, [4]byte{0x04, 0x9d, 0x7c, 0xb2}, "yprv", "ypub"},
+		{ScriptP2WPKH, [4]byte{0x04, 0xb2, 0x43, 0x0c}, [4]byte{0x04, 0xb2, 0x47, 0x46}, "zprv", "zpub"},
+		{ScriptP2WSHInP2SH, [4]byte{0x02, 0x95, 0xb0, 0x05}, [4]byte{0x02, 0x95, 0xb4, 0x3f}, "Yprv", "Ypub"},
+		{ScriptP2WSH, [4]byte{0x02, 0xaa, 0x7a, 0x99}, [4]byte{0x02, 0xaa, 0x7e, 0xd3}, "Zprv", "Zpub"},
+	}
+
+	// bitcoinTestnetKeyVersions are the SLIP-132 versions shared by testnet, regtest and signet
+	bitcoinTestnetKeyVersions = []KeyVersion{
+		{ScriptP2PKH, [4]byte{0x04, 0x35, 0x83, 0x94}, [4]byte{0x04, 0x35, 0x87, 0xcf}, "tprv", "tpub"},
+		{ScriptP2WPKHInP2SH, [4]byte{0x04, 0x4a, 0x4e, 0x28}, [4]byte{0x04, 0x4a, 0x52, 0x62}, "uprv", "upub"},
+		{ScriptP2WPKH, [4]byte{0x04, 0x5f, 0x18, 0xbc}, [4]byte{0x04, 0x5f, 0x1c, 0xf6}, "vprv", "vpub"},
+		{ScriptP2WSHInP2SH, [4]byte{0x02, 0x42, 0x85, 0xb5}, [4]byte{0x02, 0x42, 0x89, 0xef}, "Uprv", "Upub"},
+		{ScriptP2WSH, [4]byte{0x02, 0x57, 0x50, 0x48}, [4]byte{0x02, 0x57, 0x54, 0x83}, "Vprv", "Vpub"},
+	}
+)
+
+// bitcoinMessagePrefix is the signed message magic shared by Bitcoin networks and Bitcoin Cash
+const bitcoinMessagePrefix = "Bitcoin Signed Message:\n"
+
+// builtinDefinitions are registered at startup under their fixed network identifiers.
+// Bitcoin test networks derive accounts with coin type 0 like mainnet, as this wallet always has
+var builtinDefinitions = map[Network]Definition{
+	BTCMainnet: {
+		Name:             "mainnet",
+		CoinType:         0,
+		Magic:            0xd9b4bef9,
+		PubKeyHashAddrID: 0x00,
+		ScriptHashAddrID: 0x05,
+		PrivateKeyID:     0x80,
+		Bech32HRP:        "bc",
+		MessagePrefix:    bitcoinMessagePrefix,
+		ScriptTypes:      bitcoinScriptTypes,
+		KeyVersions:      bitcoinMainnetKeyVersions,
+	},
+	BTCTestnet: {
+		Name:             "testnet",
+		Testnet:          true,
+		CoinType:         0,
+		Magic:            0x0709110b,
+		PubKeyHashAddrID: 0x6f,
+		ScriptHashAddrID: 0xc4,
+		PrivateKeyID:     0xef,
+		Bech32HRP:        "tb",
+		MessagePrefix:    bitcoinMessagePrefix,
+		ScriptTypes:      bitcoinScriptTypes,
+		KeyVersions:      bitcoinTestnetKeyVersions,
+	},
+	BTCRegtest: {
+		Name:             "regtest",
+		Testnet:          true,
+		CoinType:         0,
+		Magic:            0xdab5bffa,
+		PubKeyHashAddrID: 0x6f,
+		ScriptHashAddrID: 0xc4,
+		PrivateKeyID:     0xef,
+		Bech32HRP:        "bcrt",
+		MessagePrefix:    bitcoinMessagePrefix,
+		ScriptTypes:      bitcoinScriptTypes,
+		KeyVersions:      bitcoinTestnetKeyVersions,
+	},
+	BTCSignet: {
+		Name:             "signet",
+		Testnet:          true,
+		CoinType:         0,
+		Magic:            0x40cf030a,
+		PubKeyHashAddrID: 0x6f,
+		ScriptHashAddrID: 0xc4,
+		PrivateKeyID:     0xef,
+		Bech32HRP:        "tb",
+		MessagePrefix:    bitcoinMessagePrefix,
+		ScriptTypes:      bitcoinScriptTypes,
+		KeyVersions:      bitcoinTestnetKeyVersions,
+	},
+
+	// Litecoin uses the Ltub/Mtub versions of SLIP-132 and the zpub versions of Bitcoin for native segwit
+	LTCMainnet: {
+		Name:             "litecoin",
+		CoinType:         2,
+		Magic:            0xdbb6c0fb,
+		PubKeyHashAddrID: 0x30,
+		ScriptHashAddrID: 0x32,
+		PrivateKeyID:     0xb0,
+		Bech32HRP:        "ltc",
+		MessagePrefix:    "Litecoin Signed Message:\n",
+		ScriptTypes:      []ScriptType{ScriptP2PKH, ScriptP2WPKHInP2SH, ScriptP2WPKH},
+		KeyVersions: []KeyVersion{
+			{ScriptP2PKH, [4]byte{0x01, 0x9d, 0x9c, 0xfe}, [4]byte{0x01, 0x9d, 0xa4, 0x62}, "Ltpv", "Ltub"},
+			{ScriptP2WPKHInP2SH, [4]byte{0x01, 0xb2, 0x67, 0x92}, [4]byte{0x01, 0xb2, 0x6e, 0xf6}, "Mtpv", "Mtub"},
+			{ScriptP2WPKH, [4]byte{0x04, 0xb2, 0x43, 0x0c}, [4]byte{0x04, 0xb2, 0x47, 0x46}, "zprv", "zpub"},
+		},
+	},
+
+	// Dogecoin has no segwit, only P2PKH addresses with dgpv/dgub account keys
+	DOGEMainnet: {
+		Name:             "dogecoin",
+		CoinType:         3,
+		Magic:            0xc0c0c0c0,
+		PubKeyHashAddrID: 0x1e,
+		ScriptHashAddrID: 0x16,
+		PrivateKeyID:     0x9e,
+		MessagePrefix:    "Dogecoin Signed Message:\n",
+		ScriptTypes:      []ScriptType{ScriptP2PKH},
+		KeyVersions: []KeyVersion{
+			{ScriptP2PKH, [4]byte{0x02, 0xfa, 0xc3, 0x98}, [4]byte{0x02, 0xfa, 0xca, 0xfd}, "dgpv", "dgub"},
+		},
+	},
+
+	// Bitcoin Cash keeps the Bitcoin base58 prefixes and xpub versions, addresses are CashAddr by default
+	BCHMainnet: {
+		Name:             "bitcoincash",
+		CoinType:         145,
+		Magic:            0xe8f3e1e3,
+		PubKeyHashAddrID: 0x00,
+		ScriptHashAddrID: 0x05,
+		PrivateKeyID:     0x80,
+		CashAddrPrefix:   "bitcoincash",
+		MessagePrefix:    bitcoinMessagePrefix,
+		ScriptTypes:      []ScriptType{ScriptP2PKH},
+		KeyVersions:      bitcoinMainnetKeyVersions[:1],
+	},
+}
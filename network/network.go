@@ -18,6 +18,13 @@
 
 package network
 
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
 // Network references a distributed network endpoint
 type Network int16
 
@@ -27,4 +34,215 @@ const (
 
 	// BTCMainnet Bitcoin main network
 	BTCMainnet Network = 1
+
+	// BTCRegtest Bitcoin regression test network (local bitcoind -regtest)
+	BTCRegtest Network = 2
+
+	// BTCSignet Bitcoin default signet (BIP325)
+	BTCSignet Network = 3
+
+	// LTCMainnet Litecoin main network
+	LTCMainnet Network = 4
+
+	// DOGEMainnet Dogecoin main network
+	DOGEMainnet Network = 5
+
+	// BCHMainnet Bitcoin Cash main network
+	BCHMainnet Network = 6
+)
+
+var (
+	// ErrUnknownNetwork is returned for a network that has no registered definition
+	ErrUnknownNetwork = errors.New("Unknown network specified")
+
+	// ErrDuplicateNetwork is returned when a definition is registered under a name that is already taken
+	ErrDuplicateNetwork = errors.New("A network with this name is already registered")
+
+	// ErrInvalidDefinition is returned when a definition has no name or no P2PKH key versions
+	ErrInvalidDefinition = errors.New("Network definition must have a name and P2PKH key versions")
 )
+
+// KeyVersion holds the BIP32/SLIP-132 extended key version bytes of one script type
+type KeyVersion struct {
+	ScriptType    ScriptType
+	Private       [4]byte
+	Public        [4]byte
+	PrivatePrefix string
+	PublicPrefix  string
+}
+
+// Definition describes a coin network: everything needed to derive its accounts and encode keys and addresses
+type Definition struct {
+	// Name is the short identifier of the network (e.g. mainnet, litecoin), returned by Network.String
+	Name string
+
+	// Testnet is true for networks whose coins have no value (testnet, regtest, signet)
+	Testnet bool
+
+	// CoinType is the SLIP-44 coin type used in BIP44 style paths (m / purpose' / coin_type' / account')
+	CoinType uint32
+
+	// Magic is the network message start bytes
+	Magic uint32
+
+	// PubKeyHashAddrID and ScriptHashAddrID are the base58check version bytes of P2PKH and P2SH addresses
+	PubKeyHashAddrID byte
+	ScriptHashAddrID byte
+
+	// PrivateKeyID is the WIF version byte of private keys
+	PrivateKeyID byte
+
+	// Bech32HRP is the human readable part of segwit addresses, empty for coins without segwit
+	Bech32HRP string
+
+	// CashAddrPrefix is the prefix of CashAddr addresses (e.g. bitcoincash), empty for coins with base58 P2PKH addresses only
+	CashAddrPrefix string
+
+	// MessagePrefix is the BIP137 signed message magic (e.g. "Bitcoin Signed Message:\n"), the Bitcoin one is used when empty
+	MessagePrefix string
+
+	// ScriptTypes lists the address script types the coin supports
+	ScriptTypes []ScriptType
+
+	// KeyVersions lists the extended key versions per script type, the P2PKH entry is the BIP32 version of the coin.
+	// Script types absent here (e.g. P2TR) are serialized with the P2PKH versions
+	KeyVersions []KeyVersion
+}
+
+// SupportsScriptType returns true if the coin has addresses of scriptType
+func (d *Definition) SupportsScriptType(scriptType ScriptType) bool {
+	for _, s := range d.ScriptTypes {
+		if s == scriptType {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyVersion returns the extended key versions of a script type.
+// Supported script types without versions of their own (BIP86 Taproot) use the BIP32 versions
+func (d *Definition) KeyVersion(scriptType ScriptType) (KeyVersion, bool) {
+	var bip32 *KeyVersion
+	for i, v := range d.KeyVersions {
+		if v.ScriptType == scriptType {
+			return v, true
+		}
+		if v.ScriptType == ScriptP2PKH {
+			bip32 = &d.KeyVersions[i]
+		}
+	}
+
+	if bip32 == nil || !d.SupportsScriptType(scriptType) {
+		return KeyVersion{}, false
+	}
+
+	v := *bip32
+	v.ScriptType = scriptType
+	return v, true
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[Network]*Definition{}
+)
+
+func init() {
+	for net, def := range builtinDefinitions {
+		if err := register(net, def); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register adds a coin definition to the registry and returns the Network identifying it.
+// Networks are numbered after the predefined ones in registration order
+func Register(def Definition) (Network, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	net := Network(0)
+	for n := range registry {
+		if n >= net {
+			net = n + 1
+		}
+	}
+
+	if err := registerLocked(net, def); err != nil {
+		return 0, err
+	}
+	return net, nil
+}
+
+// GetDefinition returns the definition of a registered network
+func GetDefinition(net Network) (*Definition, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	def, ok := registry[net]
+	if !ok {
+		return nil, ErrUnknownNetwork
+	}
+	return def, nil
+}
+
+// Lookup returns the registered network with a name (e.g. regtest, litecoin)
+func Lookup(name string) (Network, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for net, def := range registry {
+		if strings.EqualFold(def.Name, name) {
+			return net, nil
+		}
+	}
+	return 0, ErrUnknownNetwork
+}
+
+// Networks returns every registered network in ascending order
+func Networks() []Network {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	nets := make([]Network, 0, len(registry))
+	for net := range registry {
+		nets = append(nets, net)
+	}
+	sort.Slice(nets, func(i, j int) bool { return nets[i] < nets[j] })
+	return nets
+}
+
+// String returns the name of the network
+func (n Network) String() string {
+	def, err := GetDefinition(n)
+	if err != nil {
+		return "unknown"
+	}
+	return def.Name
+}
+
+// register adds a definition under a fixed network identifier
+func register(net Network, def Definition) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return registerLocked(net, def)
+}
+
+// registerLocked validates and stores a definition, the caller holds registryMu
+func registerLocked(net Network, def Definition) error {
+	if def.Name == "" {
+		return ErrInvalidDefinition
+	}
+
+	if _, ok := def.KeyVersion(ScriptP2PKH); !ok {
+		return ErrInvalidDefinition
+	}
+
+	for _, d := range registry {
+		if strings.EqualFold(d.Name, def.Name) {
+			return ErrDuplicateNetwork
+		}
+	}
+
+	registry[net] = &def
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package network
+
+import "testing"
+
+func TestBuiltinNetworks(t *testing.T) {
+	names := map[Network]string{
+		BTCTestnet:  "testnet",
+		BTCMainnet:  "mainnet",
+		BTCRegtest:  "regtest",
+		BTCSignet:   "signet",
+		LTCMainnet:  "litecoin",
+		DOGEMainnet: "dogecoin",
+		BCHMainnet:  "bitcoincash",
+	}
+
+	for net, name := range names {
+		if net.String() != name {
+			t.Errorf("network %d name is %s want %s", net, net.String(), name)
+		}
+
+		found, err := Lookup(name)
+		if err != nil || found != net {
+			t.Errorf("lookup of %s returned %d", name, found)
+		}
+	}
+
+	if Network(99).String() != "unknown" {
+		t.Error("unknown network name is not expected value")
+	}
+
+	if _, err := Lookup("bitcoin-cash"); err != ErrUnknownNetwork {
+		t.Error("unknown network name did not fail where expected")
+	}
+
+	def, err := GetDefinition(LTCMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if def.CoinType != 2 || def.Bech32HRP != "ltc" || def.SupportsScriptType(ScriptP2TR) {
+		t.Errorf("litecoin definition is not expected value, got coin type %d prefix %s", def.CoinType, def.Bech32HRP)
+	}
+
+	def, err = GetDefinition(BTCMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Taproot keys use the BIP32 versions
+	if v, ok := def.KeyVersion(ScriptP2TR); !ok || v.PublicPrefix != "xpub" || v.ScriptType != ScriptP2TR {
+		t.Errorf("mainnet P2TR key version is not expected value, got %s", v.PublicPrefix)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	def := Definition{
+		Name:             "vertcoin",
+		CoinType:         28,
+		Magic:            0xdab5bffa,
+		PubKeyHashAddrID: 0x47,
+		ScriptHashAddrID: 0x05,
+		PrivateKeyID:     0x80,
+		Bech32HRP:        "vtc",
+		ScriptTypes:      []ScriptType{ScriptP2PKH, ScriptP2WPKH},
+		KeyVersions:      bitcoinMainnetKeyVersions,
+	}
+
+	net, err := Register(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if net <= BCHMainnet {
+		t.Errorf("registered network %d collides with a predefined network", net)
+	}
+
+	nets := Networks()
+	if nets[len(nets)-1] != net {
+		t.Errorf("registered network %d is not last of %v", net, nets)
+	}
+
+	if found, err := GetDefinition(net); err != nil || found.CoinType != 28 {
+		t.Error("registered definition was not returned")
+	}
+
+	if _, err := Register(def); err != ErrDuplicateNetwork {
+		t.Error("duplicate network name did not fail where expected")
+	}
+
+	if _, err := Register(Definition{Name: "empty"}); err != ErrInvalidDefinition {
+		t.Error("definition without key versions did not fail where expected")
+	}
+}
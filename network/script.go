@@ -0,0 +1,61 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package network
+
+// ScriptType is the output script an extended key is intended for (SLIP-132)
+type ScriptType int
+
+const (
+	// ScriptP2PKH pay-to-public-key-hash (and legacy P2SH multisig), xpub/tpub
+	ScriptP2PKH ScriptType = iota
+
+	// ScriptP2WPKHInP2SH P2WPKH nested in P2SH, ypub/upub
+	ScriptP2WPKHInP2SH
+
+	// ScriptP2WPKH native segwit pay-to-witness-public-key-hash, zpub/vpub
+	ScriptP2WPKH
+
+	// ScriptP2WSHInP2SH multisig P2WSH nested in P2SH, Ypub/Upub
+	ScriptP2WSHInP2SH
+
+	// ScriptP2WSH native segwit multisig pay-to-witness-script-hash, Zpub/Vpub
+	ScriptP2WSH
+
+	// ScriptP2TR Taproot pay-to-taproot single key (BIP86), serialized as xpub/tpub
+	ScriptP2TR
+)
+
+// String returns the name of the script type
+func (s ScriptType) String() string {
+	switch s {
+	case ScriptP2PKH:
+		return "P2PKH"
+	case ScriptP2WPKHInP2SH:
+		return "P2SH-P2WPKH"
+	case ScriptP2WPKH:
+		return "P2WPKH"
+	case ScriptP2WSHInP2SH:
+		return "P2SH-P2WSH"
+	case ScriptP2WSH:
+		return "P2WSH"
+	case ScriptP2TR:
+		return "P2TR"
+	}
+	return "Unknown"
+}
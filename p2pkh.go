@@ -19,31 +19,25 @@
 package sanswallet
 
 import (
-	"errors"
-
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
 
 	"github.com/sanscentral/sanswallet/keys"
 	"github.com/sanscentral/sanswallet/network"
 )
 
-var (
-	// possible prefixes for a P2PKH key (Used for key validation)
-	p2pkhHumanPre = map[string]bool{"xpub": true, "xprv": true, "tpub": true, "tprv": true}
-)
-
 // GetExtPrvForP2PKHAccount returns extended private key for BIP44 P2PKH account
 func GetExtPrvForP2PKHAccount(seed []byte, accountIndex int, testnet bool) (string, error) {
+	return GetExtPrvForP2PKHAccountOnNetwork(seed, accountIndex, testnetToNetwork(testnet))
+}
+
+// GetExtPrvForP2PKHAccountOnNetwork returns extended private key for BIP44 P2PKH account on net
+func GetExtPrvForP2PKHAccountOnNetwork(seed []byte, accountIndex int, net network.Network) (string, error) {
 	index, err := intToUint32(accountIndex)
 	if err != nil {
 		return "", err
 	}
 
-	net := network.BTCMainnet
-	if testnet {
-		net = network.BTCTestnet
-	}
-
 	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
 	if err != nil {
 		return "", err
@@ -51,21 +45,21 @@ func GetExtPrvForP2PKHAccount(seed []byte, accountIndex int, testnet bool) (stri
 
 	// Note: version prefix is unchanged for P2PKH since it matches network params
 
-	return keys.GetBIP44AccountKey(m, index, true)
+	return keys.GetAccountKeyOnNetwork(m, keys.BIP44Purpose, net, index, true)
 }
 
 // GetExtPubForP2PKHAccount returns extended public key for BIP44 P2PKH account
 func GetExtPubForP2PKHAccount(seed []byte, accountIndex int, testnet bool) (string, error) {
+	return GetExtPubForP2PKHAccountOnNetwork(seed, accountIndex, testnetToNetwork(testnet))
+}
+
+// GetExtPubForP2PKHAccountOnNetwork returns extended public key for BIP44 P2PKH account on net
+func GetExtPubForP2PKHAccountOnNetwork(seed []byte, accountIndex int, net network.Network) (string, error) {
 	index, err := intToUint32(accountIndex)
 	if err != nil {
 		return "", err
 	}
 
-	net := network.BTCMainnet
-	if testnet {
-		net = network.BTCTestnet
-	}
-
 	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
 	if err != nil {
 		return "", err
@@ -73,15 +67,19 @@ func GetExtPubForP2PKHAccount(seed []byte, accountIndex int, testnet bool) (stri
 
 	// Note: version prefix is unchanged for P2PKH since it matches network params
 
-	return keys.GetBIP44AccountKey(m, index, false)
+	return keys.GetAccountKeyOnNetwork(m, keys.BIP44Purpose, net, index, false)
 }
 
 // GetP2PKHAddressForIndex returns address for BTC account at given index
 // P2PK ('1' prefixed addresses) origional pay-to-public-key (use BIP44 derived key)
 func GetP2PKHAddressForIndex(accountKey string, addressIndex int, isChange bool, testnet bool) (string, error) {
-	validPre := p2pkhHumanPre[accountKey[:4]]
-	if !validPre {
-		return "", errors.New("Key does not start with a P2SH prefix")
+	return GetP2PKHAddressForIndexOnNetwork(accountKey, addressIndex, isChange, testnetToNetwork(testnet))
+}
+
+// GetP2PKHAddressForIndexOnNetwork returns the P2PKH address for account extended key at given index on net
+func GetP2PKHAddressForIndexOnNetwork(accountKey string, addressIndex int, isChange bool, net network.Network) (string, error) {
+	if err := checkAccountKeyVersion(accountKey, keys.ScriptP2PKH, net); err != nil {
+		return "", err
 	}
 
 	index, err := intToUint32(addressIndex)
@@ -99,11 +97,16 @@ func GetP2PKHAddressForIndex(accountKey string, addressIndex int, isChange bool,
 		return "", err
 	}
 
-	netParam := &chaincfg.MainNetParams
-	if testnet {
-		netParam = &chaincfg.TestNet3Params
+	netParam, err := keys.GetChainParams(net)
+	if err != nil {
+		return "", err
 	}
 
+	return getP2PKHAddress(k, netParam)
+}
+
+// getP2PKHAddress returns the P2PKH address of an address key
+func getP2PKHAddress(k *hdkeychain.ExtendedKey, netParam *chaincfg.Params) (string, error) {
 	a, err := k.Address(netParam)
 	if err != nil {
 		return "", err
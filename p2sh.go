@@ -19,8 +19,6 @@
 package sanswallet
 
 import (
-	"errors"
-
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil"
@@ -30,67 +28,66 @@ import (
 	"github.com/sanscentral/sanswallet/network"
 )
 
-var (
-	// Version bytes of master public/private keys indicate what type of output script should be used.
-	privP2WPKHinP2SHVer = [4]byte{0x04, 0x9d, 0x78, 0x78}
-	pubP2WPKHinP2SHVer  = [4]byte{0x04, 0x9d, 0x7c, 0xb2}
-
-	// possible prefixes for a P2SH key (Used for key validation)
-	p2shHumanPre = map[string]bool{"ypub": true, "yprv": true}
-)
-
 // GetExtPrvForP2SHAccount returns extended private key for BIP49 P2SH account
 func GetExtPrvForP2SHAccount(seed []byte, accountIndex int, testnet bool) (string, error) {
+	return GetExtPrvForP2SHAccountOnNetwork(seed, accountIndex, testnetToNetwork(testnet))
+}
+
+// GetExtPrvForP2SHAccountOnNetwork returns extended private key for BIP49 P2SH account on net
+func GetExtPrvForP2SHAccountOnNetwork(seed []byte, accountIndex int, net network.Network) (string, error) {
 	index, err := intToUint32(accountIndex)
 	if err != nil {
 		return "", err
 	}
 
-	net := network.BTCMainnet
-	if testnet {
-		net = network.BTCTestnet
-	}
-
 	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
 	if err != nil {
 		return "", err
 	}
 
-	k, err := keys.GetBIP49AccountKey(m, index, true)
+	k, err := keys.GetAccountKeyOnNetwork(m, keys.BIP49Purpose, net, index, true)
 	if err != nil {
 		return "", err
 	}
 
-	return hdkeychain.VersionedStringFromExtendedKeyString(k, privP2WPKHinP2SHVer)
+	return getVersionedAccountKey(k, keys.ScriptP2WPKHInP2SH, net, true)
 }
 
 // GetExtPubForP2SHAccount returns extended public key for BIP49 P2SH account
 func GetExtPubForP2SHAccount(seed []byte, accountIndex int, testnet bool) (string, error) {
+	return GetExtPubForP2SHAccountOnNetwork(seed, accountIndex, testnetToNetwork(testnet))
+}
+
+// GetExtPubForP2SHAccountOnNetwork returns extended public key for BIP49 P2SH account on net
+func GetExtPubForP2SHAccountOnNetwork(seed []byte, accountIndex int, net network.Network) (string, error) {
 	index, err := intToUint32(accountIndex)
 	if err != nil {
 		return "", err
 	}
 
-	net := network.BTCMainnet
-	if testnet {
-		net = network.BTCTestnet
+	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
+	if err != nil {
+		return "", err
 	}
 
-	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
+	k, err := keys.GetAccountKeyOnNetwork(m, keys.BIP49Purpose, net, index, false)
 	if err != nil {
 		return "", err
 	}
 
-	k, err := keys.GetBIP49AccountKey(m, index, false)
-	return hdkeychain.VersionedStringFromExtendedKeyString(k, pubP2WPKHinP2SHVer)
+	return getVersionedAccountKey(k, keys.ScriptP2WPKHInP2SH, net, false)
 }
 
 // GetP2SHAddressForIndex returns address for BTC account at given index
 // P2SH ('3' prefixed addresses) pay-to-script-hash includes P2WPKH-wrapped in P2SH segwit outputs (use BIP49 derived key)
 func GetP2SHAddressForIndex(accountKey string, addressIndex int, isChange bool, testnet bool) (string, error) {
-	validPre := p2shHumanPre[accountKey[:4]]
-	if !validPre {
-		return "", errors.New("Key does not start with a P2SH prefix")
+	return GetP2SHAddressForIndexOnNetwork(accountKey, addressIndex, isChange, testnetToNetwork(testnet))
+}
+
+// GetP2SHAddressForIndexOnNetwork returns the P2SH address for account extended key at given index on net
+func GetP2SHAddressForIndexOnNetwork(accountKey string, addressIndex int, isChange bool, net network.Network) (string, error) {
+	if err := checkAccountKeyVersion(accountKey, keys.ScriptP2WPKHInP2SH, net); err != nil {
+		return "", err
 	}
 
 	index, err := intToUint32(addressIndex)
@@ -108,20 +105,29 @@ func GetP2SHAddressForIndex(accountKey string, addressIndex int, isChange bool,
 		return "", err
 	}
 
-	pk, err := k.ECPubKey()
+	netParam, err := keys.GetChainParams(net)
 	if err != nil {
 		return "", err
 	}
 
-	keyHash := btcutil.Hash160(pk.SerializeCompressed())
-	scriptSig, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(keyHash).Script()
+	return getP2SHAddress(k, netParam)
+}
+
+// getP2SHAddress returns the P2WPKH-in-P2SH address of an address key
+func getP2SHAddress(k *hdkeychain.ExtendedKey, netParam *chaincfg.Params) (string, error) {
+	pk, err := k.ECPubKey()
 	if err != nil {
 		return "", err
 	}
 
-	netParam := &chaincfg.MainNetParams
-	if testnet {
-		netParam = &chaincfg.TestNet3Params
+	return getP2SHAddressForKeyHash(btcutil.Hash160(pk.SerializeCompressed()), netParam)
+}
+
+// getP2SHAddressForKeyHash returns the P2WPKH-in-P2SH address of a public key hash
+func getP2SHAddressForKeyHash(keyHash []byte, netParam *chaincfg.Params) (string, error) {
+	scriptSig, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(keyHash).Script()
+	if err != nil {
+		return "", err
 	}
 
 	segAddr, err := btcutil.NewAddressScriptHash(scriptSig, netParam)
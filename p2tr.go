@@ -0,0 +1,119 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+	"github.com/sanscentral/sanswallet/segwit"
+	"github.com/sanscentral/sanswallet/taproot"
+)
+
+// GetExtPrvForP2TRAccount returns extended private key for BIP86 P2TR account
+func GetExtPrvForP2TRAccount(seed []byte, accountIndex int, testnet bool) (string, error) {
+	return GetExtPrvForP2TRAccountOnNetwork(seed, accountIndex, testnetToNetwork(testnet))
+}
+
+// GetExtPrvForP2TRAccountOnNetwork returns extended private key for BIP86 P2TR account on net
+func GetExtPrvForP2TRAccountOnNetwork(seed []byte, accountIndex int, net network.Network) (string, error) {
+	index, err := intToUint32(accountIndex)
+	if err != nil {
+		return "", err
+	}
+
+	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
+	if err != nil {
+		return "", err
+	}
+
+	return keys.GetAccountKeyOnNetwork(m, keys.BIP86Purpose, net, index, true)
+}
+
+// GetExtPubForP2TRAccount returns extended public key for BIP86 P2TR account
+func GetExtPubForP2TRAccount(seed []byte, accountIndex int, testnet bool) (string, error) {
+	return GetExtPubForP2TRAccountOnNetwork(seed, accountIndex, testnetToNetwork(testnet))
+}
+
+// GetExtPubForP2TRAccountOnNetwork returns extended public key for BIP86 P2TR account on net
+func GetExtPubForP2TRAccountOnNetwork(seed []byte, accountIndex int, net network.Network) (string, error) {
+	index, err := intToUint32(accountIndex)
+	if err != nil {
+		return "", err
+	}
+
+	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
+	if err != nil {
+		return "", err
+	}
+
+	return keys.GetAccountKeyOnNetwork(m, keys.BIP86Purpose, net, index, false)
+}
+
+// GetP2TRAddressForIndex returns taproot bech32m address for BTC account extended key at given index
+// P2TR pay-to-taproot commits to the tweaked address key with an empty script tree (use BIP86 derived key, xpub/tpub prefix)
+func GetP2TRAddressForIndex(accountKey string, addressIndex int, isChange bool, testnet bool) (string, error) {
+	return GetP2TRAddressForIndexOnNetwork(accountKey, addressIndex, isChange, testnetToNetwork(testnet))
+}
+
+// GetP2TRAddressForIndexOnNetwork returns the P2TR address for account extended key at given index on net
+func GetP2TRAddressForIndexOnNetwork(accountKey string, addressIndex int, isChange bool, net network.Network) (string, error) {
+	if err := checkAccountKeyVersion(accountKey, keys.ScriptP2TR, net); err != nil {
+		return "", err
+	}
+
+	index, err := intToUint32(addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := keys.GetAccountAddressKey(accountKey, addt, index)
+	if err != nil {
+		return "", err
+	}
+
+	netParam, err := keys.GetChainParams(net)
+	if err != nil {
+		return "", err
+	}
+
+	return getP2TRAddress(k, netParam)
+}
+
+// getP2TRAddress returns the taproot bech32m address of an address key (BIP341 key path only output)
+func getP2TRAddress(k *hdkeychain.ExtendedKey, netParam *chaincfg.Params) (string, error) {
+	pk, err := k.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+
+	q, err := taproot.TweakPublicKey(pk, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return segwit.EncodeAddress(netParam.Bech32HRPSegwit, segwit.TaprootWitnessVersion, taproot.XOnlyPubKey(q))
+}
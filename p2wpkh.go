@@ -19,8 +19,6 @@
 package sanswallet
 
 import (
-	"errors"
-
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
@@ -29,71 +27,66 @@ import (
 	"github.com/sanscentral/sanswallet/network"
 )
 
-var (
-	// Version bytes of master public/private keys indicate what type of output script should be used.
-	privP2WPKHVer = [4]byte{0x04, 0xb2, 0x43, 0x0c}
-	pubP2WPKHVer  = [4]byte{0x04, 0xb2, 0x47, 0x46}
-
-	// possible prefixes for a P2WPKH key (Used for key validation)
-	p2wpkhHumanPre = map[string]bool{"zpub": true, "zprv": true}
-)
-
 // GetExtPrvForP2WPKHAccount returns extended private key for BIP84 P2WPKH account
 func GetExtPrvForP2WPKHAccount(seed []byte, accountIndex int, testnet bool) (string, error) {
+	return GetExtPrvForP2WPKHAccountOnNetwork(seed, accountIndex, testnetToNetwork(testnet))
+}
+
+// GetExtPrvForP2WPKHAccountOnNetwork returns extended private key for BIP84 P2WPKH account on net
+func GetExtPrvForP2WPKHAccountOnNetwork(seed []byte, accountIndex int, net network.Network) (string, error) {
 	index, err := intToUint32(accountIndex)
 	if err != nil {
 		return "", err
 	}
 
-	net := network.BTCMainnet
-	if testnet {
-		net = network.BTCTestnet
-	}
-
 	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
 	if err != nil {
 		return "", err
 	}
 
-	k, err := keys.GetBIP84AccountKey(m, index, true)
+	k, err := keys.GetAccountKeyOnNetwork(m, keys.BIP84Purpose, net, index, true)
 	if err != nil {
 		return "", err
 	}
 
-	return hdkeychain.VersionedStringFromExtendedKeyString(k, privP2WPKHVer)
+	return getVersionedAccountKey(k, keys.ScriptP2WPKH, net, true)
 }
 
 // GetExtPubForP2WPKHAccount returns extended public key for BIP84 P2WPKH account
 func GetExtPubForP2WPKHAccount(seed []byte, accountIndex int, testnet bool) (string, error) {
+	return GetExtPubForP2WPKHAccountOnNetwork(seed, accountIndex, testnetToNetwork(testnet))
+}
+
+// GetExtPubForP2WPKHAccountOnNetwork returns extended public key for BIP84 P2WPKH account on net
+func GetExtPubForP2WPKHAccountOnNetwork(seed []byte, accountIndex int, net network.Network) (string, error) {
 	index, err := intToUint32(accountIndex)
 	if err != nil {
 		return "", err
 	}
 
-	net := network.BTCMainnet
-	if testnet {
-		net = network.BTCTestnet
-	}
-
 	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
 	if err != nil {
 		return "", err
 	}
 
-	k, err := keys.GetBIP84AccountKey(m, index, false)
+	k, err := keys.GetAccountKeyOnNetwork(m, keys.BIP84Purpose, net, index, false)
 	if err != nil {
 		return "", err
 	}
 
-	return hdkeychain.VersionedStringFromExtendedKeyString(k, pubP2WPKHVer)
+	return getVersionedAccountKey(k, keys.ScriptP2WPKH, net, false)
 }
 
 // GetP2WPKHAddressForIndex returns segwit bech32 address for BTC account extended key at given index
 // P2WPKH pay-to-witness-public-key-hash is the shorter segwit form of P2PKH (newest address format at time of writing, use BIP84 derived key)
 func GetP2WPKHAddressForIndex(accountKey string, addressIndex int, isChange bool, testnet bool) (string, error) {
-	validPre := p2wpkhHumanPre[accountKey[:4]]
-	if !validPre {
-		return "", errors.New("Key does not start with a P2WPKH prefix")
+	return GetP2WPKHAddressForIndexOnNetwork(accountKey, addressIndex, isChange, testnetToNetwork(testnet))
+}
+
+// GetP2WPKHAddressForIndexOnNetwork returns the P2WPKH address for account extended key at given index on net
+func GetP2WPKHAddressForIndexOnNetwork(accountKey string, addressIndex int, isChange bool, net network.Network) (string, error) {
+	if err := checkAccountKeyVersion(accountKey, keys.ScriptP2WPKH, net); err != nil {
+		return "", err
 	}
 
 	index, err := intToUint32(addressIndex)
@@ -111,18 +104,26 @@ func GetP2WPKHAddressForIndex(accountKey string, addressIndex int, isChange bool
 		return "", err
 	}
 
-	pk, err := k.ECPubKey()
+	netParam, err := keys.GetChainParams(net)
 	if err != nil {
 		return "", err
 	}
 
-	keyHash := btcutil.Hash160(pk.SerializeCompressed())
+	return getP2WPKHAddress(k, netParam)
+}
 
-	netParam := &chaincfg.MainNetParams
-	if testnet {
-		netParam = &chaincfg.TestNet3Params
+// getP2WPKHAddress returns the segwit bech32 address of an address key
+func getP2WPKHAddress(k *hdkeychain.ExtendedKey, netParam *chaincfg.Params) (string, error) {
+	pk, err := k.ECPubKey()
+	if err != nil {
+		return "", err
 	}
 
+	return getP2WPKHAddressForKeyHash(btcutil.Hash160(pk.SerializeCompressed()), netParam)
+}
+
+// getP2WPKHAddressForKeyHash returns the segwit bech32 address of a public key hash
+func getP2WPKHAddressForKeyHash(keyHash []byte, netParam *chaincfg.Params) (string, error) {
 	segAddr, err := btcutil.NewAddressWitnessPubKeyHash(keyHash, netParam)
 	if err != nil {
 		return "", err
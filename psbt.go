@@ -0,0 +1,277 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+	"github.com/sanscentral/sanswallet/psbt"
+	"github.com/sanscentral/sanswallet/taproot"
+)
+
+var (
+	// ErrMissingPrevTx is returned when creating a PSBT spending a P2PKH UTXO without its previous transaction
+	ErrMissingPrevTx = errors.New("UTXO previous transaction is required to spend P2PKH outputs in a PSBT")
+
+	// ErrNoPSBTSignatures is returned when a PSBT has no inputs the signing key can sign
+	ErrNoPSBTSignatures = errors.New("PSBT has no inputs the key can sign")
+)
+
+// UnsignedPSBT is a PSBT spending account UTXOs, with the fee and change it was funded with
+type UnsignedPSBT struct {
+	// PSBT is the base64 encoded BIP174 PSBT
+	PSBT string
+
+	// Fee is the amount in satoshis left to miners
+	Fee int64
+
+	// ChangeIndex is the change address index paid ChangeAmount, -1 when change was too small to output
+	ChangeIndex  int
+	ChangeAmount int64
+}
+
+// CreatePSBT returns a version 0 PSBT spending all utxos of the account to recipients, funded as BuildTransaction does.
+// Inputs and the change output carry BIP32 derivations with the account key origin (master fingerprint and path)
+// so the seed, the account extended private key or a hardware wallet can sign. Watch-only accounts can create PSBTs
+func (a *Account) CreatePSBT(utxos []*UTXO, recipients []*Recipient, feeRate int64, changeIndex int) (*UnsignedPSBT, error) {
+	f, err := a.fundTransaction(utxos, recipients, feeRate, changeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := psbt.New(f.tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, in := range f.inputs {
+		pIn := p.Inputs[i]
+		pIn.NonWitnessUTXO = in.prevTx
+		if a.scriptType == keys.ScriptP2PKH {
+			if in.prevTx == nil {
+				return nil, ErrMissingPrevTx
+			}
+		} else {
+			pIn.WitnessUTXO = in.prevOut
+		}
+
+		d, redeemScript, err := a.keyDerivation(in.key, in.addt, in.addressIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		if a.scriptType == keys.ScriptP2TR {
+			pIn.TaprootBip32Derivations = []*psbt.Derivation{d}
+			pIn.TaprootInternalKey = d.PubKey
+		} else {
+			pIn.Bip32Derivations = []*psbt.Derivation{d}
+			pIn.RedeemScript = redeemScript
+		}
+	}
+
+	// The change output is the last one, its derivation lets signers recognise it as the account's own
+	if f.changeIndex >= 0 {
+		k, err := a.addressKey(keys.ChangeAddress, f.changeIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		d, redeemScript, err := a.keyDerivation(k, keys.ChangeAddress, uint32(f.changeIndex))
+		if err != nil {
+			return nil, err
+		}
+
+		out := p.Outputs[len(p.Outputs)-1]
+		if a.scriptType == keys.ScriptP2TR {
+			out.TaprootBip32Derivations = []*psbt.Derivation{d}
+			out.TaprootInternalKey = d.PubKey
+		} else {
+			out.Bip32Derivations = []*psbt.Derivation{d}
+			out.RedeemScript = redeemScript
+		}
+	}
+
+	encoded, err := p.B64Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return &UnsignedPSBT{PSBT: encoded, Fee: f.fee, ChangeIndex: f.changeIndex, ChangeAmount: f.changeAmount}, nil
+}
+
+// keyDerivation returns the PSBT derivation of an address key and the redeem script of P2SH-P2WPKH accounts.
+// Without a known key origin the account key is the root of the derivation, its own fingerprint
+// and the change and address index being the path
+func (a *Account) keyDerivation(k *hdkeychain.ExtendedKey, addt keys.AddressType, addressIndex uint32) (*psbt.Derivation, []byte, error) {
+	pub, err := k.ECPubKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var redeemScript []byte
+	d := &psbt.Derivation{PubKey: pub.SerializeCompressed()}
+	switch a.scriptType {
+	case keys.ScriptP2TR:
+		d.PubKey = taproot.XOnlyPubKey(pub)
+	case keys.ScriptP2WPKHInP2SH:
+		redeemScript = p2wpkhScript(pub)
+	}
+
+	if a.origin != nil {
+		d.Fingerprint = a.origin.Fingerprint
+		d.Path = a.origin.Path.Child(uint32(addt)).Child(addressIndex)
+		return d, redeemScript, nil
+	}
+
+	if d.Fingerprint, err = keys.GetMasterFingerprint(a.key); err != nil {
+		return nil, nil, err
+	}
+	d.Path = keys.DerivationPath{uint32(addt), addressIndex}
+	return d, redeemScript, nil
+}
+
+// ConvertPSBT converts a base64 PSBT to version 0 (BIP174) or version 2 (BIP370)
+func ConvertPSBT(psbtBase64 string, version int) (string, error) {
+	v, err := intToUint32(version)
+	if err != nil {
+		return "", err
+	}
+
+	p, err := psbt.ParseBase64(psbtBase64)
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.SetVersion(v); err != nil {
+		return "", err
+	}
+	return p.B64Encode()
+}
+
+// SignPSBTWithSeed signs the inputs of a base64 PSBT (version 0 or 2) with derivations from the master key of seed
+// and returns the updated PSBT
+func SignPSBTWithSeed(psbtBase64 string, seed []byte) (string, error) {
+	p, err := psbt.ParseBase64(psbtBase64)
+	if err != nil {
+		return "", err
+	}
+
+	// The master key version does not change its fingerprint or derived keys, any network will do
+	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, network.BTCMainnet)
+	if err != nil {
+		return "", err
+	}
+
+	n, err := p.SignWithMaster(m)
+	if err != nil {
+		return "", err
+	}
+
+	if n == 0 {
+		return "", ErrNoPSBTSignatures
+	}
+	return p.B64Encode()
+}
+
+// SignPSBT signs the inputs of a base64 PSBT spending addresses of the account and returns the updated PSBT.
+// The account must hold the extended private key
+func (a *Account) SignPSBT(psbtBase64 string) (string, error) {
+	if !a.key.IsPrivate() {
+		return "", hdkeychain.ErrNotPrivExtKey
+	}
+
+	if a.cashAddrPrefix != "" {
+		return "", ErrForkIDSigning
+	}
+
+	p, err := psbt.ParseBase64(psbtBase64)
+	if err != nil {
+		return "", err
+	}
+
+	n, err := p.SignWithAccountKey(a.key)
+	if err != nil {
+		return "", err
+	}
+
+	if n == 0 {
+		return "", ErrNoPSBTSignatures
+	}
+	return p.B64Encode()
+}
+
+// CombinePSBT merges base64 PSBTs of the same transaction signed by different signers
+func CombinePSBT(psbts []string) (string, error) {
+	packets := make([]*psbt.Packet, len(psbts))
+	for i, s := range psbts {
+		p, err := psbt.ParseBase64(s)
+		if err != nil {
+			return "", err
+		}
+		packets[i] = p
+	}
+
+	p, err := psbt.Combine(packets...)
+	if err != nil {
+		return "", err
+	}
+	return p.B64Encode()
+}
+
+// FinalizePSBT finalizes every input of a fully signed base64 PSBT and returns the finalized PSBT
+func FinalizePSBT(psbtBase64 string) (string, error) {
+	p, err := psbt.ParseBase64(psbtBase64)
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.Finalize(); err != nil {
+		return "", err
+	}
+	return p.B64Encode()
+}
+
+// ExtractPSBTTransaction finalizes a fully signed base64 PSBT and returns the hex encoded transaction to broadcast
+func ExtractPSBTTransaction(psbtBase64 string) (string, error) {
+	p, err := psbt.ParseBase64(psbtBase64)
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.Finalize(); err != nil {
+		return "", err
+	}
+
+	tx, err := p.Extract()
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := tx.Serialize(&b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b.Bytes()), nil
+}
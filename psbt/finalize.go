@@ -0,0 +1,311 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package psbt
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+var (
+	// ErrIncomplete is returned by Finalize when some input does not have the signatures its script needs
+	ErrIncomplete = errors.New("PSBT input is missing signatures")
+
+	// ErrNotFinalized is returned when extracting the transaction of a PSBT with inputs that are not finalized
+	ErrNotFinalized = errors.New("PSBT has inputs that are not finalized")
+
+	// ErrDifferentTransactions is returned when combining PSBTs of different transactions
+	ErrDifferentTransactions = errors.New("PSBTs are for different transactions")
+
+	// ErrVersionMismatch is returned when combining PSBTs of different versions
+	ErrVersionMismatch = errors.New("PSBTs have different versions")
+
+	// ErrNoPackets is returned when combining no PSBTs
+	ErrNoPackets = errors.New("No PSBTs to combine")
+)
+
+// Finalize sets the final scriptSig and witness of every input that has the signatures its script needs
+// and removes the data only signers use. It returns ErrIncomplete when some input could not be finalized
+func (p *Packet) Finalize() error {
+	complete := true
+	for i, in := range p.Inputs {
+		if in.IsFinalized() {
+			continue
+		}
+
+		ok, err := p.finalizeInput(i)
+		if err != nil {
+			return err
+		}
+		complete = complete && ok
+	}
+
+	if !complete {
+		return ErrIncomplete
+	}
+	return nil
+}
+
+// finalizeInput finalizes input i, returning false when it lacks signatures or spends an unsupported script
+func (p *Packet) finalizeInput(i int) (bool, error) {
+	in := p.Inputs[i]
+	prevOut, err := p.prevOut(i)
+	if err == ErrMissingUTXO {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if isP2TR(prevOut.PkScript) {
+		if in.TaprootKeySig == nil {
+			return false, nil
+		}
+
+		in.FinalScriptWitness = wire.TxWitness{in.TaprootKeySig}
+		in.clearSigningData()
+		return true, nil
+	}
+
+	script, witness, err := in.signingScript(prevOut.PkScript)
+	if err != nil || script == nil {
+		return false, err
+	}
+
+	var stack [][]byte
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(script) || txscript.GetScriptClass(script) == txscript.PubKeyHashTy:
+		stack = in.keyHashStack(script)
+	case txscript.GetScriptClass(script) == txscript.MultiSigTy:
+		stack, err = in.multiSigStack(script)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if stack == nil {
+		return false, nil
+	}
+
+	// Script hash spends reveal the script after the items satisfying it
+	nested := txscript.IsPayToScriptHash(prevOut.PkScript)
+	if witness {
+		if !txscript.IsPayToWitnessPubKeyHash(script) {
+			stack = append(stack, script)
+		}
+		in.FinalScriptWitness = stack
+
+		if nested {
+			if in.FinalScriptSig, err = pushScript([][]byte{in.RedeemScript}); err != nil {
+				return false, err
+			}
+		}
+	} else {
+		if nested {
+			stack = append(stack, in.RedeemScript)
+		}
+
+		if in.FinalScriptSig, err = pushScript(stack); err != nil {
+			return false, err
+		}
+	}
+
+	in.clearSigningData()
+	return true, nil
+}
+
+// keyHashStack returns the signature and public key spending a P2PKH or P2WPKH script, nil without a signature
+func (in *Input) keyHashStack(script []byte) [][]byte {
+	for _, s := range in.PartialSigs {
+		if bytes.Contains(script, btcutil.Hash160(s.PubKey)) {
+			return [][]byte{s.Signature, s.PubKey}
+		}
+	}
+	return nil
+}
+
+// multiSigStack returns the signatures spending a multisig script in the order of its keys,
+// after the empty item CHECKMULTISIG pops. It is nil without enough signatures
+func (in *Input) multiSigStack(script []byte) ([][]byte, error) {
+	_, required, err := txscript.CalcMultiSigStats(script)
+	if err != nil {
+		return nil, err
+	}
+
+	pushes, err := txscript.PushedData(script)
+	if err != nil {
+		return nil, err
+	}
+
+	stack := [][]byte{{}}
+	for _, pubKey := range pushes {
+		if s := in.partialSig(pubKey); s != nil && len(stack) <= required {
+			stack = append(stack, s.Signature)
+		}
+	}
+
+	if len(stack) <= required {
+		return nil, nil
+	}
+	return stack, nil
+}
+
+// pushScript returns a script pushing items
+func pushScript(items [][]byte) ([]byte, error) {
+	b := txscript.NewScriptBuilder()
+	for _, item := range items {
+		b.AddData(item)
+	}
+	return b.Script()
+}
+
+// clearSigningData removes the fields of a finalized input only signers and finalizers use
+func (in *Input) clearSigningData() {
+	in.PartialSigs = nil
+	in.SigHashType = nil
+	in.RedeemScript = nil
+	in.WitnessScript = nil
+	in.Bip32Derivations = nil
+	in.TaprootKeySig = nil
+	in.TaprootBip32Derivations = nil
+	in.TaprootInternalKey = nil
+}
+
+// Extract returns the signed transaction of a PSBT whose inputs are all finalized
+func (p *Packet) Extract() (*wire.MsgTx, error) {
+	if !p.IsComplete() {
+		return nil, ErrNotFinalized
+	}
+
+	if err := p.syncLockTime(); err != nil {
+		return nil, err
+	}
+
+	tx := p.UnsignedTx.Copy()
+	for i, in := range p.Inputs {
+		tx.TxIn[i].SignatureScript = in.FinalScriptSig
+		tx.TxIn[i].Witness = in.FinalScriptWitness
+	}
+	return tx, nil
+}
+
+// Combine merges PSBTs of the same transaction, e.g. signed by different cosigners.
+// Entries of every map are merged by key, the first PSBT holding a key giving its value
+func Combine(packets ...*Packet) (*Packet, error) {
+	if len(packets) == 0 {
+		return nil, ErrNoPackets
+	}
+
+	var merged [][]*keyValue
+	for i, p := range packets {
+		if p.UnsignedTx == nil {
+			return nil, ErrMissingUnsignedTx
+		}
+
+		if p.Version != packets[0].Version {
+			return nil, ErrVersionMismatch
+		}
+
+		if p.UnsignedTx.TxHash() != packets[0].UnsignedTx.TxHash() {
+			return nil, ErrDifferentTransactions
+		}
+
+		data, err := p.Serialize()
+		if err != nil {
+			return nil, err
+		}
+
+		maps, err := readMaps(data)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			merged = maps
+			continue
+		}
+
+		for j := range merged {
+			merged[j] = mergeMap(merged[j], maps[j])
+		}
+	}
+
+	var b bytes.Buffer
+	b.Write(magic)
+	w := &mapWriter{b: &b}
+	for _, kvs := range merged {
+		for _, kv := range kvs {
+			w.write(kv.key, kv.value)
+		}
+		w.end()
+	}
+
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	combined, err := Parse(b.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	// Inputs and outputs stay modifiable only if every signer allows it
+	if combined.Version == 2 {
+		combined.TxModifiable = ModifiableInputs | ModifiableOutputs
+		for _, p := range packets {
+			combined.TxModifiable &= p.TxModifiable | HasSigHashSingle
+			combined.TxModifiable |= p.TxModifiable & HasSigHashSingle
+		}
+	}
+	return combined, nil
+}
+
+// readMaps splits a serialized PSBT into its global, input and output maps
+func readMaps(data []byte) ([][]*keyValue, error) {
+	r := bytes.NewReader(data[len(magic):])
+	var maps [][]*keyValue
+	for r.Len() > 0 {
+		kvs, err := readMap(r)
+		if err != nil {
+			return nil, err
+		}
+		maps = append(maps, kvs)
+	}
+	return maps, nil
+}
+
+// mergeMap returns the entries of a followed by those of b with keys not in a
+func mergeMap(a []*keyValue, b []*keyValue) []*keyValue {
+	keys := make(map[string]bool, len(a))
+	for _, kv := range a {
+		keys[string(kv.key)] = true
+	}
+
+	for _, kv := range b {
+		if !keys[string(kv.key)] {
+			a = append(a, kv)
+		}
+	}
+	return a
+}
@@ -0,0 +1,147 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package psbt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+func TestCombineMultisig(t *testing.T) {
+	cosigners := make([]*hdkeychain.ExtendedKey, 2)
+	derivations := make([]*Derivation, 2)
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_2)
+	for i := range cosigners {
+		m, err := hdkeychain.NewMaster(bytes.Repeat([]byte{byte(i + 1)}, 32), &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cosigners[i] = m
+		derivations[i], _ = testDerivation(t, m, 0)
+		builder.AddData(derivations[i].PubKey)
+	}
+
+	witnessScript, err := builder.AddOp(txscript.OP_2).AddOp(txscript.OP_CHECKMULTISIG).Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first input is 2-of-2 P2WSH, the second the same script nested in P2SH
+	hash := sha256.Sum256(witnessScript)
+	p2wsh := append([]byte{txscript.OP_0, txscript.OP_DATA_32}, hash[:]...)
+	p2sh, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(p2wsh)).AddOp(txscript.OP_EQUAL).Script()
+
+	p, funding := testFundedPacket(t, [][]byte{p2wsh, p2sh})
+	for i, in := range p.Inputs {
+		in.WitnessUTXO = funding.TxOut[i]
+		in.WitnessScript = witnessScript
+		in.Bip32Derivations = derivations
+	}
+	p.Inputs[1].RedeemScript = p2wsh
+
+	unsigned, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed := make([]*Packet, len(cosigners))
+	for i, m := range cosigners {
+		if signed[i], err = Parse(unsigned); err != nil {
+			t.Fatal(err)
+		}
+
+		if n, err := signed[i].SignWithMaster(m); err != nil || n != 2 {
+			t.Errorf("cosigner %d signed %d inputs (%v)", i, n, err)
+		}
+	}
+
+	if err := signed[0].Finalize(); err != ErrIncomplete {
+		t.Error("PSBT with one of two signatures did not fail to finalize where expected")
+	}
+
+	combined, err := Combine(signed...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(combined.Inputs[0].PartialSigs) != 2 || len(combined.Inputs[1].PartialSigs) != 2 {
+		t.Fatal("combined PSBT does not hold the signatures of both cosigners")
+	}
+
+	if err := combined.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := combined.Inputs[0].FinalScriptWitness; len(w) != 4 || len(w[0]) != 0 || !bytes.Equal(w[3], witnessScript) {
+		t.Errorf("multisig witness is not expected value, got %x", w)
+	}
+
+	tx, err := combined.Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyPacketInputs(t, tx, funding)
+
+	// Combining is order independent and idempotent
+	reversed, err := Combine(signed[1], signed[0], signed[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reversed.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := combined.Serialize()
+	b, _ := reversed.Serialize()
+	if !bytes.Equal(a, b) {
+		t.Error("PSBTs combined in another order finalized differently")
+	}
+}
+
+func TestCombineInvalid(t *testing.T) {
+	if _, err := Combine(); err != ErrNoPackets {
+		t.Error("combining no PSBTs did not fail where expected")
+	}
+
+	a, err := New(testUnsignedTx(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := New(testUnsignedTx(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Combine(a, b); err != ErrDifferentTransactions {
+		t.Error("PSBTs of different transactions did not fail where expected")
+	}
+
+	if _, err := Combine(a, &Packet{}); err != ErrMissingUnsignedTx {
+		t.Error("PSBT without transaction did not fail where expected")
+	}
+}
@@ -0,0 +1,128 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package psbt
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// keyValue is a raw PSBT map entry, the key holding its type byte followed by the key data
+type keyValue struct {
+	key   []byte
+	value []byte
+}
+
+// keyType returns the type of the entry
+func (kv *keyValue) keyType() byte {
+	return kv.key[0]
+}
+
+// keyData returns the key after the type byte
+func (kv *keyValue) keyData() []byte {
+	return kv.key[1:]
+}
+
+// checkKeyLength returns ErrInvalidKey when the key data is not n bytes
+func (kv *keyValue) checkKeyLength(n int) error {
+	if len(kv.keyData()) != n {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// checkPubKey returns ErrInvalidKey when the key data is not a compressed or uncompressed public key
+func (kv *keyValue) checkPubKey() error {
+	if n := len(kv.keyData()); n != 33 && n != 65 {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// readMap reads the entries of a map up to its 0x00 separator
+func readMap(r io.Reader) ([]*keyValue, error) {
+	var kvs []*keyValue
+	seen := make(map[string]bool)
+	for {
+		key, err := wire.ReadVarBytes(r, 0, maxValueSize, "key")
+		if err != nil {
+			return nil, ErrInvalidKey
+		}
+
+		if len(key) == 0 {
+			return kvs, nil
+		}
+
+		if seen[string(key)] {
+			return nil, ErrDuplicateKey
+		}
+		seen[string(key)] = true
+
+		value, err := wire.ReadVarBytes(r, 0, maxValueSize, "value")
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+		kvs = append(kvs, &keyValue{key: key, value: value})
+	}
+}
+
+// mapWriter writes map entries, keeping the first error
+type mapWriter struct {
+	b   *bytes.Buffer
+	err error
+}
+
+// write writes one entry
+func (w *mapWriter) write(key []byte, value []byte) {
+	if err := wire.WriteVarBytes(w.b, 0, key); err != nil && w.err == nil {
+		w.err = err
+	}
+
+	if err := wire.WriteVarBytes(w.b, 0, value); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// writeIfSet writes an entry without key data when value is not empty
+func (w *mapWriter) writeIfSet(keyType byte, value []byte) {
+	if len(value) != 0 {
+		w.write([]byte{keyType}, value)
+	}
+}
+
+// derivations writes BIP32 derivation entries keyed by their public keys
+func (w *mapWriter) derivations(keyType byte, derivations []*Derivation, taproot bool) {
+	for _, d := range derivations {
+		w.write(append([]byte{keyType}, d.PubKey...), serializeDerivation(d, taproot))
+	}
+}
+
+// unknowns writes entries this package does not interpret
+func (w *mapWriter) unknowns(unknowns []*Unknown) {
+	for _, u := range unknowns {
+		w.write(u.Key, u.Value)
+	}
+}
+
+// end writes the map separator
+func (w *mapWriter) end() {
+	w.b.WriteByte(0x00)
+}
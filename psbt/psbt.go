@@ -0,0 +1,750 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package psbt creates, signs, combines and finalizes partially signed bitcoin transactions, version 0 (BIP174) and version 2 (BIP370)
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/sanscentral/sanswallet/keys"
+)
+
+// magic is the prefix of every serialized PSBT
+var magic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// Global key types
+const (
+	globalUnsignedTx       = 0x00
+	globalTxVersion        = 0x02
+	globalFallbackLocktime = 0x03
+	globalInputCount       = 0x04
+	globalOutputCount      = 0x05
+	globalTxModifiable     = 0x06
+	globalVersion          = 0xfb
+)
+
+// Input key types
+const (
+	inputNonWitnessUTXO         = 0x00
+	inputWitnessUTXO            = 0x01
+	inputPartialSig             = 0x02
+	inputSigHashType            = 0x03
+	inputRedeemScript           = 0x04
+	inputWitnessScript          = 0x05
+	inputBip32Derivation        = 0x06
+	inputFinalScriptSig         = 0x07
+	inputFinalScriptWitness     = 0x08
+	inputPreviousTxID           = 0x0e
+	inputOutputIndex            = 0x0f
+	inputSequence               = 0x10
+	inputRequiredTimeLocktime   = 0x11
+	inputRequiredHeightLocktime = 0x12
+	inputTaprootKeySig          = 0x13
+	inputTaprootBip32Derivation = 0x16
+	inputTaprootInternalKey     = 0x17
+)
+
+// Output key types
+const (
+	outputRedeemScript           = 0x00
+	outputWitnessScript          = 0x01
+	outputBip32Derivation        = 0x02
+	outputAmount                 = 0x03
+	outputScript                 = 0x04
+	outputTaprootInternalKey     = 0x05
+	outputTaprootBip32Derivation = 0x07
+)
+
+// maxValueSize bounds the size of a single key or value when parsing
+const maxValueSize = 4000000
+
+var (
+	// ErrInvalidMagic is returned when data does not start with the PSBT magic bytes
+	ErrInvalidMagic = errors.New("PSBT magic bytes are invalid")
+
+	// ErrDuplicateKey is returned when a PSBT map holds the same key twice
+	ErrDuplicateKey = errors.New("PSBT map has a duplicate key")
+
+	// ErrInvalidKey is returned for keys whose key data does not suit their type
+	ErrInvalidKey = errors.New("PSBT key is invalid")
+
+	// ErrInvalidValue is returned for values that cannot be decoded for their type
+	ErrInvalidValue = errors.New("PSBT value is invalid")
+
+	// ErrMissingUnsignedTx is returned when a version 0 PSBT has no unsigned transaction
+	ErrMissingUnsignedTx = errors.New("PSBT has no unsigned transaction")
+
+	// ErrSignedUnsignedTx is returned when the unsigned transaction has scriptSigs or witnesses
+	ErrSignedUnsignedTx = errors.New("PSBT unsigned transaction must not have scriptSigs or witnesses")
+
+	// ErrMapCount is returned when the number of input or output maps does not match the transaction
+	ErrMapCount = errors.New("PSBT input or output count does not match the transaction")
+
+	// ErrUnsupportedVersion is returned for PSBT versions this package cannot read
+	ErrUnsupportedVersion = errors.New("PSBT version is not supported")
+)
+
+// Derivation is the public key of a BIP32 derivation and its key origin
+type Derivation struct {
+	// PubKey is the 33 byte compressed public key, or the 32 byte x-only key of taproot derivations
+	PubKey      []byte
+	Fingerprint uint32
+	Path        keys.DerivationPath
+
+	// LeafHashes are the tapleaf hashes of scripts the key is used in, empty for key path spends
+	LeafHashes [][]byte
+}
+
+// PartialSig is a public key and its signature (with hash type byte) of an input
+type PartialSig struct {
+	PubKey    []byte
+	Signature []byte
+}
+
+// Unknown is a key-value pair of a type this package does not interpret, kept so it survives a round trip
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+// Input holds the per-input data of a PSBT
+type Input struct {
+	NonWitnessUTXO *wire.MsgTx
+	WitnessUTXO    *wire.TxOut
+	PartialSigs    []*PartialSig
+
+	// SigHashType is nil when the signer chooses (SIGHASH_ALL, or SIGHASH_DEFAULT for taproot)
+	SigHashType *txscript.SigHashType
+
+	RedeemScript     []byte
+	WitnessScript    []byte
+	Bip32Derivations []*Derivation
+
+	FinalScriptSig     []byte
+	FinalScriptWitness wire.TxWitness
+
+	TaprootKeySig           []byte
+	TaprootBip32Derivations []*Derivation
+	TaprootInternalKey      []byte
+
+	// RequiredTimeLocktime and RequiredHeightLocktime are the BIP370 locktime the input needs, nil when it has none
+	RequiredTimeLocktime   *uint32
+	RequiredHeightLocktime *uint32
+
+	Unknowns []*Unknown
+}
+
+// Output holds the per-output data of a PSBT
+type Output struct {
+	RedeemScript     []byte
+	WitnessScript    []byte
+	Bip32Derivations []*Derivation
+
+	TaprootInternalKey      []byte
+	TaprootBip32Derivations []*Derivation
+
+	Unknowns []*Unknown
+}
+
+// Packet is a partially signed bitcoin transaction
+type Packet struct {
+	// UnsignedTx is the transaction being signed. Version 2 PSBTs carry it as per-input and per-output fields
+	// (previous txid and vout, sequence, amount and script), its locktime following from the input requirements
+	UnsignedTx *wire.MsgTx
+	Inputs     []*Input
+	Outputs    []*Output
+
+	// Version is the PSBT version, 0 for BIP174 or 2 for BIP370
+	Version uint32
+
+	// FallbackLocktime is the locktime of version 2 PSBTs whose inputs require none, nil when unset (0)
+	FallbackLocktime *uint32
+
+	// TxModifiable holds the version 2 Modifiable flags (ModifiableInputs, ModifiableOutputs and HasSigHashSingle)
+	TxModifiable uint8
+
+	Unknowns []*Unknown
+}
+
+// New returns a version 0 PSBT for a transaction without scriptSigs or witnesses, with empty input and output maps
+func New(tx *wire.MsgTx) (*Packet, error) {
+	for _, in := range tx.TxIn {
+		if len(in.SignatureScript) != 0 || len(in.Witness) != 0 {
+			return nil, ErrSignedUnsignedTx
+		}
+	}
+
+	p := &Packet{UnsignedTx: tx.Copy()}
+	for range tx.TxIn {
+		p.Inputs = append(p.Inputs, &Input{})
+	}
+	for range tx.TxOut {
+		p.Outputs = append(p.Outputs, &Output{})
+	}
+	return p, nil
+}
+
+// IsFinalized returns true if the input has a final scriptSig or witness
+func (in *Input) IsFinalized() bool {
+	return in.FinalScriptSig != nil || in.FinalScriptWitness != nil
+}
+
+// IsComplete returns true if every input is finalized and the transaction can be extracted
+func (p *Packet) IsComplete() bool {
+	for _, in := range p.Inputs {
+		if !in.IsFinalized() {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse decodes a binary version 0 (BIP174) or version 2 (BIP370) PSBT
+func Parse(data []byte) (*Packet, error) {
+	r := bytes.NewReader(data)
+	m := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, m); err != nil || !bytes.Equal(m, magic) {
+		return nil, ErrInvalidMagic
+	}
+
+	p := &Packet{}
+	global, err := readMap(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := &txFields{}
+	for _, kv := range global {
+		switch kv.keyType() {
+		case globalUnsignedTx:
+			if len(kv.key) != 1 {
+				return nil, ErrInvalidKey
+			}
+
+			tx := wire.NewMsgTx(0)
+			if err := tx.DeserializeNoWitness(bytes.NewReader(kv.value)); err != nil {
+				return nil, ErrInvalidValue
+			}
+			p.UnsignedTx = tx
+		case globalVersion:
+			if len(kv.key) != 1 || len(kv.value) != 4 {
+				return nil, ErrInvalidValue
+			}
+			p.Version = binary.LittleEndian.Uint32(kv.value)
+		case globalTxVersion, globalFallbackLocktime, globalInputCount, globalOutputCount, globalTxModifiable:
+			// Version 2 fields have no key data, keys of these types with data are unknown to both versions
+			if len(kv.key) != 1 {
+				p.Unknowns = append(p.Unknowns, &Unknown{Key: kv.key, Value: kv.value})
+			} else if err := p.parseTxField(kv, fields); err != nil {
+				return nil, err
+			}
+		default:
+			p.Unknowns = append(p.Unknowns, &Unknown{Key: kv.key, Value: kv.value})
+		}
+	}
+
+	var inputs, outputs int
+	switch p.Version {
+	case 0:
+		if fields.present {
+			return nil, ErrVersionField
+		}
+
+		if p.UnsignedTx == nil {
+			return nil, ErrMissingUnsignedTx
+		}
+
+		for _, in := range p.UnsignedTx.TxIn {
+			if len(in.SignatureScript) != 0 || len(in.Witness) != 0 {
+				return nil, ErrSignedUnsignedTx
+			}
+		}
+		inputs, outputs = len(p.UnsignedTx.TxIn), len(p.UnsignedTx.TxOut)
+	case 2:
+		if p.UnsignedTx != nil {
+			return nil, ErrVersionField
+		}
+
+		if fields.txVersion == nil || fields.inputCount == nil || fields.outputCount == nil {
+			return nil, ErrMissingField
+		}
+
+		// Every map takes at least its separator byte
+		if *fields.inputCount+*fields.outputCount > uint64(r.Len()) {
+			return nil, ErrMapCount
+		}
+
+		p.UnsignedTx = wire.NewMsgTx(*fields.txVersion)
+		inputs, outputs = int(*fields.inputCount), int(*fields.outputCount)
+	default:
+		return nil, ErrUnsupportedVersion
+	}
+
+	for i := 0; i < inputs; i++ {
+		kvs, err := readMap(r)
+		if err != nil {
+			return nil, err
+		}
+
+		in, txIn, err := parseInput(kvs, p.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Inputs = append(p.Inputs, in)
+		if txIn != nil {
+			p.UnsignedTx.AddTxIn(txIn)
+		}
+	}
+
+	for i := 0; i < outputs; i++ {
+		kvs, err := readMap(r)
+		if err != nil {
+			return nil, err
+		}
+
+		out, txOut, err := parseOutput(kvs, p.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Outputs = append(p.Outputs, out)
+		if txOut != nil {
+			p.UnsignedTx.AddTxOut(txOut)
+		}
+	}
+
+	if r.Len() != 0 {
+		return nil, ErrMapCount
+	}
+
+	if err := p.syncLockTime(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ParseBase64 decodes a base64 PSBT
+func ParseBase64(s string) (*Packet, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Serialize returns the binary encoding of the PSBT in its version
+func (p *Packet) Serialize() ([]byte, error) {
+	if p.UnsignedTx == nil {
+		return nil, ErrMissingUnsignedTx
+	}
+
+	if len(p.Inputs) != len(p.UnsignedTx.TxIn) || len(p.Outputs) != len(p.UnsignedTx.TxOut) {
+		return nil, ErrMapCount
+	}
+
+	var b bytes.Buffer
+	b.Write(magic)
+	w := &mapWriter{b: &b}
+
+	switch p.Version {
+	case 0:
+		var tx bytes.Buffer
+		if err := p.UnsignedTx.SerializeNoWitness(&tx); err != nil {
+			return nil, err
+		}
+		w.write([]byte{globalUnsignedTx}, tx.Bytes())
+	case 2:
+		p.writeTxFields(w)
+		w.write([]byte{globalVersion}, uint32Bytes(p.Version))
+	default:
+		return nil, ErrUnsupportedVersion
+	}
+	w.unknowns(p.Unknowns)
+	w.end()
+
+	// Version 2 maps also describe the transaction inputs and outputs
+	for i, in := range p.Inputs {
+		var txIn *wire.TxIn
+		if p.Version == 2 {
+			txIn = p.UnsignedTx.TxIn[i]
+		}
+		in.serialize(w, txIn)
+	}
+
+	for i, out := range p.Outputs {
+		var txOut *wire.TxOut
+		if p.Version == 2 {
+			txOut = p.UnsignedTx.TxOut[i]
+		}
+		out.serialize(w, txOut)
+	}
+	return b.Bytes(), w.err
+}
+
+// B64Encode returns the base64 encoding of the PSBT
+func (p *Packet) B64Encode() (string, error) {
+	data, err := p.Serialize()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// parseInput decodes the key-value pairs of an input map,
+// with the transaction input described by the map of version 2 PSBTs
+func parseInput(kvs []*keyValue, version uint32) (*Input, *wire.TxIn, error) {
+	in := &Input{}
+	var txIn *wire.TxIn
+	if version == 2 {
+		if !hasKey(kvs, inputPreviousTxID) || !hasKey(kvs, inputOutputIndex) {
+			return nil, nil, ErrMissingField
+		}
+		txIn = wire.NewTxIn(&wire.OutPoint{}, nil, nil)
+	}
+
+	for _, kv := range kvs {
+		var err error
+		switch kv.keyType() {
+		case inputNonWitnessUTXO:
+			if err = kv.checkKeyLength(0); err == nil {
+				in.NonWitnessUTXO = wire.NewMsgTx(0)
+				err = in.NonWitnessUTXO.Deserialize(bytes.NewReader(kv.value))
+			}
+		case inputWitnessUTXO:
+			if err = kv.checkKeyLength(0); err == nil {
+				in.WitnessUTXO, err = parseTxOut(kv.value)
+			}
+		case inputPartialSig:
+			if err = kv.checkPubKey(); err == nil {
+				in.PartialSigs = append(in.PartialSigs, &PartialSig{PubKey: kv.keyData(), Signature: kv.value})
+			}
+		case inputSigHashType:
+			if err = kv.checkKeyLength(0); err == nil && len(kv.value) == 4 {
+				hashType := txscript.SigHashType(binary.LittleEndian.Uint32(kv.value))
+				in.SigHashType = &hashType
+			} else if err == nil {
+				err = ErrInvalidValue
+			}
+		case inputRedeemScript:
+			if err = kv.checkKeyLength(0); err == nil {
+				in.RedeemScript = kv.value
+			}
+		case inputWitnessScript:
+			if err = kv.checkKeyLength(0); err == nil {
+				in.WitnessScript = kv.value
+			}
+		case inputBip32Derivation:
+			var d *Derivation
+			if d, err = parseDerivation(kv, false); err == nil {
+				in.Bip32Derivations = append(in.Bip32Derivations, d)
+			}
+		case inputFinalScriptSig:
+			if err = kv.checkKeyLength(0); err == nil {
+				in.FinalScriptSig = kv.value
+			}
+		case inputFinalScriptWitness:
+			if err = kv.checkKeyLength(0); err == nil {
+				in.FinalScriptWitness, err = parseWitness(kv.value)
+			}
+		case inputPreviousTxID, inputOutputIndex, inputSequence, inputRequiredTimeLocktime, inputRequiredHeightLocktime:
+			switch {
+			case len(kv.key) != 1:
+				in.Unknowns = append(in.Unknowns, &Unknown{Key: kv.key, Value: kv.value})
+			case txIn == nil:
+				err = ErrVersionField
+			default:
+				err = parseInputTxField(kv, in, txIn)
+			}
+		case inputTaprootKeySig:
+			if err = kv.checkKeyLength(0); err == nil && len(kv.value) != 64 && len(kv.value) != 65 {
+				err = ErrInvalidValue
+			}
+			in.TaprootKeySig = kv.value
+		case inputTaprootBip32Derivation:
+			var d *Derivation
+			if d, err = parseDerivation(kv, true); err == nil {
+				in.TaprootBip32Derivations = append(in.TaprootBip32Derivations, d)
+			}
+		case inputTaprootInternalKey:
+			if err = kv.checkKeyLength(0); err == nil && len(kv.value) != 32 {
+				err = ErrInvalidValue
+			}
+			in.TaprootInternalKey = kv.value
+		default:
+			in.Unknowns = append(in.Unknowns, &Unknown{Key: kv.key, Value: kv.value})
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return in, txIn, nil
+}
+
+// parseOutput decodes the key-value pairs of an output map,
+// with the transaction output described by the map of version 2 PSBTs
+func parseOutput(kvs []*keyValue, version uint32) (*Output, *wire.TxOut, error) {
+	out := &Output{}
+	var txOut *wire.TxOut
+	if version == 2 {
+		if !hasKey(kvs, outputAmount) || !hasKey(kvs, outputScript) {
+			return nil, nil, ErrMissingField
+		}
+		txOut = &wire.TxOut{}
+	}
+
+	for _, kv := range kvs {
+		var err error
+		switch kv.keyType() {
+		case outputRedeemScript:
+			if err = kv.checkKeyLength(0); err == nil {
+				out.RedeemScript = kv.value
+			}
+		case outputWitnessScript:
+			if err = kv.checkKeyLength(0); err == nil {
+				out.WitnessScript = kv.value
+			}
+		case outputBip32Derivation:
+			var d *Derivation
+			if d, err = parseDerivation(kv, false); err == nil {
+				out.Bip32Derivations = append(out.Bip32Derivations, d)
+			}
+		case outputAmount, outputScript:
+			switch {
+			case len(kv.key) != 1:
+				out.Unknowns = append(out.Unknowns, &Unknown{Key: kv.key, Value: kv.value})
+			case txOut == nil:
+				err = ErrVersionField
+			default:
+				err = parseOutputTxField(kv, txOut)
+			}
+		case outputTaprootInternalKey:
+			if err = kv.checkKeyLength(0); err == nil && len(kv.value) != 32 {
+				err = ErrInvalidValue
+			}
+			out.TaprootInternalKey = kv.value
+		case outputTaprootBip32Derivation:
+			var d *Derivation
+			if d, err = parseDerivation(kv, true); err == nil {
+				out.TaprootBip32Derivations = append(out.TaprootBip32Derivations, d)
+			}
+		default:
+			out.Unknowns = append(out.Unknowns, &Unknown{Key: kv.key, Value: kv.value})
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return out, txOut, nil
+}
+
+// serialize writes the input map, with the fields describing txIn when it is not nil (version 2)
+func (in *Input) serialize(w *mapWriter, txIn *wire.TxIn) {
+	if in.NonWitnessUTXO != nil {
+		var tx bytes.Buffer
+		if err := in.NonWitnessUTXO.Serialize(&tx); err != nil && w.err == nil {
+			w.err = err
+		}
+		w.write([]byte{inputNonWitnessUTXO}, tx.Bytes())
+	}
+
+	if in.WitnessUTXO != nil {
+		var out bytes.Buffer
+		if err := wire.WriteTxOut(&out, 0, 0, in.WitnessUTXO); err != nil && w.err == nil {
+			w.err = err
+		}
+		w.write([]byte{inputWitnessUTXO}, out.Bytes())
+	}
+
+	for _, s := range in.PartialSigs {
+		w.write(append([]byte{inputPartialSig}, s.PubKey...), s.Signature)
+	}
+
+	if in.SigHashType != nil {
+		w.write([]byte{inputSigHashType}, uint32Bytes(uint32(*in.SigHashType)))
+	}
+
+	w.writeIfSet(inputRedeemScript, in.RedeemScript)
+	w.writeIfSet(inputWitnessScript, in.WitnessScript)
+	w.derivations(inputBip32Derivation, in.Bip32Derivations, false)
+
+	if in.FinalScriptSig != nil {
+		w.write([]byte{inputFinalScriptSig}, in.FinalScriptSig)
+	}
+
+	if in.FinalScriptWitness != nil {
+		w.write([]byte{inputFinalScriptWitness}, serializeWitness(in.FinalScriptWitness))
+	}
+
+	if txIn != nil {
+		in.writeTxFields(w, txIn)
+	}
+
+	w.writeIfSet(inputTaprootKeySig, in.TaprootKeySig)
+	w.derivations(inputTaprootBip32Derivation, in.TaprootBip32Derivations, true)
+	w.writeIfSet(inputTaprootInternalKey, in.TaprootInternalKey)
+	w.unknowns(in.Unknowns)
+	w.end()
+}
+
+// serialize writes the output map, with the fields describing txOut when it is not nil (version 2)
+func (out *Output) serialize(w *mapWriter, txOut *wire.TxOut) {
+	w.writeIfSet(outputRedeemScript, out.RedeemScript)
+	w.writeIfSet(outputWitnessScript, out.WitnessScript)
+	w.derivations(outputBip32Derivation, out.Bip32Derivations, false)
+
+	if txOut != nil {
+		w.write([]byte{outputAmount}, uint64Bytes(uint64(txOut.Value)))
+		w.write([]byte{outputScript}, txOut.PkScript)
+	}
+	w.writeIfSet(outputTaprootInternalKey, out.TaprootInternalKey)
+	w.derivations(outputTaprootBip32Derivation, out.TaprootBip32Derivations, true)
+	w.unknowns(out.Unknowns)
+	w.end()
+}
+
+// parseTxOut decodes a serialized transaction output
+func parseTxOut(value []byte) (*wire.TxOut, error) {
+	r := bytes.NewReader(value)
+	var amount [8]byte
+	if _, err := io.ReadFull(r, amount[:]); err != nil {
+		return nil, ErrInvalidValue
+	}
+
+	script, err := wire.ReadVarBytes(r, 0, maxValueSize, "pkScript")
+	if err != nil || r.Len() != 0 {
+		return nil, ErrInvalidValue
+	}
+	return wire.NewTxOut(int64(binary.LittleEndian.Uint64(amount[:])), script), nil
+}
+
+// parseWitness decodes a serialized witness stack
+func parseWitness(value []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(value)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil || count > uint64(len(value)) {
+		return nil, ErrInvalidValue
+	}
+
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(r, 0, maxValueSize, "witness item")
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+	}
+
+	if r.Len() != 0 {
+		return nil, ErrInvalidValue
+	}
+	return witness, nil
+}
+
+// serializeWitness encodes a witness stack as in a transaction
+func serializeWitness(witness wire.TxWitness) []byte {
+	var b bytes.Buffer
+	wire.WriteVarInt(&b, 0, uint64(len(witness)))
+	for _, item := range witness {
+		wire.WriteVarBytes(&b, 0, item)
+	}
+	return b.Bytes()
+}
+
+// parseDerivation decodes a BIP32 derivation, with the leaf hashes that prefix the origin of taproot derivations
+func parseDerivation(kv *keyValue, taproot bool) (*Derivation, error) {
+	d := &Derivation{PubKey: kv.keyData()}
+	if taproot && len(d.PubKey) != 32 {
+		return nil, ErrInvalidKey
+	}
+
+	if !taproot {
+		if err := kv.checkPubKey(); err != nil {
+			return nil, err
+		}
+	}
+
+	r := bytes.NewReader(kv.value)
+	if taproot {
+		count, err := wire.ReadVarInt(r, 0)
+		if err != nil || count > uint64(len(kv.value)/32) {
+			return nil, ErrInvalidValue
+		}
+
+		for i := uint64(0); i < count; i++ {
+			h := make([]byte, 32)
+			if _, err := io.ReadFull(r, h); err != nil {
+				return nil, ErrInvalidValue
+			}
+			d.LeafHashes = append(d.LeafHashes, h)
+		}
+	}
+
+	if r.Len() < 4 || r.Len()%4 != 0 {
+		return nil, ErrInvalidValue
+	}
+
+	var buf [4]byte
+	io.ReadFull(r, buf[:])
+	d.Fingerprint = binary.BigEndian.Uint32(buf[:])
+	for r.Len() > 0 {
+		io.ReadFull(r, buf[:])
+		d.Path = append(d.Path, binary.LittleEndian.Uint32(buf[:]))
+	}
+	return d, nil
+}
+
+// serializeDerivation encodes the value of a BIP32 derivation
+func serializeDerivation(d *Derivation, taproot bool) []byte {
+	var b bytes.Buffer
+	if taproot {
+		wire.WriteVarInt(&b, 0, uint64(len(d.LeafHashes)))
+		for _, h := range d.LeafHashes {
+			b.Write(h)
+		}
+	}
+
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], d.Fingerprint)
+	b.Write(buf[:])
+	for _, c := range d.Path {
+		b.Write(uint32Bytes(c))
+	}
+	return b.Bytes()
+}
+
+// uint64Bytes returns v as 8 little-endian bytes
+func uint64Bytes(v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return buf[:]
+}
+
+// uint32Bytes returns v as 4 little-endian bytes
+func uint32Bytes(v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return buf[:]
+}
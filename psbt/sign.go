@@ -0,0 +1,346 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package psbt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/taproot"
+)
+
+var (
+	// ErrMissingUTXO is returned when an input has no UTXO to sign or finalize against
+	ErrMissingUTXO = errors.New("PSBT input has no UTXO")
+
+	// ErrUTXOMismatch is returned when a non-witness UTXO is not the transaction spent by its input
+	ErrUTXOMismatch = errors.New("PSBT non-witness UTXO does not match the input outpoint")
+
+	// ErrScriptMismatch is returned when a redeem or witness script does not hash to the script it is for
+	ErrScriptMismatch = errors.New("PSBT redeem or witness script does not match the spent output")
+)
+
+// keySource returns the private key of a derivation, or nil when the derivation is not of its keys
+type keySource func(d *Derivation) (*btcec.PrivateKey, error)
+
+// SignWithMaster signs every input with a derivation from the master key fingerprint
+// and returns the number of signatures added
+func (p *Packet) SignWithMaster(master *hdkeychain.ExtendedKey) (int, error) {
+	fingerprint, err := keys.GetMasterFingerprint(master)
+	if err != nil {
+		return 0, err
+	}
+
+	return p.sign(func(d *Derivation) (*btcec.PrivateKey, error) {
+		if d.Fingerprint != fingerprint {
+			return nil, nil
+		}
+
+		k, err := keys.DeriveKeyForPath(master, d.Path)
+		if err != nil {
+			return nil, err
+		}
+		return derivedKey(k, d)
+	})
+}
+
+// SignWithAccountKey signs every input with a derivation of an address key of an account extended private key
+// (the last two path components being the change and address index) and returns the number of signatures added
+func (p *Packet) SignWithAccountKey(account *hdkeychain.ExtendedKey) (int, error) {
+	return p.sign(func(d *Derivation) (*btcec.PrivateKey, error) {
+		n := len(d.Path)
+		if n < 2 || d.Path[n-2] >= keys.HardenedKeyZeroIndex || d.Path[n-1] >= keys.HardenedKeyZeroIndex {
+			return nil, nil
+		}
+
+		k, err := keys.DeriveKeyForPath(account, d.Path[n-2:])
+		if err != nil {
+			return nil, err
+		}
+		return derivedKey(k, d)
+	})
+}
+
+// derivedKey returns the private key of k when its public key is the one of the derivation
+func derivedKey(k *hdkeychain.ExtendedKey, d *Derivation) (*btcec.PrivateKey, error) {
+	priv, err := k.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var pub []byte
+	switch len(d.PubKey) {
+	case 32:
+		pub = taproot.XOnlyPubKey(priv.PubKey())
+	case 33:
+		pub = priv.PubKey().SerializeCompressed()
+	default:
+		pub = priv.PubKey().SerializeUncompressed()
+	}
+
+	if !bytes.Equal(pub, d.PubKey) {
+		return nil, nil
+	}
+	return priv, nil
+}
+
+// sign adds the signatures source can make to inputs that are not finalized
+func (p *Packet) sign(source keySource) (int, error) {
+	if err := p.syncLockTime(); err != nil {
+		return 0, err
+	}
+
+	sigHashes := txscript.NewTxSigHashes(p.UnsignedTx)
+	signed := 0
+	for i, in := range p.Inputs {
+		if in.IsFinalized() {
+			continue
+		}
+
+		prevOut, err := p.prevOut(i)
+		if err == ErrMissingUTXO {
+			continue
+		}
+
+		if err != nil {
+			return signed, err
+		}
+
+		var n int
+		if isP2TR(prevOut.PkScript) {
+			n, err = p.signTaproot(i, prevOut.PkScript, source)
+		} else {
+			n, err = p.signECDSA(i, prevOut, sigHashes, source)
+		}
+
+		if err != nil {
+			return signed, err
+		}
+		signed += n
+	}
+	return signed, nil
+}
+
+// signECDSA adds the partial signatures of an input spending a legacy, P2SH or segwit v0 output
+func (p *Packet) signECDSA(i int, prevOut *wire.TxOut, sigHashes *txscript.TxSigHashes, source keySource) (int, error) {
+	in := p.Inputs[i]
+	subScript, witness, err := in.signingScript(prevOut.PkScript)
+	if err != nil || subScript == nil {
+		return 0, err
+	}
+
+	hashType := txscript.SigHashAll
+	if in.SigHashType != nil {
+		hashType = *in.SigHashType
+	}
+
+	signed := 0
+	for _, d := range in.Bip32Derivations {
+		if in.partialSig(d.PubKey) != nil || (witness && len(d.PubKey) != 33) {
+			continue
+		}
+
+		// The key must appear in the script, directly or as its hash
+		if !bytes.Contains(subScript, d.PubKey) && !bytes.Contains(subScript, btcutil.Hash160(d.PubKey)) {
+			continue
+		}
+
+		priv, err := source(d)
+		if err != nil {
+			return signed, err
+		}
+
+		if priv == nil {
+			continue
+		}
+
+		// Legacy signatures do not commit to the amount, the whole previous transaction is needed to check it
+		if !witness && in.NonWitnessUTXO == nil {
+			return signed, ErrMissingUTXO
+		}
+
+		var sig []byte
+		if witness {
+			sig, err = txscript.RawTxInWitnessSignature(p.UnsignedTx, sigHashes, i, prevOut.Value, subScript, hashType, priv)
+		} else {
+			sig, err = txscript.RawTxInSignature(p.UnsignedTx, i, subScript, hashType, priv)
+		}
+
+		if err != nil {
+			return signed, err
+		}
+
+		in.PartialSigs = append(in.PartialSigs, &PartialSig{PubKey: d.PubKey, Signature: sig})
+		p.updateModifiable(hashType)
+		signed++
+	}
+	return signed, nil
+}
+
+// signTaproot adds the key path signature of an input spending a P2TR output without a script tree (BIP86)
+func (p *Packet) signTaproot(i int, pkScript []byte, source keySource) (int, error) {
+	in := p.Inputs[i]
+	if in.TaprootKeySig != nil {
+		return 0, nil
+	}
+
+	hashType := taproot.SigHashDefault
+	if in.SigHashType != nil {
+		hashType = *in.SigHashType
+	}
+
+	for _, d := range in.TaprootBip32Derivations {
+		if len(d.LeafHashes) != 0 {
+			continue
+		}
+
+		priv, err := source(d)
+		if err != nil {
+			return 0, err
+		}
+
+		if priv == nil {
+			continue
+		}
+
+		outputPriv, err := taproot.TweakPrivateKey(priv, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		if !bytes.Equal(taproot.XOnlyPubKey(outputPriv.PubKey()), pkScript[2:]) {
+			continue
+		}
+
+		prevOuts, err := p.prevOuts()
+		if err != nil {
+			return 0, err
+		}
+
+		hash, err := taproot.KeySpendSigHash(p.UnsignedTx, prevOuts, i, hashType)
+		if err != nil {
+			return 0, err
+		}
+
+		sig, err := taproot.SignSchnorr(outputPriv, hash, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		if hashType != taproot.SigHashDefault {
+			sig = append(sig, byte(hashType))
+		}
+		in.TaprootKeySig = sig
+		p.updateModifiable(hashType)
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// signingScript returns the script signatures of the input commit to and whether the spend is segwit,
+// or a nil script for outputs this package cannot sign
+func (in *Input) signingScript(pkScript []byte) ([]byte, bool, error) {
+	script := pkScript
+	nested := false
+	if txscript.IsPayToScriptHash(pkScript) {
+		if in.RedeemScript == nil {
+			return nil, false, nil
+		}
+
+		if !bytes.Equal(btcutil.Hash160(in.RedeemScript), pkScript[2:22]) {
+			return nil, false, ErrScriptMismatch
+		}
+		script = in.RedeemScript
+		nested = true
+	}
+
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(script):
+		return script, true, nil
+	case txscript.IsPayToWitnessScriptHash(script):
+		if in.WitnessScript == nil {
+			return nil, false, nil
+		}
+
+		hash := sha256.Sum256(in.WitnessScript)
+		if !bytes.Equal(hash[:], script[2:]) {
+			return nil, false, ErrScriptMismatch
+		}
+		return in.WitnessScript, true, nil
+	case txscript.IsWitnessProgram(script):
+		return nil, false, nil
+	case nested || txscript.GetScriptClass(script) == txscript.PubKeyHashTy:
+		return script, false, nil
+	}
+	return nil, false, nil
+}
+
+// partialSig returns the partial signature of a public key, nil if there is none
+func (in *Input) partialSig(pubKey []byte) *PartialSig {
+	for _, s := range in.PartialSigs {
+		if bytes.Equal(s.PubKey, pubKey) {
+			return s
+		}
+	}
+	return nil
+}
+
+// prevOut returns the output spent by input i, from its witness UTXO or non-witness UTXO
+func (p *Packet) prevOut(i int) (*wire.TxOut, error) {
+	in := p.Inputs[i]
+	if in.WitnessUTXO != nil {
+		return in.WitnessUTXO, nil
+	}
+
+	if in.NonWitnessUTXO == nil {
+		return nil, ErrMissingUTXO
+	}
+
+	op := p.UnsignedTx.TxIn[i].PreviousOutPoint
+	if in.NonWitnessUTXO.TxHash() != op.Hash || int(op.Index) >= len(in.NonWitnessUTXO.TxOut) {
+		return nil, ErrUTXOMismatch
+	}
+	return in.NonWitnessUTXO.TxOut[op.Index], nil
+}
+
+// prevOuts returns the outputs spent by every input, which taproot signatures commit to
+func (p *Packet) prevOuts() ([]*wire.TxOut, error) {
+	prevOuts := make([]*wire.TxOut, len(p.Inputs))
+	for i := range p.Inputs {
+		prevOut, err := p.prevOut(i)
+		if err != nil {
+			return nil, err
+		}
+		prevOuts[i] = prevOut
+	}
+	return prevOuts, nil
+}
+
+// isP2TR returns true for segwit version 1 outputs with a 32 byte program
+func isP2TR(script []byte) bool {
+	return len(script) == 34 && script[0] == txscript.OP_1 && script[1] == txscript.OP_DATA_32
+}
@@ -0,0 +1,410 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package psbt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/taproot"
+)
+
+// BIP32 test vector 1 seed ref: https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki#test-vector-1
+const testSeedHex = "000102030405060708090a0b0c0d0e0f"
+
+// testAccountPath is the account the test derivations are under, m/84'/0'/0'
+var testAccountPath = keys.NewAccountPath(keys.BIP84Purpose, 0, 0)
+
+// testMaster returns the master key of the test seed
+func testMaster(t *testing.T) *hdkeychain.ExtendedKey {
+	seed, _ := hex.DecodeString(testSeedHex)
+	m, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// testDerivation returns the derivation of address index of the test account and its public key
+func testDerivation(t *testing.T, master *hdkeychain.ExtendedKey, index uint32) (*Derivation, *btcec.PublicKey) {
+	fingerprint, err := keys.GetMasterFingerprint(master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := testAccountPath.Child(0).Child(index)
+	k, err := keys.DeriveKeyForPath(master, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := k.ECPubKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Derivation{PubKey: pub.SerializeCompressed(), Fingerprint: fingerprint, Path: path}, pub
+}
+
+// testFundedPacket returns a PSBT spending one output of each script of a funding transaction
+func testFundedPacket(t *testing.T, scripts [][]byte) (*Packet, *wire.MsgTx) {
+	funding := testUnsignedTx(1)
+	funding.TxOut = nil
+	for _, script := range scripts {
+		funding.AddTxOut(wire.NewTxOut(100000, script))
+	}
+
+	tx := testUnsignedTx(len(scripts))
+	for _, in := range tx.TxIn {
+		in.PreviousOutPoint.Hash = funding.TxHash()
+	}
+
+	p, err := New(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, funding
+}
+
+// verifyPacketInputs runs the scripts of every input of a signed transaction
+func verifyPacketInputs(t *testing.T, tx *wire.MsgTx, funding *wire.MsgTx) {
+	sigHashes := txscript.NewTxSigHashes(tx)
+	for i := range tx.TxIn {
+		prevOut := funding.TxOut[tx.TxIn[i].PreviousOutPoint.Index]
+		if isP2TR(prevOut.PkScript) {
+			hash, err := taproot.KeySpendSigHash(tx, funding.TxOut, i, taproot.SigHashDefault)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := taproot.VerifySchnorr(prevOut.PkScript[2:], hash, tx.TxIn[i].Witness[0]); err != nil {
+				t.Errorf("taproot input %d does not verify: %s", i, err.Error())
+			}
+			continue
+		}
+
+		vm, err := txscript.NewEngine(prevOut.PkScript, tx, i, txscript.StandardVerifyFlags, nil, sigHashes, prevOut.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := vm.Execute(); err != nil {
+			t.Errorf("input %d does not verify: %s", i, err.Error())
+		}
+	}
+}
+
+func TestSignFinalizeExtract(t *testing.T) {
+	master := testMaster(t)
+	var derivations []*Derivation
+	var scripts [][]byte
+	for i := uint32(0); i < 4; i++ {
+		d, pub := testDerivation(t, master, i)
+		derivations = append(derivations, d)
+
+		hash := btcutil.Hash160(d.PubKey)
+		switch i {
+		case 0:
+			script, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+				AddData(hash).AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+			scripts = append(scripts, script)
+		case 1:
+			redeemScript := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, hash...)
+			script, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(redeemScript)).AddOp(txscript.OP_EQUAL).Script()
+			scripts = append(scripts, script)
+		case 2:
+			scripts = append(scripts, append([]byte{txscript.OP_0, txscript.OP_DATA_20}, hash...))
+		case 3:
+			output, err := taproot.TweakPublicKey(pub, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scripts = append(scripts, append([]byte{txscript.OP_1, txscript.OP_DATA_32}, taproot.XOnlyPubKey(output)...))
+			d.PubKey = taproot.XOnlyPubKey(pub)
+		}
+	}
+
+	p, funding := testFundedPacket(t, scripts)
+	p.Inputs[0].NonWitnessUTXO = funding
+	p.Inputs[0].Bip32Derivations = derivations[:1]
+	p.Inputs[1].WitnessUTXO = funding.TxOut[1]
+	p.Inputs[1].RedeemScript = append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(derivations[1].PubKey)...)
+	p.Inputs[1].Bip32Derivations = derivations[1:2]
+	p.Inputs[2].WitnessUTXO = funding.TxOut[2]
+	p.Inputs[2].Bip32Derivations = derivations[2:3]
+	p.Inputs[3].WitnessUTXO = funding.TxOut[3]
+	p.Inputs[3].TaprootBip32Derivations = derivations[3:]
+	p.Inputs[3].TaprootInternalKey = derivations[3].PubKey
+
+	unsigned, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Finalize(); err != ErrIncomplete {
+		t.Error("unsigned PSBT did not fail to finalize where expected")
+	}
+
+	if _, err := p.Extract(); err != ErrNotFinalized {
+		t.Error("unsigned PSBT did not fail to extract where expected")
+	}
+
+	n, err := p.SignWithMaster(master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 4 {
+		t.Errorf("master key signed %d inputs want 4", n)
+	}
+
+	if n, _ := p.SignWithMaster(master); n != 0 {
+		t.Errorf("signing again added %d signatures", n)
+	}
+
+	// The account key makes the same deterministic signatures
+	fromAccount, err := Parse(unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := keys.DeriveKeyForPath(master, testAccountPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := fromAccount.SignWithAccountKey(account); err != nil || n != 4 {
+		t.Errorf("account key signed %d inputs want 4 (%v)", n, err)
+	}
+
+	signed, _ := p.Serialize()
+	if again, _ := fromAccount.Serialize(); !bytes.Equal(signed, again) {
+		t.Error("account key signatures are not the master key signatures")
+	}
+
+	if err := p.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, in := range p.Inputs {
+		if !in.IsFinalized() || in.PartialSigs != nil || in.Bip32Derivations != nil || in.TaprootKeySig != nil {
+			t.Errorf("input %d is not finalized or kept signing data", i)
+		}
+	}
+
+	if p.Inputs[0].FinalScriptWitness != nil || p.Inputs[1].FinalScriptSig == nil || p.Inputs[2].FinalScriptSig != nil {
+		t.Error("finalized inputs do not have the expected scriptSigs and witnesses")
+	}
+
+	tx, err := p.Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tx.TxIn) != 4 || p.UnsignedTx.TxIn[0].SignatureScript != nil {
+		t.Error("extracted transaction is not a signed copy of the unsigned transaction")
+	}
+	verifyPacketInputs(t, tx, funding)
+}
+
+func TestSignSkipsOtherKeys(t *testing.T) {
+	master := testMaster(t)
+	d, _ := testDerivation(t, master, 0)
+	script := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(d.PubKey)...)
+
+	p, funding := testFundedPacket(t, [][]byte{script})
+	p.Inputs[0].WitnessUTXO = funding.TxOut[0]
+	p.Inputs[0].Bip32Derivations = []*Derivation{d}
+
+	seed := bytes.Repeat([]byte{0x42}, 32)
+	other, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := p.SignWithMaster(other); err != nil || n != 0 {
+		t.Errorf("other master key signed %d inputs (%v)", n, err)
+	}
+
+	if n, err := p.SignWithAccountKey(other); err != nil || n != 0 {
+		t.Errorf("other account key signed %d inputs (%v)", n, err)
+	}
+
+	// Legacy inputs need the previous transaction
+	legacy, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(d.PubKey)).AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+	p, funding = testFundedPacket(t, [][]byte{legacy})
+	p.Inputs[0].WitnessUTXO = funding.TxOut[0]
+	p.Inputs[0].Bip32Derivations = []*Derivation{d}
+	if _, err := p.SignWithMaster(master); err != ErrMissingUTXO {
+		t.Error("legacy input without previous transaction did not fail where expected")
+	}
+
+	p.Inputs[0].WitnessUTXO = nil
+	p.Inputs[0].NonWitnessUTXO = testUnsignedTx(1)
+	if _, err := p.SignWithMaster(master); err != ErrUTXOMismatch {
+		t.Error("wrong previous transaction did not fail where expected")
+	}
+}
+
+// BIP174 creator, signer, combiner, finalizer and extractor test vectors
+// ref: https://github.com/bitcoin/bips/blob/master/bip-0174.mediawiki#test-vectors
+const (
+	testBIP174Master = "tprv8ZgxMBicQKsPd9TeAdPADNnSyH9SSUUbTVeFszDE23Ki6TBB5nCefAdHkK8Fm3qMQR6sHwA56zqRmKmxnHk37JkiFzvncDqoKmPWubu7hDF"
+
+	// testBIP174Created is the PSBT of the creator, spending two outputs to two P2WPKH outputs
+	testBIP174Created = "70736274ff01009a020000000258e87a21b56daf0c23be8e7070456c336f7cbaa5c8757924f545887bb2abdd750000000000ffffffff838d0427d0ec650a68aa46bb0b098aea4422c071b2ca78352a077959d07cea1d0100000000ffffffff0270aaf00800000000160014d85c2b71d0060b09c9886aeb815e50991dda124d00e1f5050000000016001400aea9a2e5f0f876a588df5546e8742d1d87008f000000000000000000"
+
+	// testBIP174Updated is the PSBT the signers start from, with the UTXOs, scripts, derivations and sighash types of the updater
+	testBIP174Updated = "70736274ff01009a020000000258e87a21b56daf0c23be8e7070456c336f7cbaa5c8757924f545887bb2abdd750000000000ffffffff838d0427d0ec650a68aa46bb0b098aea4422c071b2ca78352a077959d07cea1d0100000000ffffffff0270aaf00800000000160014d85c2b71d0060b09c9886aeb815e50991dda124d00e1f5050000000016001400aea9a2e5f0f876a588df5546e8742d1d87008f00000000000100bb0200000001aad73931018bd25f84ae400b68848be09db706eac2ac18298babee71ab656f8b0000000048473044022058f6fc7c6a33e1b31548d481c826c015bd30135aad42cd67790dab66d2ad243b02204a1ced2604c6735b6393e5b41691dd78b00f0c5942fb9f751856faa938157dba01feffffff0280f0fa020000000017a9140fb9463421696b82c833af241c78c17ddbde493487d0f20a270100000017a91429ca74f8a08f81999428185c97b5d852e4063f6187650000000104475221029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f2102dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d752ae2206029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f10d90c6a4f000000800000008000000080220602dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d710d90c6a4f000000800000008001000080010304010000000001012000c2eb0b0000000017a914b7f5faf40e3d40a5a459b1db3535f2b72fa921e88701042200208c2353173743b595dfb4a07b72ba8e42e3797da74e87fe7d9d7497e3b2028903010547522103089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc21023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7352ae2206023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7310d90c6a4f000000800000008003000080220603089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc10d90c6a4f0000008000000080020000800103040100000000220203a9a4c37f5996d3aa25dbac6b570af0650394492942460b354753ed9eeca5877110d90c6a4f000000800000008004000080002202027f6399757d2eff55a136ad02c684b1838b6556e5f1b6b34282a94b6b5005109610d90c6a4f00000080000000800500008000"
+
+	// testBIP174Signed1 and testBIP174Signed2 are testBIP174Updated signed by each signer
+	testBIP174Signed1 = "70736274ff01009a020000000258e87a21b56daf0c23be8e7070456c336f7cbaa5c8757924f545887bb2abdd750000000000ffffffff838d0427d0ec650a68aa46bb0b098aea4422c071b2ca78352a077959d07cea1d0100000000ffffffff0270aaf00800000000160014d85c2b71d0060b09c9886aeb815e50991dda124d00e1f5050000000016001400aea9a2e5f0f876a588df5546e8742d1d87008f00000000000100bb0200000001aad73931018bd25f84ae400b68848be09db706eac2ac18298babee71ab656f8b0000000048473044022058f6fc7c6a33e1b31548d481c826c015bd30135aad42cd67790dab66d2ad243b02204a1ced2604c6735b6393e5b41691dd78b00f0c5942fb9f751856faa938157dba01feffffff0280f0fa020000000017a9140fb9463421696b82c833af241c78c17ddbde493487d0f20a270100000017a91429ca74f8a08f81999428185c97b5d852e4063f6187650000002202029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f473044022074018ad4180097b873323c0015720b3684cc8123891048e7dbcd9b55ad679c99022073d369b740e3eb53dcefa33823c8070514ca55a7dd9544f157c167913261118c01010304010000000104475221029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f2102dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d752ae2206029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f10d90c6a4f000000800000008000000080220602dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d710d90c6a4f0000008000000080010000800001012000c2eb0b0000000017a914b7f5faf40e3d40a5a459b1db3535f2b72fa921e887220203089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc473044022062eb7a556107a7c73f45ac4ab5a1dddf6f7075fb1275969a7f383efff784bcb202200c05dbb7470dbf2f08557dd356c7325c1ed30913e996cd3840945db12228da5f010103040100000001042200208c2353173743b595dfb4a07b72ba8e42e3797da74e87fe7d9d7497e3b2028903010547522103089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc21023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7352ae2206023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7310d90c6a4f000000800000008003000080220603089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc10d90c6a4f00000080000000800200008000220203a9a4c37f5996d3aa25dbac6b570af0650394492942460b354753ed9eeca5877110d90c6a4f000000800000008004000080002202027f6399757d2eff55a136ad02c684b1838b6556e5f1b6b34282a94b6b5005109610d90c6a4f00000080000000800500008000"
+	testBIP174Signed2 = "70736274ff01009a020000000258e87a21b56daf0c23be8e7070456c336f7cbaa5c8757924f545887bb2abdd750000000000ffffffff838d0427d0ec650a68aa46bb0b098aea4422c071b2ca78352a077959d07cea1d0100000000ffffffff0270aaf00800000000160014d85c2b71d0060b09c9886aeb815e50991dda124d00e1f5050000000016001400aea9a2e5f0f876a588df5546e8742d1d87008f00000000000100bb0200000001aad73931018bd25f84ae400b68848be09db706eac2ac18298babee71ab656f8b0000000048473044022058f6fc7c6a33e1b31548d481c826c015bd30135aad42cd67790dab66d2ad243b02204a1ced2604c6735b6393e5b41691dd78b00f0c5942fb9f751856faa938157dba01feffffff0280f0fa020000000017a9140fb9463421696b82c833af241c78c17ddbde493487d0f20a270100000017a91429ca74f8a08f81999428185c97b5d852e4063f618765000000220202dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d7483045022100f61038b308dc1da865a34852746f015772934208c6d24454393cd99bdf2217770220056e675a675a6d0a02b85b14e5e29074d8a25a9b5760bea2816f661910a006ea01010304010000000104475221029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f2102dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d752ae2206029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f10d90c6a4f000000800000008000000080220602dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d710d90c6a4f0000008000000080010000800001012000c2eb0b0000000017a914b7f5faf40e3d40a5a459b1db3535f2b72fa921e8872202023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e73473044022065f45ba5998b59a27ffe1a7bed016af1f1f90d54b3aa8f7450aa5f56a25103bd02207f724703ad1edb96680b284b56d4ffcb88f7fb759eabbe08aa30f29b851383d2010103040100000001042200208c2353173743b595dfb4a07b72ba8e42e3797da74e87fe7d9d7497e3b2028903010547522103089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc21023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7352ae2206023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7310d90c6a4f000000800000008003000080220603089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc10d90c6a4f00000080000000800200008000220203a9a4c37f5996d3aa25dbac6b570af0650394492942460b354753ed9eeca5877110d90c6a4f000000800000008004000080002202027f6399757d2eff55a136ad02c684b1838b6556e5f1b6b34282a94b6b5005109610d90c6a4f00000080000000800500008000"
+
+	// testBIP174Combined is the combination of the signed PSBTs
+	testBIP174Combined = "70736274ff01009a020000000258e87a21b56daf0c23be8e7070456c336f7cbaa5c8757924f545887bb2abdd750000000000ffffffff838d0427d0ec650a68aa46bb0b098aea4422c071b2ca78352a077959d07cea1d0100000000ffffffff0270aaf00800000000160014d85c2b71d0060b09c9886aeb815e50991dda124d00e1f5050000000016001400aea9a2e5f0f876a588df5546e8742d1d87008f00000000000100bb0200000001aad73931018bd25f84ae400b68848be09db706eac2ac18298babee71ab656f8b0000000048473044022058f6fc7c6a33e1b31548d481c826c015bd30135aad42cd67790dab66d2ad243b02204a1ced2604c6735b6393e5b41691dd78b00f0c5942fb9f751856faa938157dba01feffffff0280f0fa020000000017a9140fb9463421696b82c833af241c78c17ddbde493487d0f20a270100000017a91429ca74f8a08f81999428185c97b5d852e4063f6187650000002202029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f473044022074018ad4180097b873323c0015720b3684cc8123891048e7dbcd9b55ad679c99022073d369b740e3eb53dcefa33823c8070514ca55a7dd9544f157c167913261118c01220202dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d7483045022100f61038b308dc1da865a34852746f015772934208c6d24454393cd99bdf2217770220056e675a675a6d0a02b85b14e5e29074d8a25a9b5760bea2816f661910a006ea01010304010000000104475221029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f2102dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d752ae2206029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f10d90c6a4f000000800000008000000080220602dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d710d90c6a4f0000008000000080010000800001012000c2eb0b0000000017a914b7f5faf40e3d40a5a459b1db3535f2b72fa921e887220203089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc473044022062eb7a556107a7c73f45ac4ab5a1dddf6f7075fb1275969a7f383efff784bcb202200c05dbb7470dbf2f08557dd356c7325c1ed30913e996cd3840945db12228da5f012202023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e73473044022065f45ba5998b59a27ffe1a7bed016af1f1f90d54b3aa8f7450aa5f56a25103bd02207f724703ad1edb96680b284b56d4ffcb88f7fb759eabbe08aa30f29b851383d2010103040100000001042200208c2353173743b595dfb4a07b72ba8e42e3797da74e87fe7d9d7497e3b2028903010547522103089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc21023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7352ae2206023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7310d90c6a4f000000800000008003000080220603089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc10d90c6a4f00000080000000800200008000220203a9a4c37f5996d3aa25dbac6b570af0650394492942460b354753ed9eeca5877110d90c6a4f000000800000008004000080002202027f6399757d2eff55a136ad02c684b1838b6556e5f1b6b34282a94b6b5005109610d90c6a4f00000080000000800500008000"
+
+	// testBIP174Finalized is testBIP174Combined finalized and testBIP174Extracted the transaction extracted from it
+	testBIP174Finalized = "70736274ff01009a020000000258e87a21b56daf0c23be8e7070456c336f7cbaa5c8757924f545887bb2abdd750000000000ffffffff838d0427d0ec650a68aa46bb0b098aea4422c071b2ca78352a077959d07cea1d0100000000ffffffff0270aaf00800000000160014d85c2b71d0060b09c9886aeb815e50991dda124d00e1f5050000000016001400aea9a2e5f0f876a588df5546e8742d1d87008f00000000000100bb0200000001aad73931018bd25f84ae400b68848be09db706eac2ac18298babee71ab656f8b0000000048473044022058f6fc7c6a33e1b31548d481c826c015bd30135aad42cd67790dab66d2ad243b02204a1ced2604c6735b6393e5b41691dd78b00f0c5942fb9f751856faa938157dba01feffffff0280f0fa020000000017a9140fb9463421696b82c833af241c78c17ddbde493487d0f20a270100000017a91429ca74f8a08f81999428185c97b5d852e4063f6187650000000107da00473044022074018ad4180097b873323c0015720b3684cc8123891048e7dbcd9b55ad679c99022073d369b740e3eb53dcefa33823c8070514ca55a7dd9544f157c167913261118c01483045022100f61038b308dc1da865a34852746f015772934208c6d24454393cd99bdf2217770220056e675a675a6d0a02b85b14e5e29074d8a25a9b5760bea2816f661910a006ea01475221029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f2102dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d752ae0001012000c2eb0b0000000017a914b7f5faf40e3d40a5a459b1db3535f2b72fa921e8870107232200208c2353173743b595dfb4a07b72ba8e42e3797da74e87fe7d9d7497e3b20289030108da0400473044022062eb7a556107a7c73f45ac4ab5a1dddf6f7075fb1275969a7f383efff784bcb202200c05dbb7470dbf2f08557dd356c7325c1ed30913e996cd3840945db12228da5f01473044022065f45ba5998b59a27ffe1a7bed016af1f1f90d54b3aa8f7450aa5f56a25103bd02207f724703ad1edb96680b284b56d4ffcb88f7fb759eabbe08aa30f29b851383d20147522103089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc21023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7352ae00220203a9a4c37f5996d3aa25dbac6b570af0650394492942460b354753ed9eeca5877110d90c6a4f000000800000008004000080002202027f6399757d2eff55a136ad02c684b1838b6556e5f1b6b34282a94b6b5005109610d90c6a4f00000080000000800500008000"
+	testBIP174Extracted = "0200000000010258e87a21b56daf0c23be8e7070456c336f7cbaa5c8757924f545887bb2abdd7500000000da00473044022074018ad4180097b873323c0015720b3684cc8123891048e7dbcd9b55ad679c99022073d369b740e3eb53dcefa33823c8070514ca55a7dd9544f157c167913261118c01483045022100f61038b308dc1da865a34852746f015772934208c6d24454393cd99bdf2217770220056e675a675a6d0a02b85b14e5e29074d8a25a9b5760bea2816f661910a006ea01475221029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f2102dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d752aeffffffff838d0427d0ec650a68aa46bb0b098aea4422c071b2ca78352a077959d07cea1d01000000232200208c2353173743b595dfb4a07b72ba8e42e3797da74e87fe7d9d7497e3b2028903ffffffff0270aaf00800000000160014d85c2b71d0060b09c9886aeb815e50991dda124d00e1f5050000000016001400aea9a2e5f0f876a588df5546e8742d1d87008f000400473044022062eb7a556107a7c73f45ac4ab5a1dddf6f7075fb1275969a7f383efff784bcb202200c05dbb7470dbf2f08557dd356c7325c1ed30913e996cd3840945db12228da5f01473044022065f45ba5998b59a27ffe1a7bed016af1f1f90d54b3aa8f7450aa5f56a25103bd02207f724703ad1edb96680b284b56d4ffcb88f7fb759eabbe08aa30f29b851383d20147522103089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc21023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e7352ae00000000"
+)
+
+// testBIP174Signers are the WIF private keys of each signer
+var testBIP174Signers = [][]string{
+	{"cP53pDbR5WtAD8dYAW9hhTjuvvTVaEiQBdrz9XPrgLBeRFiyCbQr", "cR6SXDoyfQrcp4piaiHE97Rsgta9mNhGTen9XeonVgwsh4iSgw6d"},
+	{"cT7J9YpCwY3AVRFSjN6ukeEeWY6mhpbJPxRaDaP5QTdygQRxP9Au", "cNBc3SWUip9PPm1GjRoLEJT6T41iNzCYtD7qro84FMnM5zEqeJsE"},
+}
+
+// testBIP174Packet parses a hex encoded PSBT
+func testBIP174Packet(t *testing.T, s string) *Packet {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestBIP174Roles(t *testing.T) {
+	unsigned := testBIP174Packet(t, testBIP174Created).UnsignedTx
+	created, err := New(unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(mustSerialize(t, created)) != testBIP174Created {
+		t.Error("created PSBT is not the creator test vector")
+	}
+
+	// Each signer holds two of the four keys of the inputs
+	signed := make([]*Packet, len(testBIP174Signers))
+	for i, wifs := range testBIP174Signers {
+		var privs []*btcec.PrivateKey
+		for _, s := range wifs {
+			wif, err := btcutil.DecodeWIF(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			privs = append(privs, wif.PrivKey)
+		}
+
+		signed[i] = testBIP174Packet(t, testBIP174Updated)
+		n, err := signed[i].sign(func(d *Derivation) (*btcec.PrivateKey, error) {
+			for _, priv := range privs {
+				if bytes.Equal(priv.PubKey().SerializeCompressed(), d.PubKey) {
+					return priv, nil
+				}
+			}
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := testBIP174Signed1
+		if i == 1 {
+			expected = testBIP174Signed2
+		}
+
+		if n != 2 || hex.EncodeToString(mustSerialize(t, signed[i])) != expected {
+			t.Errorf("signer %d added %d signatures or did not give the signer test vector", i+1, n)
+		}
+	}
+
+	combined, err := Combine(signed...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(mustSerialize(t, combined)) != testBIP174Combined {
+		t.Error("combined PSBT is not the combiner test vector")
+	}
+
+	if err := combined.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(mustSerialize(t, combined)) != testBIP174Finalized {
+		t.Error("finalized PSBT is not the finalizer test vector")
+	}
+
+	// The master key of the test vectors makes all four signatures
+	master, err := hdkeychain.NewKeyFromString(testBIP174Master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := testBIP174Packet(t, testBIP174Updated)
+	if n, err := p.SignWithMaster(master); err != nil || n != 4 {
+		t.Fatalf("master key added %d signatures: %v", n, err)
+	}
+
+	if err := p.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, packet := range []*Packet{combined, p} {
+		tx, err := packet.Extract()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var b bytes.Buffer
+		if err := tx.Serialize(&b); err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(b.Bytes()) != testBIP174Extracted {
+			t.Error("extracted transaction is not the extractor test vector")
+		}
+	}
+}
@@ -0,0 +1,364 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package psbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Flags of the version 2 TxModifiable field
+const (
+	// ModifiableInputs is set when inputs can be added
+	ModifiableInputs = 0x01
+
+	// ModifiableOutputs is set when outputs can be added
+	ModifiableOutputs = 0x02
+
+	// HasSigHashSingle is set when a signature commits to the output at its own input index
+	HasSigHashSingle = 0x04
+)
+
+var (
+	// ErrVersionField is returned for fields the PSBT version excludes, such as the unsigned transaction in version 2
+	ErrVersionField = errors.New("PSBT has a field its version does not allow")
+
+	// ErrMissingField is returned when a version 2 PSBT lacks a field needed to build its transaction
+	ErrMissingField = errors.New("PSBT is missing a required version 2 field")
+
+	// ErrLockTimeConflict is returned when the inputs of a version 2 PSBT require both time and height locktimes
+	ErrLockTimeConflict = errors.New("PSBT inputs require conflicting locktimes")
+
+	// ErrNotModifiable is returned when adding inputs or outputs a PSBT does not allow to be added
+	ErrNotModifiable = errors.New("PSBT inputs or outputs cannot be modified")
+)
+
+// txFields are the global version 2 fields describing the transaction
+type txFields struct {
+	// present is set when any version 2 only field was found
+	present     bool
+	txVersion   *int32
+	inputCount  *uint64
+	outputCount *uint64
+}
+
+// SetVersion converts the PSBT to version 0 or 2. Converting to version 0 drops the locktime requirements
+// and Modifiable flags, the transaction keeping the locktime they determined
+func (p *Packet) SetVersion(version uint32) error {
+	switch version {
+	case 0:
+		p.FallbackLocktime = nil
+		p.TxModifiable = 0
+		for _, in := range p.Inputs {
+			in.RequiredTimeLocktime = nil
+			in.RequiredHeightLocktime = nil
+		}
+	case 2:
+		if p.Version != 2 && p.UnsignedTx.LockTime != 0 {
+			lockTime := p.UnsignedTx.LockTime
+			p.FallbackLocktime = &lockTime
+		}
+	default:
+		return ErrUnsupportedVersion
+	}
+
+	p.Version = version
+	return nil
+}
+
+// AddInput appends an input to a version 2 PSBT with the ModifiableInputs flag. The input must not
+// change the locktime once inputs are signed
+func (p *Packet) AddInput(txIn *wire.TxIn, in *Input) error {
+	if p.Version != 2 || p.TxModifiable&ModifiableInputs == 0 {
+		return ErrNotModifiable
+	}
+
+	if len(txIn.SignatureScript) != 0 || len(txIn.Witness) != 0 {
+		return ErrSignedUnsignedTx
+	}
+
+	p.UnsignedTx.AddTxIn(wire.NewTxIn(&txIn.PreviousOutPoint, nil, nil))
+	p.UnsignedTx.TxIn[len(p.UnsignedTx.TxIn)-1].Sequence = txIn.Sequence
+	p.Inputs = append(p.Inputs, in)
+
+	lockTime, err := p.lockTime()
+	if err == nil && lockTime != p.UnsignedTx.LockTime && p.hasSignatures() {
+		err = ErrNotModifiable
+	}
+
+	if err != nil {
+		p.UnsignedTx.TxIn = p.UnsignedTx.TxIn[:len(p.UnsignedTx.TxIn)-1]
+		p.Inputs = p.Inputs[:len(p.Inputs)-1]
+		return err
+	}
+
+	p.UnsignedTx.LockTime = lockTime
+	return nil
+}
+
+// AddOutput appends an output to a version 2 PSBT with the ModifiableOutputs flag
+func (p *Packet) AddOutput(txOut *wire.TxOut, out *Output) error {
+	if p.Version != 2 || p.TxModifiable&ModifiableOutputs == 0 {
+		return ErrNotModifiable
+	}
+
+	p.UnsignedTx.AddTxOut(wire.NewTxOut(txOut.Value, txOut.PkScript))
+	p.Outputs = append(p.Outputs, out)
+	return nil
+}
+
+// lockTime returns the locktime of a version 2 PSBT (BIP370): the fallback locktime when no input requires one,
+// otherwise the largest required locktime of the type every requiring input supports, height if both are
+func (p *Packet) lockTime() (uint32, error) {
+	required := false
+	heightOK, timeOK := true, true
+	var height, time uint32
+	for _, in := range p.Inputs {
+		if in.RequiredHeightLocktime == nil && in.RequiredTimeLocktime == nil {
+			continue
+		}
+		required = true
+
+		if in.RequiredHeightLocktime == nil {
+			heightOK = false
+		} else if *in.RequiredHeightLocktime > height {
+			height = *in.RequiredHeightLocktime
+		}
+
+		if in.RequiredTimeLocktime == nil {
+			timeOK = false
+		} else if *in.RequiredTimeLocktime > time {
+			time = *in.RequiredTimeLocktime
+		}
+	}
+
+	switch {
+	case !required && p.FallbackLocktime != nil:
+		return *p.FallbackLocktime, nil
+	case !required:
+		return 0, nil
+	case heightOK:
+		return height, nil
+	case timeOK:
+		return time, nil
+	}
+	return 0, ErrLockTimeConflict
+}
+
+// syncLockTime sets the locktime of the transaction of a version 2 PSBT from its fields
+func (p *Packet) syncLockTime() error {
+	if p.Version != 2 {
+		return nil
+	}
+
+	lockTime, err := p.lockTime()
+	if err != nil {
+		return err
+	}
+	p.UnsignedTx.LockTime = lockTime
+	return nil
+}
+
+// hasSignatures returns true if any input is signed or finalized
+func (p *Packet) hasSignatures() bool {
+	for _, in := range p.Inputs {
+		if len(in.PartialSigs) != 0 || in.TaprootKeySig != nil || in.IsFinalized() {
+			return true
+		}
+	}
+	return false
+}
+
+// updateModifiable clears the Modifiable flags a signature of hashType forbids (BIP370)
+func (p *Packet) updateModifiable(hashType txscript.SigHashType) {
+	if p.Version != 2 {
+		return
+	}
+
+	if hashType&txscript.SigHashAnyOneCanPay == 0 {
+		p.TxModifiable &^= ModifiableInputs
+	}
+
+	switch hashType &^ txscript.SigHashAnyOneCanPay {
+	case txscript.SigHashNone:
+	case txscript.SigHashSingle:
+		p.TxModifiable |= HasSigHashSingle
+	default:
+		p.TxModifiable &^= ModifiableOutputs
+	}
+}
+
+// parseTxField decodes a global version 2 field
+func (p *Packet) parseTxField(kv *keyValue, fields *txFields) error {
+	if err := kv.checkKeyLength(0); err != nil {
+		return err
+	}
+	fields.present = true
+
+	switch kv.keyType() {
+	case globalTxVersion:
+		v, err := parseUint32(kv.value)
+		if err != nil {
+			return err
+		}
+
+		txVersion := int32(v)
+		fields.txVersion = &txVersion
+	case globalFallbackLocktime:
+		lockTime, err := parseUint32(kv.value)
+		if err != nil {
+			return err
+		}
+		p.FallbackLocktime = &lockTime
+	case globalInputCount, globalOutputCount:
+		r := bytes.NewReader(kv.value)
+		count, err := wire.ReadVarInt(r, 0)
+		if err != nil || r.Len() != 0 {
+			return ErrInvalidValue
+		}
+
+		if kv.keyType() == globalInputCount {
+			fields.inputCount = &count
+		} else {
+			fields.outputCount = &count
+		}
+	case globalTxModifiable:
+		if len(kv.value) != 1 {
+			return ErrInvalidValue
+		}
+		p.TxModifiable = kv.value[0]
+	}
+	return nil
+}
+
+// writeTxFields writes the global version 2 fields
+func (p *Packet) writeTxFields(w *mapWriter) {
+	w.write([]byte{globalTxVersion}, uint32Bytes(uint32(p.UnsignedTx.Version)))
+	if p.FallbackLocktime != nil {
+		w.write([]byte{globalFallbackLocktime}, uint32Bytes(*p.FallbackLocktime))
+	}
+
+	w.write([]byte{globalInputCount}, varIntBytes(uint64(len(p.Inputs))))
+	w.write([]byte{globalOutputCount}, varIntBytes(uint64(len(p.Outputs))))
+	if p.TxModifiable != 0 {
+		w.write([]byte{globalTxModifiable}, []byte{p.TxModifiable})
+	}
+}
+
+// parseInputTxField decodes a version 2 input field into the input or the transaction input it describes
+func parseInputTxField(kv *keyValue, in *Input, txIn *wire.TxIn) error {
+	if err := kv.checkKeyLength(0); err != nil {
+		return err
+	}
+
+	if kv.keyType() == inputPreviousTxID {
+		if len(kv.value) != 32 {
+			return ErrInvalidValue
+		}
+		copy(txIn.PreviousOutPoint.Hash[:], kv.value)
+		return nil
+	}
+
+	v, err := parseUint32(kv.value)
+	if err != nil {
+		return err
+	}
+
+	switch kv.keyType() {
+	case inputOutputIndex:
+		txIn.PreviousOutPoint.Index = v
+	case inputSequence:
+		txIn.Sequence = v
+	case inputRequiredTimeLocktime:
+		if v < txscript.LockTimeThreshold {
+			return ErrInvalidValue
+		}
+		in.RequiredTimeLocktime = &v
+	case inputRequiredHeightLocktime:
+		if v == 0 || v >= txscript.LockTimeThreshold {
+			return ErrInvalidValue
+		}
+		in.RequiredHeightLocktime = &v
+	}
+	return nil
+}
+
+// writeTxFields writes the version 2 fields describing the transaction input of the input
+func (in *Input) writeTxFields(w *mapWriter, txIn *wire.TxIn) {
+	w.write([]byte{inputPreviousTxID}, txIn.PreviousOutPoint.Hash[:])
+	w.write([]byte{inputOutputIndex}, uint32Bytes(txIn.PreviousOutPoint.Index))
+	if txIn.Sequence != wire.MaxTxInSequenceNum {
+		w.write([]byte{inputSequence}, uint32Bytes(txIn.Sequence))
+	}
+
+	if in.RequiredTimeLocktime != nil {
+		w.write([]byte{inputRequiredTimeLocktime}, uint32Bytes(*in.RequiredTimeLocktime))
+	}
+
+	if in.RequiredHeightLocktime != nil {
+		w.write([]byte{inputRequiredHeightLocktime}, uint32Bytes(*in.RequiredHeightLocktime))
+	}
+}
+
+// parseOutputTxField decodes a version 2 output field into the transaction output it describes
+func parseOutputTxField(kv *keyValue, txOut *wire.TxOut) error {
+	if err := kv.checkKeyLength(0); err != nil {
+		return err
+	}
+
+	if kv.keyType() == outputScript {
+		txOut.PkScript = kv.value
+		return nil
+	}
+
+	if len(kv.value) != 8 {
+		return ErrInvalidValue
+	}
+	txOut.Value = int64(binary.LittleEndian.Uint64(kv.value))
+	return nil
+}
+
+// hasKey returns true if a map has an entry of keyType without key data
+func hasKey(kvs []*keyValue, keyType byte) bool {
+	for _, kv := range kvs {
+		if len(kv.key) == 1 && kv.keyType() == keyType {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUint32 decodes a 4 byte little-endian value
+func parseUint32(value []byte) (uint32, error) {
+	if len(value) != 4 {
+		return 0, ErrInvalidValue
+	}
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+// varIntBytes returns the compact size encoding of v
+func varIntBytes(v uint64) []byte {
+	var b bytes.Buffer
+	wire.WriteVarInt(&b, 0, v)
+	return b.Bytes()
+}
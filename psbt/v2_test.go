@@ -0,0 +1,538 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package psbt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+
+	"github.com/sanscentral/sanswallet/taproot"
+)
+
+func TestConvertVersions(t *testing.T) {
+	tx := testUnsignedTx(2)
+	tx.LockTime = 650000
+	tx.TxIn[1].Sequence = wire.MaxTxInSequenceNum - 2
+
+	p, err := New(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Inputs[0].WitnessUTXO = wire.NewTxOut(100000, tx.TxOut[0].PkScript)
+
+	v0, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.SetVersion(2); err != nil {
+		t.Fatal(err)
+	}
+
+	v2, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Version != 2 || parsed.FallbackLocktime == nil || *parsed.FallbackLocktime != 650000 {
+		t.Fatal("version 2 PSBT does not hold the fallback locktime")
+	}
+
+	if parsed.UnsignedTx.TxHash() != tx.TxHash() {
+		t.Error("version 2 PSBT does not describe the same transaction")
+	}
+
+	if in := parsed.UnsignedTx.TxIn[1]; in.Sequence != tx.TxIn[1].Sequence || in.PreviousOutPoint != tx.TxIn[1].PreviousOutPoint {
+		t.Error("version 2 input fields are not expected values")
+	}
+
+	if out := parsed.UnsignedTx.TxOut[0]; out.Value != 90000 || !bytes.Equal(out.PkScript, tx.TxOut[0].PkScript) {
+		t.Error("version 2 output fields are not expected values")
+	}
+
+	if again, _ := parsed.Serialize(); !bytes.Equal(again, v2) {
+		t.Error("version 2 PSBT did not serialize the same after parsing")
+	}
+
+	if err := parsed.SetVersion(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if again, _ := parsed.Serialize(); !bytes.Equal(again, v0) {
+		t.Error("version 2 PSBT did not convert back to the version 0 PSBT")
+	}
+
+	if err := parsed.SetVersion(1); err != ErrUnsupportedVersion {
+		t.Error("unsupported version did not fail where expected")
+	}
+}
+
+func TestLockTime(t *testing.T) {
+	height := func(v uint32) *uint32 { return &v }
+	p, err := New(testUnsignedTx(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.SetVersion(2); err != nil {
+		t.Fatal(err)
+	}
+
+	p.FallbackLocktime = height(100)
+	if lockTime, _ := p.lockTime(); lockTime != 100 {
+		t.Errorf("locktime without requirements is %d want the fallback", lockTime)
+	}
+
+	// Height is chosen when every requiring input supports it
+	p.Inputs[0].RequiredHeightLocktime = height(700000)
+	p.Inputs[0].RequiredTimeLocktime = height(1600000000)
+	p.Inputs[1].RequiredHeightLocktime = height(710000)
+	if lockTime, _ := p.lockTime(); lockTime != 710000 {
+		t.Errorf("locktime is %d want the largest height 710000", lockTime)
+	}
+
+	p.Inputs[2].RequiredTimeLocktime = height(1500000000)
+	if _, err := p.lockTime(); err != ErrLockTimeConflict {
+		t.Error("conflicting locktimes did not fail where expected")
+	}
+
+	p.Inputs[1].RequiredHeightLocktime = nil
+	p.Inputs[1].RequiredTimeLocktime = height(1550000000)
+	if lockTime, _ := p.lockTime(); lockTime != 1600000000 {
+		t.Errorf("locktime is %d want the largest time 1600000000", lockTime)
+	}
+
+	data, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.UnsignedTx.LockTime != 1600000000 || *parsed.Inputs[1].RequiredTimeLocktime != 1550000000 {
+		t.Errorf("parsed locktime is %d", parsed.UnsignedTx.LockTime)
+	}
+}
+
+func TestModifiable(t *testing.T) {
+	master := testMaster(t)
+	d, _ := testDerivation(t, master, 0)
+	script := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(d.PubKey)...)
+
+	p, funding := testFundedPacket(t, [][]byte{script, script})
+	p.Inputs[0].WitnessUTXO = funding.TxOut[0]
+	p.Inputs[0].Bip32Derivations = []*Derivation{d}
+
+	txIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 0), nil, nil)
+	if err := p.AddInput(txIn, &Input{}); err != ErrNotModifiable {
+		t.Error("input added to a version 0 PSBT where failure was expected")
+	}
+
+	if err := p.SetVersion(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.AddOutput(wire.NewTxOut(1000, script), &Output{}); err != ErrNotModifiable {
+		t.Error("output added without the modifiable flag where failure was expected")
+	}
+
+	p.TxModifiable = ModifiableInputs | ModifiableOutputs
+	if err := p.AddInput(txIn, &Input{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.AddOutput(wire.NewTxOut(1000, script), &Output{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A SIGHASH_ALL|ANYONECANPAY signature still lets inputs be added
+	hashType := txscript.SigHashAll | txscript.SigHashAnyOneCanPay
+	p.Inputs[0].SigHashType = &hashType
+	if n, err := p.SignWithMaster(master); err != nil || n != 1 {
+		t.Fatalf("master key signed %d inputs (%v)", n, err)
+	}
+
+	if p.TxModifiable != ModifiableInputs {
+		t.Errorf("modifiable flags after signing are %02x want %02x", p.TxModifiable, ModifiableInputs)
+	}
+
+	lockTime := uint32(800000)
+	if err := p.AddInput(txIn, &Input{RequiredHeightLocktime: &lockTime}); err != ErrNotModifiable {
+		t.Error("input changing the locktime of a signed PSBT was added where failure was expected")
+	}
+
+	if len(p.Inputs) != 3 || len(p.UnsignedTx.TxIn) != 3 {
+		t.Error("rejected input was not removed")
+	}
+
+	data, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed.UnsignedTx.TxIn) != 3 || len(parsed.UnsignedTx.TxOut) != 2 || parsed.TxModifiable != ModifiableInputs {
+		t.Error("modified version 2 PSBT did not parse with its inputs, outputs and flags")
+	}
+}
+
+func TestCombineVersion2(t *testing.T) {
+	packets := make([]*Packet, 2)
+	for i := range packets {
+		p, err := New(testUnsignedTx(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.SetVersion(2)
+		packets[i] = p
+	}
+
+	packets[0].TxModifiable = ModifiableInputs | ModifiableOutputs
+	packets[1].TxModifiable = ModifiableInputs | HasSigHashSingle
+
+	combined, err := Combine(packets...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if combined.TxModifiable != ModifiableInputs|HasSigHashSingle {
+		t.Errorf("combined modifiable flags are %02x", combined.TxModifiable)
+	}
+
+	v0, _ := New(testUnsignedTx(1))
+	if _, err := Combine(packets[0], v0); err != ErrVersionMismatch {
+		t.Error("PSBTs of different versions did not fail where expected")
+	}
+}
+
+func TestSignVersion2(t *testing.T) {
+	master := testMaster(t)
+	d0, _ := testDerivation(t, master, 0)
+	d1, pub := testDerivation(t, master, 1)
+
+	output, err := taproot.TweakPublicKey(pub, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d1.PubKey = taproot.XOnlyPubKey(pub)
+
+	scripts := [][]byte{
+		append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(d0.PubKey)...),
+		append([]byte{txscript.OP_1, txscript.OP_DATA_32}, taproot.XOnlyPubKey(output)...),
+	}
+
+	p, funding := testFundedPacket(t, scripts)
+	for i, in := range p.Inputs {
+		in.WitnessUTXO = funding.TxOut[i]
+	}
+	p.Inputs[0].Bip32Derivations = []*Derivation{d0}
+	p.Inputs[1].TaprootBip32Derivations = []*Derivation{d1}
+
+	v0, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.SetVersion(2)
+	v2, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txs := make([]*wire.MsgTx, 2)
+	for i, data := range [][]byte{v0, v2} {
+		p, err := Parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n, err := p.SignWithMaster(master); err != nil || n != 2 {
+			t.Fatalf("version %d PSBT signed %d inputs (%v)", p.Version, n, err)
+		}
+
+		if err := p.Finalize(); err != nil {
+			t.Fatal(err)
+		}
+
+		if txs[i], err = p.Extract(); err != nil {
+			t.Fatal(err)
+		}
+		verifyPacketInputs(t, txs[i], funding)
+	}
+
+	if txs[0].WitnessHash() != txs[1].WitnessHash() {
+		t.Error("version 0 and 2 PSBTs signed different transactions")
+	}
+}
+
+func TestParseInvalidVersion2(t *testing.T) {
+	p, err := New(testUnsignedTx(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetVersion(2)
+
+	valid, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Parse(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop each version 2 field the transaction needs
+	maps, err := readMaps(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for m, kvs := range maps {
+		for i, kv := range kvs {
+			if kv.keyType() == globalVersion && m == 0 {
+				continue
+			}
+
+			dropped := make([][]*keyValue, len(maps))
+			copy(dropped, maps)
+			dropped[m] = append(append([]*keyValue{}, kvs[:i]...), kvs[i+1:]...)
+
+			if _, err := Parse(testWriteMaps(dropped)); err != ErrMissingField {
+				t.Errorf("version 2 PSBT without map %d field %02x returned %v", m, kv.keyType(), err)
+			}
+		}
+	}
+
+	// Version 0 PSBTs must not have version 2 fields, nor version 2 PSBTs an unsigned transaction
+	var tx bytes.Buffer
+	testUnsignedTx(1).SerializeNoWitness(&tx)
+	withTx := append([][]*keyValue{}, maps...)
+	withTx[0] = append([]*keyValue{{key: []byte{globalUnsignedTx}, value: tx.Bytes()}}, maps[0]...)
+	if _, err := Parse(testWriteMaps(withTx)); err != ErrVersionField {
+		t.Error("version 2 PSBT with an unsigned transaction did not fail where expected")
+	}
+
+	v0, _ := New(testUnsignedTx(1))
+	v0Maps, _ := readMaps(mustSerialize(t, v0))
+	v0Maps[1] = append(v0Maps[1], &keyValue{key: []byte{inputSequence}, value: uint32Bytes(1)})
+	if _, err := Parse(testWriteMaps(v0Maps)); err != ErrVersionField {
+		t.Error("version 0 PSBT with a version 2 input field did not fail where expected")
+	}
+
+	withHeight := append([][]*keyValue{}, maps...)
+	withHeight[1] = append(append([]*keyValue{}, maps[1]...), &keyValue{key: []byte{inputRequiredHeightLocktime}, value: uint32Bytes(txscript.LockTimeThreshold)})
+	if _, err := Parse(testWriteMaps(withHeight)); err != ErrInvalidValue {
+		t.Error("height locktime above the time threshold did not fail where expected")
+	}
+}
+
+// testBIP370Maps returns the maps of the version 2 PSBT the BIP370 test vectors start from:
+// one input and two outputs, with fields in key type order
+func testBIP370Maps() [][]*keyValue {
+	txid := bytes.Repeat([]byte{0x0b}, chainhash.HashSize)
+	script := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, bytes.Repeat([]byte{0xc4}, 20)...)
+	return [][]*keyValue{
+		{
+			{key: []byte{globalTxVersion}, value: uint32Bytes(2)},
+			{key: []byte{globalInputCount}, value: []byte{1}},
+			{key: []byte{globalOutputCount}, value: []byte{2}},
+			{key: []byte{globalVersion}, value: uint32Bytes(2)},
+		},
+		{
+			{key: []byte{inputPreviousTxID}, value: txid},
+			{key: []byte{inputOutputIndex}, value: uint32Bytes(0)},
+		},
+		{
+			{key: []byte{outputAmount}, value: uint64Bytes(229128)},
+			{key: []byte{outputScript}, value: script},
+		},
+		{
+			{key: []byte{outputAmount}, value: uint64Bytes(199999883)},
+			{key: []byte{outputScript}, value: script},
+		},
+	}
+}
+
+// testWithField returns maps with kv inserted in map m before the first key of a larger type
+func testWithField(maps [][]*keyValue, m int, kv *keyValue) [][]*keyValue {
+	with := append([][]*keyValue{}, maps...)
+	kvs := make([]*keyValue, 0, len(maps[m])+1)
+	added := false
+	for _, existing := range maps[m] {
+		if !added && bytes.Compare(kv.key, existing.key) < 0 {
+			kvs = append(kvs, kv)
+			added = true
+		}
+		kvs = append(kvs, existing)
+	}
+
+	if !added {
+		kvs = append(kvs, kv)
+	}
+	with[m] = kvs
+	return with
+}
+
+// testWithoutField returns maps without the key of keyType in map m
+func testWithoutField(maps [][]*keyValue, m int, keyType byte) [][]*keyValue {
+	without := append([][]*keyValue{}, maps...)
+	without[m] = nil
+	for _, kv := range maps[m] {
+		if kv.keyType() != keyType {
+			without[m] = append(without[m], kv)
+		}
+	}
+	return without
+}
+
+// BIP370 test vector cases ref: https://github.com/bitcoin/bips/blob/master/bip-0370.mediawiki#test-vectors
+func TestBIP370Vectors(t *testing.T) {
+	base := testBIP370Maps()
+	field := func(keyType byte, value []byte) *keyValue {
+		return &keyValue{key: []byte{keyType}, value: value}
+	}
+
+	// withUnknown returns maps with a key of keyType with key data at the end of map m, where unknown keys are written
+	withUnknown := func(maps [][]*keyValue, m int, keyType byte) [][]*keyValue {
+		with := append([][]*keyValue{}, maps...)
+		with[m] = append(append([]*keyValue{}, maps[m]...), &keyValue{key: []byte{keyType, 0x01}, value: []byte{0x02}})
+		return with
+	}
+
+	v0, err := New(testUnsignedTx(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v0Maps, err := readMaps(mustSerialize(t, v0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := len(v0Maps) - 1
+
+	var tx bytes.Buffer
+	testUnsignedTx(1).SerializeNoWitness(&tx)
+
+	invalid := []struct {
+		name string
+		maps [][]*keyValue
+		err  error
+	}{
+		{"PSBTv0 with PSBT_GLOBAL_VERSION 2", testWithField(v0Maps, 0, field(globalVersion, uint32Bytes(2))), ErrVersionField},
+		{"PSBTv0 with PSBT_GLOBAL_TX_VERSION", testWithField(v0Maps, 0, field(globalTxVersion, uint32Bytes(2))), ErrVersionField},
+		{"PSBTv0 with PSBT_GLOBAL_FALLBACK_LOCKTIME", testWithField(v0Maps, 0, field(globalFallbackLocktime, uint32Bytes(0))), ErrVersionField},
+		{"PSBTv0 with PSBT_GLOBAL_INPUT_COUNT", testWithField(v0Maps, 0, field(globalInputCount, []byte{1})), ErrVersionField},
+		{"PSBTv0 with PSBT_GLOBAL_OUTPUT_COUNT", testWithField(v0Maps, 0, field(globalOutputCount, []byte{1})), ErrVersionField},
+		{"PSBTv0 with PSBT_GLOBAL_TX_MODIFIABLE", testWithField(v0Maps, 0, field(globalTxModifiable, []byte{0})), ErrVersionField},
+		{"PSBTv0 with PSBT_IN_PREVIOUS_TXID", testWithField(v0Maps, 1, field(inputPreviousTxID, make([]byte, chainhash.HashSize))), ErrVersionField},
+		{"PSBTv0 with PSBT_IN_OUTPUT_INDEX", testWithField(v0Maps, 1, field(inputOutputIndex, uint32Bytes(0))), ErrVersionField},
+		{"PSBTv0 with PSBT_IN_SEQUENCE", testWithField(v0Maps, 1, field(inputSequence, uint32Bytes(0xffffffff))), ErrVersionField},
+		{"PSBTv0 with PSBT_IN_REQUIRED_TIME_LOCKTIME", testWithField(v0Maps, 1, field(inputRequiredTimeLocktime, uint32Bytes(657624942))), ErrVersionField},
+		{"PSBTv0 with PSBT_IN_REQUIRED_HEIGHT_LOCKTIME", testWithField(v0Maps, 1, field(inputRequiredHeightLocktime, uint32Bytes(10000))), ErrVersionField},
+		{"PSBTv0 with PSBT_OUT_AMOUNT", testWithField(v0Maps, last, field(outputAmount, uint64Bytes(1000))), ErrVersionField},
+		{"PSBTv0 with PSBT_OUT_SCRIPT", testWithField(v0Maps, last, field(outputScript, []byte{txscript.OP_TRUE})), ErrVersionField},
+		{"PSBTv2 missing PSBT_GLOBAL_INPUT_COUNT", testWithoutField(base, 0, globalInputCount), ErrMissingField},
+		{"PSBTv2 missing PSBT_GLOBAL_OUTPUT_COUNT", testWithoutField(base, 0, globalOutputCount), ErrMissingField},
+		{"PSBTv2 missing PSBT_IN_PREVIOUS_TXID", testWithoutField(base, 1, inputPreviousTxID), ErrMissingField},
+		{"PSBTv2 missing PSBT_IN_OUTPUT_INDEX", testWithoutField(base, 1, inputOutputIndex), ErrMissingField},
+		{"PSBTv2 missing PSBT_OUT_AMOUNT", testWithoutField(base, 2, outputAmount), ErrMissingField},
+		{"PSBTv2 missing PSBT_OUT_SCRIPT", testWithoutField(base, 2, outputScript), ErrMissingField},
+		{"PSBTv2 with PSBT_IN_REQUIRED_TIME_LOCKTIME less than 500000000", testWithField(base, 1, field(inputRequiredTimeLocktime, uint32Bytes(txscript.LockTimeThreshold-1))), ErrInvalidValue},
+		{"PSBTv2 with PSBT_IN_REQUIRED_HEIGHT_LOCKTIME greater than or equal to 500000000", testWithField(base, 1, field(inputRequiredHeightLocktime, uint32Bytes(txscript.LockTimeThreshold))), ErrInvalidValue},
+		{"PSBTv2 with PSBT_GLOBAL_UNSIGNED_TX", testWithField(base, 0, field(globalUnsignedTx, tx.Bytes())), ErrVersionField},
+	}
+
+	for _, c := range invalid {
+		if _, err := Parse(testWriteMaps(c.maps)); err != c.err {
+			t.Errorf("%s returned %v want %v", c.name, err, c.err)
+		}
+	}
+
+	valid := []struct {
+		name string
+		maps [][]*keyValue
+	}{
+		{"1 input, 2 output PSBTv2, required fields only", base},
+		{"Updated with PSBT_IN_SEQUENCE", testWithField(base, 1, field(inputSequence, uint32Bytes(0xfffffffe)))},
+		{"Updated with PSBT_IN_REQUIRED_TIME_LOCKTIME", testWithField(base, 1, field(inputRequiredTimeLocktime, uint32Bytes(657624942)))},
+		{"Updated with PSBT_IN_REQUIRED_HEIGHT_LOCKTIME", testWithField(base, 1, field(inputRequiredHeightLocktime, uint32Bytes(10000)))},
+		{"Updated with both PSBT_IN_REQUIRED_TIME_LOCKTIME and PSBT_IN_REQUIRED_HEIGHT_LOCKTIME", testWithField(testWithField(base, 1,
+			field(inputRequiredTimeLocktime, uint32Bytes(657624942))), 1, field(inputRequiredHeightLocktime, uint32Bytes(10000)))},
+		{"PSBT_GLOBAL_FALLBACK_LOCKTIME set", testWithField(base, 0, field(globalFallbackLocktime, uint32Bytes(0)))},
+		{"PSBT_GLOBAL_TX_MODIFIABLE inputs modifiable", testWithField(base, 0, field(globalTxModifiable, []byte{ModifiableInputs}))},
+		{"PSBT_GLOBAL_TX_MODIFIABLE outputs modifiable", testWithField(base, 0, field(globalTxModifiable, []byte{ModifiableOutputs}))},
+		{"PSBT_GLOBAL_TX_MODIFIABLE has SIGHASH_SINGLE", testWithField(base, 0, field(globalTxModifiable, []byte{HasSigHashSingle}))},
+		{"PSBT_GLOBAL_TX_MODIFIABLE all flags", testWithField(base, 0, field(globalTxModifiable, []byte{ModifiableInputs | ModifiableOutputs | HasSigHashSingle}))},
+		{"PSBTv2 keys of version 2 types with key data are unknown", withUnknown(withUnknown(withUnknown(base, 0, globalInputCount), 1, inputOutputIndex), 2, outputAmount)},
+		{"PSBTv0 keys of version 2 types with key data are unknown", withUnknown(withUnknown(withUnknown(v0Maps, 0, globalInputCount), 1, inputPreviousTxID), last, outputScript)},
+	}
+
+	for _, c := range valid {
+		data := testWriteMaps(c.maps)
+		p, err := Parse(data)
+		if err != nil {
+			t.Errorf("%s did not parse: %s", c.name, err.Error())
+			continue
+		}
+
+		if again, err := p.Serialize(); err != nil || !bytes.Equal(data, again) {
+			t.Errorf("%s serialized again differs", c.name)
+		}
+	}
+}
+
+// testWriteMaps serializes raw maps as a PSBT
+func testWriteMaps(maps [][]*keyValue) []byte {
+	var b bytes.Buffer
+	b.Write(magic)
+	w := &mapWriter{b: &b}
+	for _, kvs := range maps {
+		for _, kv := range kvs {
+			w.write(kv.key, kv.value)
+		}
+		w.end()
+	}
+	return b.Bytes()
+}
+
+// mustSerialize returns the binary encoding of a PSBT
+func mustSerialize(t *testing.T, p *Packet) []byte {
+	data, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
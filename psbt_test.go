@@ -0,0 +1,284 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/sanscentral/sanswallet/psbt"
+)
+
+// testFundingTx returns a transaction paying amounts to the external addresses of an account from index 0
+func testFundingTx(t *testing.T, account *Account, amounts ...int64) *wire.MsgTx {
+	tx := wire.NewMsgTx(txVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	for i, amount := range amounts {
+		address, err := account.Address(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		script, err := account.addressScript(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.AddTxOut(wire.NewTxOut(amount, script))
+	}
+	return tx
+}
+
+// testFundingUTXOs returns the outputs of a funding transaction as UTXOs including the previous transaction
+func testFundingUTXOs(funding *wire.MsgTx) []*UTXO {
+	var b bytes.Buffer
+	funding.Serialize(&b)
+
+	utxos := make([]*UTXO, len(funding.TxOut))
+	for i, out := range funding.TxOut {
+		utxos[i] = &UTXO{TxID: funding.TxHash().String(), Vout: i, Amount: out.Value, AddressIndex: i, PrevTxHex: hex.EncodeToString(b.Bytes())}
+	}
+	return utxos
+}
+
+func TestCreateAndSignPSBT(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipients := []*Recipient{{testP2PK1, 30000}, {testP2WPKH10, 40000}}
+	for _, purpose := range []int{44, 49, 84, 86} {
+		account, err := NewAccountFromSeed(seed, purpose, 0, testIsTestnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		funding := testFundingTx(t, account, 60000, 45000)
+		utxos := testFundingUTXOs(funding)
+
+		unsigned, err := account.CreatePSBT(utxos, recipients, testFeeRate, 4)
+		if err != nil {
+			t.Errorf("purpose %d PSBT failed: %s", purpose, err.Error())
+			continue
+		}
+
+		p, err := psbt.ParseBase64(unsigned.PSBT)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		in := p.Inputs[0]
+		derivations := in.Bip32Derivations
+		if purpose == 86 {
+			derivations = in.TaprootBip32Derivations
+		}
+
+		if len(derivations) != 1 || derivations[0].Fingerprint != 0x73c5da0a || derivations[0].Path[0] != 0x80000000+uint32(purpose) {
+			t.Errorf("purpose %d input derivation is not the account key origin", purpose)
+		}
+
+		if n := len(p.Outputs[2].Bip32Derivations) + len(p.Outputs[2].TaprootBip32Derivations); n != 1 || unsigned.ChangeIndex != 4 {
+			t.Errorf("purpose %d change output has %d derivations", purpose, n)
+		}
+
+		signed, err := SignPSBTWithSeed(unsigned.PSBT, seed)
+		if err != nil {
+			t.Errorf("purpose %d PSBT signing failed: %s", purpose, err.Error())
+			continue
+		}
+
+		txHex, err := ExtractPSBTTransaction(signed)
+		if err != nil {
+			t.Errorf("purpose %d PSBT extraction failed: %s", purpose, err.Error())
+			continue
+		}
+
+		// Deterministic signatures make the PSBT transaction the one BuildTransaction signs
+		built, err := account.BuildTransaction(utxos, recipients, testFeeRate, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if txHex != built.Hex || unsigned.Fee != built.Fee {
+			t.Errorf("purpose %d PSBT transaction is not the built transaction\n%s\n%s", purpose, txHex, built.Hex)
+		}
+	}
+}
+
+func TestSignPSBTWithAccountKey(t *testing.T) {
+	watchOnly, err := NewAccountFromExtendedKey(testP2WPKHPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	funding := testFundingTx(t, watchOnly, 80000)
+	utxos := testFundingUTXOs(funding)
+	unsigned, err := watchOnly.CreatePSBT(utxos, []*Recipient{{testP2WPKH10, 50000}}, testFeeRate, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := watchOnly.SignPSBT(unsigned.PSBT); err == nil {
+		t.Error("watch-only account signed where failure was expected")
+	}
+
+	// Without a key origin the derivations are relative to the account key, the seed cannot match them
+	seed, _ := hex.DecodeString(testSeedHex)
+	if _, err := SignPSBTWithSeed(unsigned.PSBT, seed); err != ErrNoPSBTSignatures {
+		t.Error("seed signed a PSBT without its fingerprint where failure was expected")
+	}
+
+	account, err := NewAccountFromExtendedKey(testP2WPKHPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := account.SignPSBT(unsigned.PSBT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	combined, err := CombinePSBT([]string{unsigned.PSBT, signed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finalized, err := FinalizePSBT(combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txHex, err := ExtractPSBTTransaction(finalized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := hex.DecodeString(txHex)
+	tx := wire.NewMsgTx(0)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyInput(tx, funding.TxOut, 0); err != nil {
+		t.Errorf("PSBT transaction does not verify: %s", err.Error())
+	}
+
+	if _, err := FinalizePSBT(unsigned.PSBT); err != psbt.ErrIncomplete {
+		t.Error("unsigned PSBT did not fail to finalize where expected")
+	}
+}
+
+func TestPSBTVersion2(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	account, err := NewAccountFromSeed(seed, 86, 0, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	utxos := testFundingUTXOs(testFundingTx(t, account, 70000))
+	unsigned, err := account.CreatePSBT(utxos, []*Recipient{{testP2WPKH10, 50000}}, testFeeRate, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2, err := ConvertPSBT(unsigned.PSBT, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p, err := psbt.ParseBase64(v2); err != nil || p.Version != 2 {
+		t.Fatalf("converted PSBT is not version 2 (%v)", err)
+	}
+
+	signed, err := account.SignPSBT(v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromV2, err := ExtractPSBTTransaction(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing either version gives the same transaction
+	signedV0, err := SignPSBTWithSeed(unsigned.PSBT, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromV0, err := ExtractPSBTTransaction(signedV0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fromV0 != fromV2 {
+		t.Errorf("version 2 PSBT transaction is not the version 0 one\n%s\n%s", fromV2, fromV0)
+	}
+
+	back, err := ConvertPSBT(v2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if back != unsigned.PSBT {
+		t.Error("PSBT converted to version 2 and back is not the original PSBT")
+	}
+
+	if _, err := ConvertPSBT(v2, 1); err != psbt.ErrUnsupportedVersion {
+		t.Error("unsupported PSBT version did not fail where expected")
+	}
+
+	if _, err := CombinePSBT([]string{v2, unsigned.PSBT}); err != psbt.ErrVersionMismatch {
+		t.Error("PSBTs of different versions combined where failure was expected")
+	}
+}
+
+func TestCreatePSBTPrevTx(t *testing.T) {
+	account, err := NewAccountFromExtendedKey(testP2PKHPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	funding := testFundingTx(t, account, 80000, 20000)
+	utxos := testFundingUTXOs(funding)[:1]
+	recipients := []*Recipient{{testP2WPKH10, 50000}}
+
+	if _, err := account.CreatePSBT(utxos, recipients, testFeeRate, 0); err != nil {
+		t.Error(err.Error())
+	}
+
+	utxos[0].Vout = 1
+	if _, err := account.CreatePSBT(utxos, recipients, testFeeRate, 0); err != ErrPrevTxMismatch {
+		t.Error("previous transaction with another output did not fail where expected")
+	}
+
+	utxos[0].Vout = 0
+	utxos[0].PrevTxHex = ""
+	if _, err := account.CreatePSBT(utxos, recipients, testFeeRate, 0); err != ErrMissingPrevTx {
+		t.Error("P2PKH UTXO without previous transaction did not fail where expected")
+	}
+}
@@ -0,0 +1,110 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// restorePurposes are the BIP purposes searched for accounts when restoring a seed, in result order
+var restorePurposes = []uint32{keys.BIP44Purpose, keys.BIP49Purpose, keys.BIP84Purpose, keys.BIP86Purpose}
+
+// RestoredAccount is an account with on-chain history found when restoring a seed
+type RestoredAccount struct {
+	Purpose      int
+	AccountIndex int
+	Account      *Account
+
+	// Scan holds the used addresses and next unused indexes of the account
+	Scan *ScanResult
+}
+
+// RestoreResult summarises the used accounts of a seed
+type RestoreResult struct {
+	// Accounts are the used accounts ordered by purpose (44, 49, 84, 86) then account index
+	Accounts []*RestoredAccount
+}
+
+// ForScriptType returns the used accounts of a script type, e.g. keys.ScriptP2WPKH for BIP84 accounts
+func (r *RestoreResult) ForScriptType(scriptType keys.ScriptType) []*RestoredAccount {
+	var accounts []*RestoredAccount
+	for _, a := range r.Accounts {
+		if a.Account.ScriptType() == scriptType {
+			accounts = append(accounts, a)
+		}
+	}
+	return accounts
+}
+
+// RestoreAccounts finds the used mainnet or testnet accounts of a seed, see RestoreAccountsOnNetwork
+func RestoreAccounts(seed []byte, backend ChainBackend, gapLimit int, testnet bool) (*RestoreResult, error) {
+	return RestoreAccountsOnNetwork(seed, backend, gapLimit, testnetToNetwork(testnet))
+}
+
+// RestoreAccountsOnNetwork finds the used accounts of a seed on net by BIP44 account discovery.
+// For each purpose the network supports, accounts 0, 1, ... are scanned with gapLimit (0 for DefaultGapLimit)
+// until one has no history, which ends the search for that purpose
+func RestoreAccountsOnNetwork(seed []byte, backend ChainBackend, gapLimit int, net network.Network) (*RestoreResult, error) {
+	if gapLimit < 0 {
+		return nil, ErrInvalidGapLimit
+	}
+
+	def, err := network.GetDefinition(net)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := keys.GetExtendedMasterPrivateKeyFromSeedBytes(seed, net)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RestoreResult{}
+	for _, purpose := range restorePurposes {
+		scriptType := purposeScriptTypes[purpose]
+		if !def.SupportsScriptType(scriptType) {
+			continue
+		}
+
+		for index := uint32(0); index < keys.HardenedKeyZeroIndex; index++ {
+			account, err := newAccountFromMaster(m, purpose, scriptType, index, net)
+			if err != nil {
+				return nil, err
+			}
+
+			scan, err := account.Scan(backend, gapLimit)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(scan.Used) == 0 {
+				break
+			}
+
+			result.Accounts = append(result.Accounts, &RestoredAccount{
+				Purpose:      int(purpose),
+				AccountIndex: int(index),
+				Account:      account,
+				Scan:         scan,
+			})
+		}
+	}
+	return result, nil
+}
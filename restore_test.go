@@ -0,0 +1,110 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+func TestRestoreAccounts(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := NewMemoryChainBackend()
+	backend.AddTransaction(testP2PK0, "tx-44-0")
+
+	used := []struct {
+		accountIndex int
+		addressIndex int
+		isChange     bool
+	}{{0, 5, false}, {1, 0, true}, {3, 0, false}}
+
+	for _, u := range used {
+		account, err := NewAccountFromSeed(seed, 84, u.accountIndex, testIsTestnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		address, err := account.AddressRange(u.addressIndex, 1, u.isChange, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		backend.AddTransaction(address[0], "tx-84")
+	}
+
+	result, err := RestoreAccounts(seed, backend, 0, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Account 3 is not found as account 2 has no history
+	expected := []struct {
+		purpose      int
+		accountIndex int
+	}{{44, 0}, {84, 0}, {84, 1}}
+
+	if len(result.Accounts) != len(expected) {
+		t.Fatalf("restore found %d accounts want %d", len(result.Accounts), len(expected))
+	}
+
+	for i, e := range expected {
+		a := result.Accounts[i]
+		if a.Purpose != e.purpose || a.AccountIndex != e.accountIndex {
+			t.Errorf("restored account %d is %d/%d want %d/%d", i, a.Purpose, a.AccountIndex, e.purpose, e.accountIndex)
+		}
+	}
+
+	if pub, _ := result.Accounts[1].Account.ExtendedPublicKey(); pub != testP2WPKHPub {
+		t.Errorf("restored BIP84 account key is not expected value want %s got %s", testP2WPKHPub, pub)
+	}
+
+	if next := result.Accounts[1].Scan.NextExternalIndex; next != 6 {
+		t.Errorf("restored BIP84 account next index is %d want 6", next)
+	}
+
+	if n := len(result.ForScriptType(keys.ScriptP2WPKH)); n != 2 {
+		t.Errorf("restore found %d P2WPKH accounts want 2", n)
+	}
+
+	if n := len(result.ForScriptType(keys.ScriptP2TR)); n != 0 {
+		t.Errorf("restore found %d P2TR accounts want 0", n)
+	}
+
+	// Dogecoin only has BIP44 accounts, so an unused seed costs one account scan
+	counting := &countingBackend{MemoryChainBackend: NewMemoryChainBackend()}
+	result, err = RestoreAccountsOnNetwork(seed, counting, 0, network.DOGEMainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Accounts) != 0 || counting.queries != 2*DefaultGapLimit {
+		t.Errorf("unused Dogecoin restore found %d accounts after %d queries", len(result.Accounts), counting.queries)
+	}
+
+	if _, err := RestoreAccounts(seed, backend, -1, testIsTestnet); err != ErrInvalidGapLimit {
+		t.Error("negative gap limit did not fail where expected")
+	}
+}
@@ -21,11 +21,15 @@ package sanswallet
 import (
 	"encoding/hex"
 	"testing"
+
+	"github.com/sanscentral/sanswallet/mnemonic"
+	"github.com/sanscentral/sanswallet/network"
 )
 
 const (
 	// Seed : mnemonic = abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about
-	testSeedHex = "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"
+	testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	testSeedHex  = "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"
 
 	// useful Test reference: https://iancoleman.io/bip39/
 	// P2PK BIP44
@@ -52,6 +56,42 @@ const (
 	testP2WPKHPriv = "zprvAdG4iTXWBoARxkkzNpNh8r6Qag3irQB8PzEMkAFeTRXxHpbF9z4QgEvBRmfvqWvGp42t42nvgGpNgYSJA9iefm1yYNZKEm7z6qUWCroSQnE"
 	testP2WPKHPub  = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
 
+	// P2TR BIP86 ref: https://github.com/bitcoin/bips/blob/master/bip-0086.mediawiki#test-vectors
+	testP2TR0       = "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+	testP2TR1       = "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh"
+	testP2TRChange0 = "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7"
+
+	testP2TRPriv = "xprv9xgqHN7yz9MwCkxsBPN5qetuNdQSUttZNKw1dcYTV4mkaAFiBVGQziHs3NRSWMkCzvgjEe3n9xV8oYywvM8at9yRqyaZVz6TYYhX98VjsUk"
+	testP2TRPub  = "xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ"
+
+	// Testnet P2SH BIP49 (SLIP-132 uprv/upub)
+	testTestnetP2SH0    = "2My47gHNc8nhX5kBWqXHU4f8uuQvQKEgwMd"
+	testTestnetP2SH1    = "2NCSZrX49HHyzUy6oj8ggm9WD19hFvjzzou"
+	testTestnetP2SHPriv = "uprv8zce6RjwEBG8EVS4wuwEmgBuYT3KRE82EhAcZxnHAKwPM2q62pMiUA1mNqqMrevRX95rPyVWM6CQZ3aEESCjgR8y6uRtB1e6BSApsP5qyCQ"
+	testTestnetP2SHPub  = "upub5DbzVwGq4YpRSyWY3wUF8p8e6Usopgqsbv6DNMBtifUNDqAEaMfy1xLFE7fmL1W2zDFmBT9d9YXwbxgznndu6g7mPJGJG12MDaJp6j9WNDJ"
+
+	// Testnet P2WPKH BIP84 (SLIP-132 vprv/vpub)
+	testTestnetP2WPKH0    = "tb1qcr8te4kr609gcawutmrza0j4xv80jy8zmfp6l0"
+	testTestnetP2WPKH1    = "tb1qnjg0jd8228aq7egyzacy8cys3knf9xvrn9d67m"
+	testTestnetP2WPKHPriv = "vprv9Kw1Vnqqb4zWZZzX3PECJViPtoTw5vD8jY9Ucag6wQ2S5RLL9MQABzHdLwqaqtJbBVZf48QgqdQB9Pz3HN4bUpHa51mcu7r31wDveZokZ6z"
+	testTestnetP2WPKHPub  = "vpub5YvMuJNjRSYon44z9QmCfdf8SqJRVNvz6m55Qy5iVjZQxDfUgtiQjnc7CC1fAbED2tAGCZRERUfvtn2DstZGU6HMns6dXXH2wujSc2wfi2x"
+
+	// BIP49 testnet vector ref: https://github.com/bitcoin/bips/blob/master/bip-0049.mediawiki#test-vectors
+	// Account 0 key (m/49'/1'/0') converted from tprv to uprv version bytes
+	testBIP49VectorAccountPriv = "uprv91G7gZkzehuMVxDJTYE6tLivdF8e4rvzSu1LFfKw3b2Qx1Aj8vpoFnHdfUZ3hmi9jsvPifmZ24RTN2KhwB8BfMLTVqaBReibyaFFcTP1s9n"
+	testBIP49VectorAddress0    = "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2"
+
+	// Regtest addresses of the same BIP84/BIP86 keys (bcrt segwit prefix)
+	testRegtestP2WPKH0 = "bcrt1qcr8te4kr609gcawutmrza0j4xv80jy8zeqchgx"
+	testRegtestP2WPKH1 = "bcrt1qnjg0jd8228aq7egyzacy8cys3knf9xvr3v5hfj"
+	testRegtestP2TR0   = "bcrt1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqvg32hk"
+
+	// Litecoin (coin type 2) and Dogecoin (coin type 3) ref: https://iancoleman.io/bip39/
+	testLTCP2PKH0  = "LUWPbpM43E2p7ZSh8cyTBEkvpHmr3cB8Ez"
+	testLTCP2SH0   = "M7wtsL7wSHDBJVMWWhtQfTMSYYkyooAAXM"
+	testLTCP2WPKH0 = "ltc1qjmxnz78nmc8nq77wuxh25n2es7rzm5c2rkk4wh"
+	testDOGEP2PKH0 = "DBus3bamQjgJULBJtYXpEzDWQRwF5iwxgC"
+
 	testIsChangeAddress = false
 	testIsTestnet       = false
 )
@@ -256,3 +296,345 @@ func TestP2PKHAddressGeneration(t *testing.T) {
 		t.Errorf("test P2PKH address 10 is not expected value want %s got %s", testP2PK10, address10)
 	}
 }
+
+func TestP2TRKeyExport(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	priv, err := GetExtPrvForP2TRAccount(seed, 0, testIsTestnet)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if priv != testP2TRPriv {
+		t.Errorf("test extended P2TR private key export is not expected value want\n%s \ngot \n%s", testP2TRPriv, priv)
+	}
+
+	pub, err := GetExtPubForP2TRAccount(seed, 0, testIsTestnet)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if pub != testP2TRPub {
+		t.Errorf("test extended P2TR public key export is not expected value want\n%s \ngot \n%s", testP2TRPub, pub)
+	}
+
+	np, err := GetP2TRAddressForIndex(pub, 0, false, false)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if np != testP2TR0 {
+		t.Errorf("test extended P2TR public key did not result in expected address")
+	}
+
+	tpub, err := GetExtPubForP2TRAccount(seed, 0, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	ta, err := GetP2TRAddressForIndex(tpub, 0, false, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if ta[:4] != "tb1p" {
+		t.Errorf("testnet P2TR address does not start with tb1p, got %s", ta)
+	}
+}
+
+func TestP2TRAddressGeneration(t *testing.T) {
+	addresses := []struct {
+		index    int
+		isChange bool
+		expected string
+	}{
+		{0, false, testP2TR0},
+		{1, false, testP2TR1},
+		{0, true, testP2TRChange0},
+	}
+
+	for _, a := range addresses {
+		address, err := GetP2TRAddressForIndex(testP2TRPriv, a.index, a.isChange, testIsTestnet)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		if address != a.expected {
+			t.Errorf("test P2TR address %d (change %t) is not expected value want %s got %s", a.index, a.isChange, a.expected, address)
+		}
+	}
+
+	if _, err := GetP2TRAddressForIndex(testP2WPKHPub, 0, testIsChangeAddress, testIsTestnet); err == nil {
+		t.Error("P2WPKH key did not fail for P2TR address where expected")
+	}
+}
+
+func TestMnemonicSeedKeyExport(t *testing.T) {
+	seed, err := mnemonic.GetSeedFromMnemonic(testMnemonic, "")
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if hex.EncodeToString(seed) != testSeedHex {
+		t.Errorf("test mnemonic seed is not expected value want\n%s \ngot \n%x", testSeedHex, seed)
+	}
+
+	priv, err := GetExtPrvForP2WPKHAccount(seed, 0, testIsTestnet)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if priv != testP2WPKHPriv {
+		t.Errorf("test extended P2WPKH private key from mnemonic is not expected value want\n%s \ngot \n%s", testP2WPKHPriv, priv)
+	}
+}
+
+func TestTestnetP2SHKeyExport(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	priv, err := GetExtPrvForP2SHAccount(seed, 0, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if priv != testTestnetP2SHPriv {
+		t.Errorf("test extended testnet P2SH private key export is not expected value want\n%s \ngot \n%s", testTestnetP2SHPriv, priv)
+	}
+
+	pub, err := GetExtPubForP2SHAccount(seed, 0, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if pub != testTestnetP2SHPub {
+		t.Errorf("test extended testnet P2SH public key export is not expected value want\n%s \ngot \n%s", testTestnetP2SHPub, pub)
+	}
+
+	for i, expected := range []string{testTestnetP2SH0, testTestnetP2SH1} {
+		for _, k := range []string{priv, pub} {
+			a, err := GetP2SHAddressForIndex(k, i, testIsChangeAddress, true)
+			if err != nil {
+				t.Error(err.Error())
+			}
+
+			if a != expected {
+				t.Errorf("test testnet P2SH address %d is not expected value want %s got %s", i, expected, a)
+			}
+		}
+	}
+
+	a, err := GetP2SHAddressForIndex(testBIP49VectorAccountPriv, 0, testIsChangeAddress, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if a != testBIP49VectorAddress0 {
+		t.Errorf("BIP49 test vector address is not expected value want %s got %s", testBIP49VectorAddress0, a)
+	}
+}
+
+func TestTestnetP2WPKHKeyExport(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	priv, err := GetExtPrvForP2WPKHAccount(seed, 0, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if priv != testTestnetP2WPKHPriv {
+		t.Errorf("test extended testnet P2WPKH private key export is not expected value want\n%s \ngot \n%s", testTestnetP2WPKHPriv, priv)
+	}
+
+	pub, err := GetExtPubForP2WPKHAccount(seed, 0, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if pub != testTestnetP2WPKHPub {
+		t.Errorf("test extended testnet P2WPKH public key export is not expected value want\n%s \ngot \n%s", testTestnetP2WPKHPub, pub)
+	}
+
+	for i, expected := range []string{testTestnetP2WPKH0, testTestnetP2WPKH1} {
+		for _, k := range []string{priv, pub} {
+			a, err := GetP2WPKHAddressForIndex(k, i, testIsChangeAddress, true)
+			if err != nil {
+				t.Error(err.Error())
+			}
+
+			if a != expected {
+				t.Errorf("test testnet P2WPKH address %d is not expected value want %s got %s", i, expected, a)
+			}
+		}
+	}
+}
+
+func TestRegtestAndSignetAddresses(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	pub, err := GetExtPubForP2WPKHAccountOnNetwork(seed, 0, network.BTCRegtest)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	// Regtest and signet keys use the testnet versions
+	if pub != testTestnetP2WPKHPub {
+		t.Errorf("regtest P2WPKH public key is not expected value want\n%s \ngot \n%s", testTestnetP2WPKHPub, pub)
+	}
+
+	addresses := []struct {
+		address  func() (string, error)
+		expected string
+	}{
+		{func() (string, error) { return GetP2WPKHAddressForIndexOnNetwork(pub, 0, false, network.BTCRegtest) }, testRegtestP2WPKH0},
+		{func() (string, error) { return GetP2WPKHAddressForIndexOnNetwork(pub, 1, false, network.BTCRegtest) }, testRegtestP2WPKH1},
+		{func() (string, error) { return GetP2WPKHAddressForIndexOnNetwork(pub, 0, false, network.BTCSignet) }, testTestnetP2WPKH0},
+		{func() (string, error) {
+			return GetP2SHAddressForIndexOnNetwork(testTestnetP2SHPub, 1, false, network.BTCSignet)
+		}, testTestnetP2SH1},
+		{func() (string, error) {
+			return GetP2SHAddressForIndexOnNetwork(testTestnetP2SHPub, 0, false, network.BTCRegtest)
+		}, testTestnetP2SH0},
+		{func() (string, error) {
+			tpub, err := GetExtPubForP2TRAccountOnNetwork(seed, 0, network.BTCRegtest)
+			if err != nil {
+				return "", err
+			}
+			return GetP2TRAddressForIndexOnNetwork(tpub, 0, false, network.BTCRegtest)
+		}, testRegtestP2TR0},
+	}
+
+	for i, a := range addresses {
+		address, err := a.address()
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		if address != a.expected {
+			t.Errorf("address %d is not expected value want %s got %s", i, a.expected, address)
+		}
+	}
+
+	if _, err := GetP2WPKHAddressForIndexOnNetwork(testP2WPKHPub, 0, false, network.BTCRegtest); err == nil {
+		t.Error("mainnet key did not fail for regtest address where expected")
+	}
+
+	if _, err := GetP2WPKHAddressForIndexOnNetwork(pub, 0, false, network.Network(99)); err == nil {
+		t.Error("unknown network did not fail where expected")
+	}
+
+	account, err := NewAccountFromExtendedKeyOnNetwork(pub, network.BTCRegtest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a, _ := account.Address(1); a != testRegtestP2WPKH1 || account.Network() != network.BTCRegtest || !account.IsTestnet() {
+		t.Errorf("regtest account address is not expected value, got %s", a)
+	}
+
+	if _, err := NewAccountFromExtendedKeyOnNetwork(pub, network.BTCMainnet); err == nil {
+		t.Error("testnet key did not fail for mainnet account where expected")
+	}
+}
+
+func TestLitecoinAndDogecoinAddresses(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	accounts := []struct {
+		purpose  int
+		net      network.Network
+		prefix   string
+		expected string
+	}{
+		{44, network.LTCMainnet, "Ltub", testLTCP2PKH0},
+		{49, network.LTCMainnet, "Mtub", testLTCP2SH0},
+		{84, network.LTCMainnet, "zpub", testLTCP2WPKH0},
+		{44, network.DOGEMainnet, "dgub", testDOGEP2PKH0},
+	}
+
+	for _, a := range accounts {
+		account, err := NewAccountFromSeedOnNetwork(seed, a.purpose, 0, a.net)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		address, err := account.Address(0)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if address != a.expected {
+			t.Errorf("%s BIP%d address is not expected value want %s got %s", a.net, a.purpose, a.expected, address)
+		}
+
+		pub, err := account.ExtendedPublicKey()
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if pub[:4] != a.prefix {
+			t.Errorf("%s BIP%d account key does not start with %s, got %s", a.net, a.purpose, a.prefix, pub[:4])
+		}
+
+		// The exported key must produce the same address through the network aware API
+		fromKey, err := NewAccountFromExtendedKeyOnNetwork(pub, a.net)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		if address, _ := fromKey.Address(0); address != a.expected {
+			t.Errorf("%s BIP%d address from account key is not expected value want %s got %s", a.net, a.purpose, a.expected, address)
+		}
+	}
+
+	if _, err := NewAccountFromSeedOnNetwork(seed, 84, 0, network.DOGEMainnet); err != ErrUnsupportedScriptType {
+		t.Error("dogecoin segwit account did not fail where expected")
+	}
+
+	if _, err := NewAccountFromSeedOnNetwork(seed, 86, 0, network.LTCMainnet); err != ErrUnsupportedScriptType {
+		t.Error("litecoin taproot account did not fail where expected")
+	}
+}
+
+func TestAccountKeyVersionMismatch(t *testing.T) {
+	// Keys must match both the script type and the network of the address function
+	if _, err := GetP2SHAddressForIndex(testTestnetP2SHPub, 0, testIsChangeAddress, false); err == nil {
+		t.Error("testnet P2SH key did not fail for mainnet address where expected")
+	}
+
+	if _, err := GetP2WPKHAddressForIndex(testP2WPKHPub, 0, testIsChangeAddress, true); err == nil {
+		t.Error("mainnet P2WPKH key did not fail for testnet address where expected")
+	}
+
+	if _, err := GetP2WPKHAddressForIndex(testP2SHPub, 0, testIsChangeAddress, false); err == nil {
+		t.Error("P2SH key did not fail for P2WPKH address where expected")
+	}
+
+	if _, err := GetP2PKHAddressForIndex(testP2WPKHPub, 0, testIsChangeAddress, false); err == nil {
+		t.Error("P2WPKH key did not fail for P2PKH address where expected")
+	}
+
+	for _, k := range []string{"", "xpu", testP2PKHPub[:len(testP2PKHPub)-1] + "x"} {
+		if _, err := GetP2PKHAddressForIndex(k, 0, testIsChangeAddress, false); err == nil {
+			t.Errorf("malformed key %q did not fail where expected", k)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"errors"
+
+	"github.com/sanscentral/sanswallet/keys"
+)
+
+// DefaultGapLimit is the BIP44 number of consecutive unused addresses after which a chain is considered unused
+const DefaultGapLimit = 20
+
+// scanWorkers is the number of goroutines deriving each batch of scanned addresses
+const scanWorkers = 4
+
+// ErrInvalidGapLimit is returned for a negative gap limit
+var ErrInvalidGapLimit = errors.New("Gap limit must not be negative")
+
+// ChainBackend is the source of address history for scans, e.g. an Electrum server, block explorer or local node
+type ChainBackend interface {
+	// AddressHistory returns the ids of the transactions paying to or spending from address, none for an unused address
+	AddressHistory(address string) ([]string, error)
+}
+
+// UsedAddress is an account address with on-chain history
+type UsedAddress struct {
+	Address  string
+	Index    int
+	IsChange bool
+
+	// TxIDs are the transactions of the address as returned by the backend
+	TxIDs []string
+}
+
+// ScanResult lists the used addresses of an account and the first unused index after the last used one of each chain
+type ScanResult struct {
+	// Used holds the used receive addresses by index, followed by the used change addresses
+	Used []*UsedAddress
+
+	NextExternalIndex int
+	NextChangeIndex   int
+}
+
+// Scan walks the receive and change chains of the account, querying backend for the history of each address
+// until gapLimit consecutive addresses are unused. A gapLimit of 0 uses DefaultGapLimit.
+// Accounts created from a /1/* descriptor only have their change chain scanned
+func (a *Account) Scan(backend ChainBackend, gapLimit int) (*ScanResult, error) {
+	if gapLimit < 0 {
+		return nil, ErrInvalidGapLimit
+	}
+
+	if gapLimit == 0 {
+		gapLimit = DefaultGapLimit
+	}
+
+	var external []*UsedAddress
+	var nextExternal int
+	if a.chain == keys.ExternalAddress {
+		var err error
+		external, nextExternal, err = a.scanChain(backend, gapLimit, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	change, nextChange, err := a.scanChain(backend, gapLimit, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScanResult{
+		Used:              append(external, change...),
+		NextExternalIndex: nextExternal,
+		NextChangeIndex:   nextChange,
+	}, nil
+}
+
+// scanChain returns the used addresses of the receive or change chain and the index after the last used one.
+// Addresses are derived gapLimit at a time, so a batch always covers the gap that would end the scan
+func (a *Account) scanChain(backend ChainBackend, gapLimit int, isChange bool) ([]*UsedAddress, int, error) {
+	var used []*UsedAddress
+	next := 0
+	for start := 0; start-next < gapLimit; start += gapLimit {
+		count := gapLimit
+		if remaining := int64(keys.HardenedKeyZeroIndex) - int64(start); remaining < int64(count) {
+			count = int(remaining)
+		}
+
+		if count <= 0 {
+			break
+		}
+
+		addresses, err := a.AddressRange(start, count, isChange, scanWorkers)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for i, address := range addresses {
+			index := start + i
+			if index-next >= gapLimit {
+				break
+			}
+
+			txids, err := backend.AddressHistory(address)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if len(txids) > 0 {
+				used = append(used, &UsedAddress{Address: address, Index: index, IsChange: isChange, TxIDs: txids})
+				next = index + 1
+			}
+		}
+	}
+	return used, next, nil
+}
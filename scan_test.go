@@ -0,0 +1,121 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"errors"
+	"testing"
+)
+
+// countingBackend counts the addresses queried from a MemoryChainBackend
+type countingBackend struct {
+	*MemoryChainBackend
+	queries int
+	err     error
+}
+
+func (b *countingBackend) AddressHistory(address string) ([]string, error) {
+	b.queries++
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.MemoryChainBackend.AddressHistory(address)
+}
+
+func TestAccountScan(t *testing.T) {
+	account, err := NewAccountFromExtendedKey(testP2WPKHPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := &countingBackend{MemoryChainBackend: NewMemoryChainBackend()}
+	for _, index := range []int{0, 3, 22} {
+		address, _ := account.Address(index)
+		backend.AddTransaction(address, "tx-external")
+	}
+
+	change, _ := account.ChangeAddress(1)
+	backend.AddTransaction(change, "tx-change-1")
+	backend.AddTransaction(change, "tx-change-2")
+	backend.AddTransaction(change, "tx-change-2")
+
+	result, err := account.Scan(backend, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.NextExternalIndex != 23 || result.NextChangeIndex != 2 {
+		t.Errorf("next unused indexes are %d and %d want 23 and 2", result.NextExternalIndex, result.NextChangeIndex)
+	}
+
+	expected := []struct {
+		index    int
+		isChange bool
+		txs      int
+	}{{0, false, 1}, {3, false, 1}, {22, false, 1}, {1, true, 2}}
+
+	if len(result.Used) != len(expected) {
+		t.Fatalf("scan found %d used addresses want %d", len(result.Used), len(expected))
+	}
+
+	for i, e := range expected {
+		u := result.Used[i]
+		if u.Index != e.index || u.IsChange != e.isChange || len(u.TxIDs) != e.txs {
+			t.Errorf("used address %d is index %d (change %t, %d txs) want index %d (change %t, %d txs)", i, u.Index, u.IsChange, len(u.TxIDs), e.index, e.isChange, e.txs)
+		}
+
+		if u.Index == 0 && u.Address != testP2WPKH0 {
+			t.Errorf("used address is not expected value want %s got %s", testP2WPKH0, u.Address)
+		}
+	}
+
+	// External chain stops after 23 + 20 queries, change after 2 + 20
+	if backend.queries != 43+22 {
+		t.Errorf("scan queried %d addresses want %d", backend.queries, 43+22)
+	}
+
+	// A smaller gap limit does not reach index 22
+	result, err = account.Scan(backend, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.NextExternalIndex != 4 || len(result.Used) != 3 {
+		t.Errorf("gap limit 5 scan found %d used addresses up to %d", len(result.Used), result.NextExternalIndex)
+	}
+
+	empty := &countingBackend{MemoryChainBackend: NewMemoryChainBackend()}
+	result, err = account.Scan(empty, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Used) != 0 || result.NextExternalIndex != 0 || result.NextChangeIndex != 0 || empty.queries != 2*DefaultGapLimit {
+		t.Errorf("unused account scan returned %d used addresses after %d queries", len(result.Used), empty.queries)
+	}
+
+	backendErr := errors.New("backend unavailable")
+	if _, err := account.Scan(&countingBackend{MemoryChainBackend: NewMemoryChainBackend(), err: backendErr}, 0); err != backendErr {
+		t.Error("backend error was not returned")
+	}
+
+	if _, err := account.Scan(backend, -1); err != ErrInvalidGapLimit {
+		t.Error("negative gap limit did not fail where expected")
+	}
+}
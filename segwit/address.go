@@ -0,0 +1,117 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package segwit
+
+import (
+	"errors"
+)
+
+const (
+	// MaxWitnessVersion is the highest witness version an address can encode
+	MaxWitnessVersion = 16
+
+	// TaprootWitnessVersion is the witness version of BIP341 Taproot outputs
+	TaprootWitnessVersion = 1
+
+	// minProgramLength and maxProgramLength bound the witness program size of any version (BIP141)
+	minProgramLength = 2
+	maxProgramLength = 40
+)
+
+var (
+	// ErrInvalidWitnessVersion is returned when a witness version is above 16
+	ErrInvalidWitnessVersion = errors.New("Witness version must be between 0 and 16")
+
+	// ErrInvalidProgramLength is returned when a witness program has an invalid size for its version
+	ErrInvalidProgramLength = errors.New("Witness program has an invalid length")
+
+	// ErrWrongEncoding is returned when an address uses Bech32 for version 1+ or Bech32m for version 0
+	ErrWrongEncoding = errors.New("Witness version 0 must use Bech32 and later versions Bech32m")
+)
+
+// EncodeAddress returns the segwit address of a witness program.
+// Version 0 programs are Bech32 encoded (BIP173), later versions Bech32m (BIP350)
+func EncodeAddress(hrp string, version byte, program []byte) (string, error) {
+	if err := checkProgram(version, program); err != nil {
+		return "", err
+	}
+
+	data, err := ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	return Encode(hrp, append([]byte{version}, data...), encodingForVersion(version))
+}
+
+// DecodeAddress returns the human readable part, witness version and witness program of a segwit address
+func DecodeAddress(address string) (hrp string, version byte, program []byte, err error) {
+	hrp, data, enc, err := Decode(address)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	if len(data) == 0 {
+		return "", 0, nil, ErrInvalidLength
+	}
+
+	version = data[0]
+	if version > MaxWitnessVersion {
+		return "", 0, nil, ErrInvalidWitnessVersion
+	}
+
+	if enc != encodingForVersion(version) {
+		return "", 0, nil, ErrWrongEncoding
+	}
+
+	program, err = ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	if err := checkProgram(version, program); err != nil {
+		return "", 0, nil, err
+	}
+	return hrp, version, program, nil
+}
+
+// encodingForVersion returns the checksum variant required for a witness version
+func encodingForVersion(version byte) Encoding {
+	if version == 0 {
+		return Bech32
+	}
+	return Bech32m
+}
+
+// checkProgram validates the witness version and the program length rules of BIP141
+func checkProgram(version byte, program []byte) error {
+	if version > MaxWitnessVersion {
+		return ErrInvalidWitnessVersion
+	}
+
+	if len(program) < minProgramLength || len(program) > maxProgramLength {
+		return ErrInvalidProgramLength
+	}
+
+	// Version 0 programs are either a 20 byte key hash (P2WPKH) or a 32 byte script hash (P2WSH)
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return ErrInvalidProgramLength
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package segwit
+
+import (
+	"errors"
+	"strings"
+)
+
+// Encoding is the checksum variant of a bech32 string
+type Encoding int
+
+const (
+	// Bech32 is the original BIP173 checksum, used by version 0 witness programs
+	Bech32 Encoding = iota + 1
+
+	// Bech32m is the BIP350 checksum, used by version 1+ witness programs (e.g. Taproot)
+	Bech32m
+)
+
+const (
+	// charset maps 5 bit values to bech32 characters
+	charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// separator divides the human readable part from the data part
+	separator = '1'
+
+	// checksumLength is the number of 5 bit characters in a checksum
+	checksumLength = 6
+
+	// maxLength is the maximum length of a bech32 string
+	maxLength = 90
+
+	// bech32Const and bech32mConst are the polymod residues of valid Bech32 and Bech32m checksums
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+var (
+	// ErrMixedCase is returned when a bech32 string mixes upper and lower case characters
+	ErrMixedCase = errors.New("Bech32 string must not mix upper and lower case")
+
+	// ErrInvalidLength is returned when a bech32 string is longer than 90 characters or its parts are too short
+	ErrInvalidLength = errors.New("Bech32 string has an invalid length")
+
+	// ErrInvalidCharacter is returned when a bech32 string contains a character outside its charset
+	ErrInvalidCharacter = errors.New("Bech32 string contains an invalid character")
+
+	// ErrInvalidChecksum is returned when the checksum matches neither Bech32 nor Bech32m
+	ErrInvalidChecksum = errors.New("Bech32 checksum is invalid")
+
+	// ErrInvalidPadding is returned when regrouped data has excess or non-zero padding bits
+	ErrInvalidPadding = errors.New("Bech32 data has invalid padding")
+)
+
+// Encode returns the bech32 string of hrp and 5 bit data with the checksum of enc
+func Encode(hrp string, data []byte, enc Encoding) (string, error) {
+	if len(hrp) == 0 || len(hrp)+len(data)+1+checksumLength > maxLength {
+		return "", ErrInvalidLength
+	}
+
+	hrp = strings.ToLower(hrp)
+	for _, c := range []byte(hrp) {
+		if c < 33 || c > 126 {
+			return "", ErrInvalidCharacter
+		}
+	}
+
+	out := make([]byte, 0, len(hrp)+1+len(data)+checksumLength)
+	out = append(append(out, hrp...), separator)
+	for _, d := range data {
+		if d > 31 {
+			return "", ErrInvalidCharacter
+		}
+		out = append(out, charset[d])
+	}
+
+	for _, d := range checksum(hrp, data, enc) {
+		out = append(out, charset[d])
+	}
+	return string(out), nil
+}
+
+// Decode returns the lower case human readable part, 5 bit data and checksum variant of a bech32 string
+func Decode(s string) (hrp string, data []byte, enc Encoding, err error) {
+	if len(s) > maxLength {
+		return "", nil, 0, ErrInvalidLength
+	}
+
+	lower := strings.ToLower(s)
+	if lower != s && strings.ToUpper(s) != s {
+		return "", nil, 0, ErrMixedCase
+	}
+
+	pos := strings.LastIndexByte(lower, separator)
+	if pos < 1 || pos+1+checksumLength > len(lower) {
+		return "", nil, 0, ErrInvalidLength
+	}
+
+	hrp = lower[:pos]
+	for _, c := range []byte(hrp) {
+		if c < 33 || c > 126 {
+			return "", nil, 0, ErrInvalidCharacter
+		}
+	}
+
+	data = make([]byte, 0, len(lower)-pos-1)
+	for _, c := range []byte(lower[pos+1:]) {
+		d := strings.IndexByte(charset, c)
+		if d < 0 {
+			return "", nil, 0, ErrInvalidCharacter
+		}
+		data = append(data, byte(d))
+	}
+
+	switch polymod(hrp, data) {
+	case bech32Const:
+		enc = Bech32
+	case bech32mConst:
+		enc = Bech32m
+	default:
+		return "", nil, 0, ErrInvalidChecksum
+	}
+
+	return hrp, data[:len(data)-checksumLength], enc, nil
+}
+
+// ConvertBits regroups data of fromBits bit values into toBits bit values.
+// With pad the last group is zero padded, without it leftover bits must be zero and fewer than fromBits
+func ConvertBits(data []byte, fromBits uint, toBits uint, pad bool) ([]byte, error) {
+	var acc, bits uint
+	maxv := uint(1)<<toBits - 1
+	out := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+	for _, d := range data {
+		if uint(d)>>fromBits != 0 {
+			return nil, ErrInvalidCharacter
+		}
+		acc = acc<<fromBits | uint(d)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, ErrInvalidPadding
+	}
+	return out, nil
+}
+
+// checksum returns the 6 character checksum of hrp and data for enc
+func checksum(hrp string, data []byte, enc Encoding) []byte {
+	values := append(append([]byte{}, data...), make([]byte, checksumLength)...)
+	c := bech32Const
+	if enc == Bech32m {
+		c = bech32mConst
+	}
+
+	mod := polymod(hrp, values) ^ uint32(c)
+	result := make([]byte, checksumLength)
+	for i := range result {
+		result[i] = byte(mod >> uint(5*(5-i)) & 31)
+	}
+	return result
+}
+
+// polymod computes the BCH checksum of the expanded hrp followed by data
+func polymod(hrp string, data []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	step := func(v byte) {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if top>>uint(i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+
+	for _, c := range []byte(hrp) {
+		step(c >> 5)
+	}
+	step(0)
+	for _, c := range []byte(hrp) {
+		step(c & 31)
+	}
+	for _, d := range data {
+		step(d)
+	}
+	return chk
+}
@@ -0,0 +1,129 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package segwit
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test vector ref: https://github.com/bitcoin/bips/blob/master/bip-0350.mediawiki#test-vectors
+func TestDecodeValid(t *testing.T) {
+	valid := []struct {
+		s   string
+		enc Encoding
+	}{
+		{"A12UEL5L", Bech32},
+		{"a12uel5l", Bech32},
+		{"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs", Bech32},
+		{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", Bech32},
+		{"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j", Bech32},
+		{"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", Bech32},
+		{"?1ezyfcl", Bech32},
+		{"A1LQFN3A", Bech32m},
+		{"a1lqfn3a", Bech32m},
+		{"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6", Bech32m},
+		{"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx", Bech32m},
+		{"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8", Bech32m},
+		{"split1checkupstagehandshakeupstreamerranterredcaperredlc445v", Bech32m},
+		{"?1v759aa", Bech32m},
+	}
+
+	for _, v := range valid {
+		hrp, data, enc, err := Decode(v.s)
+		if err != nil {
+			t.Errorf("%s did not decode: %s", v.s, err.Error())
+			continue
+		}
+
+		if enc != v.enc {
+			t.Errorf("%s decoded with encoding %d want %d", v.s, enc, v.enc)
+		}
+
+		// Encoding again must give the lower case form of the input
+		s, err := Encode(hrp, data, enc)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if s != strings.ToLower(v.s) {
+			t.Errorf("%s encoded again as %s", v.s, s)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	invalid := []string{
+		"\x201nwldj5",
+		"\x7f1axkwrx",
+		"\x801eym55h",
+		"\x201xj0phk",
+		"\x7f1g6xzxy",
+		"\x801vctc34",
+		"an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4",
+		"qyrz8wqd2c9m",
+		"1qyrz8wqd2c9m",
+		"y1b0jsk6g",
+		"lt1igcx5c0",
+		"in1muywd",
+		"mm1crxm3i",
+		"au1s5cgom",
+		"M1VUXWEZ",
+		"16plkw9",
+		"1p2gdwpf",
+		"pzry9x0s0muk",
+		"1pzry9x0s0muk",
+		"x1b4n0q5v",
+		"li1dgmt3",
+		"A1G7SGD8",
+		"10a06t8",
+		"1qzzfhee",
+	}
+
+	for _, s := range invalid {
+		if _, _, _, err := Decode(s); err == nil {
+			t.Errorf("%q decoded where failure was expected", s)
+		}
+	}
+}
+
+func TestConvertBits(t *testing.T) {
+	data := []byte{0x00, 0x14, 0x75, 0x1e, 0x76, 0xe8, 0x19}
+	five, err := ConvertBits(data, 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eight, err := ConvertBits(five, 5, 8, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(eight) != string(data) {
+		t.Errorf("regrouped data is not expected value, got %x", eight)
+	}
+
+	if _, err := ConvertBits([]byte{32}, 5, 8, true); err == nil {
+		t.Error("value wider than source group did not fail where expected")
+	}
+
+	if _, err := ConvertBits([]byte{0x1f}, 5, 8, false); err != ErrInvalidPadding {
+		t.Error("non-zero padding did not fail where expected")
+	}
+}
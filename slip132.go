@@ -0,0 +1,100 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+var (
+	// ErrConvertPrivateToPublic is returned when a private key is converted to a public key prefix
+	ErrConvertPrivateToPublic = errors.New("Cannot convert a private extended key to a public key prefix")
+
+	// ErrConvertPublicToPrivate is returned when a public key is converted to a private key prefix
+	ErrConvertPublicToPrivate = errors.New("Cannot convert a public extended key to a private key prefix")
+
+	// ErrConvertNetwork is returned when a key is converted to a prefix of another network
+	ErrConvertNetwork = errors.New("Cannot convert an extended key to a prefix of a different network")
+)
+
+// ConvertExtendedKey re-encodes an extended key with the SLIP-132 version of targetPrefix (e.g. xpub, ypub, zpub, vprv).
+// Only the version bytes change, the key material is left untouched
+func ConvertExtendedKey(extendedKey string, targetPrefix string) (string, error) {
+	source, sourcePrivate, err := keys.GetExtendedKeyInfo(extendedKey)
+	if err != nil {
+		return "", err
+	}
+
+	target, targetPrivate, err := keys.LookupKeyPrefix(targetPrefix)
+	if err != nil {
+		return "", fmt.Errorf("Unknown extended key prefix %q", targetPrefix)
+	}
+
+	if sourcePrivate && !targetPrivate {
+		return "", ErrConvertPrivateToPublic
+	}
+
+	if !sourcePrivate && targetPrivate {
+		return "", ErrConvertPublicToPrivate
+	}
+
+	if source.Network != target.Network {
+		return "", ErrConvertNetwork
+	}
+
+	return getVersionedAccountKey(extendedKey, target.ScriptType, target.Network, targetPrivate)
+}
+
+// getVersionedAccountKey re-encodes an account key with the SLIP-132 version bytes of scriptType on net
+func getVersionedAccountKey(accountKey string, scriptType keys.ScriptType, net network.Network, includePrivateKey bool) (string, error) {
+	v, err := keys.GetKeyVersion(scriptType, net)
+	if err != nil {
+		return "", err
+	}
+
+	if includePrivateKey {
+		return hdkeychain.VersionedStringFromExtendedKeyString(accountKey, v.Private)
+	}
+	return hdkeychain.VersionedStringFromExtendedKeyString(accountKey, v.Public)
+}
+
+// checkAccountKeyVersion returns an error unless accountKey carries the SLIP-132 version bytes of scriptType on net.
+// Version bytes are compared rather than registry entries as networks may share them (regtest, signet, Litecoin zpub)
+func checkAccountKeyVersion(accountKey string, scriptType keys.ScriptType, net network.Network) error {
+	want, err := keys.GetKeyVersion(scriptType, net)
+	if err != nil {
+		return err
+	}
+
+	version, err := keys.GetExtendedKeyVersion(accountKey)
+	if err != nil {
+		return err
+	}
+
+	if version != want.Private && version != want.Public {
+		return fmt.Errorf("Key does not start with a %s prefix (%s/%s)", scriptType, want.PublicPrefix, want.PrivatePrefix)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"testing"
+)
+
+func TestConvertExtendedKey(t *testing.T) {
+	conversions := []struct {
+		key      string
+		prefix   string
+		expected string
+	}{
+		// Same key material as the BIP84 account, exported with xpub/ypub version bytes
+		{testP2WPKHPub, "zpub", testP2WPKHPub},
+		{testP2SHPub, "ypub", testP2SHPub},
+		{testTestnetP2WPKHPub, "vpub", testTestnetP2WPKHPub},
+	}
+
+	for _, c := range conversions {
+		k, err := ConvertExtendedKey(c.key, c.prefix)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if k != c.expected {
+			t.Errorf("conversion to %s is not expected value want %s got %s", c.prefix, c.expected, k)
+		}
+	}
+
+	// Round trip through every format of a network must return the original key
+	for _, chain := range [][]string{
+		{testP2WPKHPub, "xpub", "ypub", "Ypub", "Zpub", "zpub"},
+		{testP2WPKHPriv, "xprv", "yprv", "Yprv", "Zprv", "zprv"},
+		{testTestnetP2SHPub, "tpub", "vpub", "Upub", "Vpub", "upub"},
+		{testTestnetP2SHPriv, "tprv", "vprv", "Uprv", "Vprv", "uprv"},
+	} {
+		k := chain[0]
+		for _, prefix := range chain[1:] {
+			var err error
+			k, err = ConvertExtendedKey(k, prefix)
+			if err != nil {
+				t.Error(err.Error())
+			}
+
+			if k[:4] != prefix {
+				t.Errorf("converted key does not start with %s, got %s", prefix, k[:4])
+			}
+		}
+
+		if k != chain[0] {
+			t.Errorf("round trip conversion is not expected value want %s got %s", chain[0], k)
+		}
+	}
+
+	// Converted keys derive the same addresses as the original
+	x, err := ConvertExtendedKey(testP2WPKHPub, "xpub")
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	z, err := ConvertExtendedKey(x, "zpub")
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	a, err := GetP2WPKHAddressForIndex(z, 0, testIsChangeAddress, testIsTestnet)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if a != testP2WPKH0 {
+		t.Errorf("address of converted key is not expected value want %s got %s", testP2WPKH0, a)
+	}
+}
+
+func TestConvertExtendedKeyErrors(t *testing.T) {
+	if _, err := ConvertExtendedKey(testP2WPKHPriv, "xpub"); err != ErrConvertPrivateToPublic {
+		t.Error("private to public conversion did not fail where expected")
+	}
+
+	if _, err := ConvertExtendedKey(testP2WPKHPub, "zprv"); err != ErrConvertPublicToPrivate {
+		t.Error("public to private conversion did not fail where expected")
+	}
+
+	if _, err := ConvertExtendedKey(testP2WPKHPub, "vpub"); err != ErrConvertNetwork {
+		t.Error("mainnet to testnet conversion did not fail where expected")
+	}
+
+	if _, err := ConvertExtendedKey(testP2WPKHPub, "wpub"); err == nil {
+		t.Error("unknown prefix conversion did not fail where expected")
+	}
+
+	if _, err := ConvertExtendedKey("zpub", "xpub"); err == nil {
+		t.Error("invalid key conversion did not fail where expected")
+	}
+}
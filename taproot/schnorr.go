@@ -0,0 +1,154 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package taproot
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+const (
+	// SchnorrSignatureLength is the size of a BIP340 signature
+	SchnorrSignatureLength = 64
+
+	// Tags of the BIP340 tagged hashes
+	auxTag       = "BIP0340/aux"
+	nonceTag     = "BIP0340/nonce"
+	challengeTag = "BIP0340/challenge"
+)
+
+var (
+	// ErrInvalidSchnorrSignature is returned when a BIP340 signature is malformed or does not verify
+	ErrInvalidSchnorrSignature = errors.New("Schnorr signature is invalid")
+
+	// ErrInvalidXOnlyPubKey is returned when 32 bytes are not the x coordinate of a curve point
+	ErrInvalidXOnlyPubKey = errors.New("X-only public key is invalid")
+
+	// ErrInvalidAuxRand is returned when the auxiliary randomness of a signature is not 32 bytes
+	ErrInvalidAuxRand = errors.New("Schnorr auxiliary randomness must be 32 bytes")
+)
+
+// SignSchnorr returns the BIP340 signature of a 32 byte hash.
+// auxRand is 32 bytes of fresh randomness, or nil for deterministic signatures (all zero auxiliary data)
+func SignSchnorr(priv *btcec.PrivateKey, hash []byte, auxRand []byte) ([]byte, error) {
+	if auxRand == nil {
+		auxRand = make([]byte, 32)
+	} else if len(auxRand) != 32 {
+		return nil, ErrInvalidAuxRand
+	}
+
+	curve := btcec.S256()
+	pub := priv.PubKey()
+	px := XOnlyPubKey(pub)
+
+	d := new(big.Int).Set(priv.D)
+	if pub.Y.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+
+	t := scalarBytes(d)
+	for i, b := range TaggedHash(auxTag, auxRand) {
+		t[i] ^= b
+	}
+
+	k := new(big.Int).SetBytes(TaggedHash(nonceTag, t, px, hash))
+	k.Mod(k, curve.N)
+	if k.Sign() == 0 {
+		return nil, ErrInvalidSchnorrSignature
+	}
+
+	rx, ry := curve.ScalarBaseMult(scalarBytes(k))
+	if ry.Bit(0) == 1 {
+		k.Sub(curve.N, k)
+	}
+
+	r := scalarBytes(rx)
+	e := challenge(r, px, hash)
+
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, curve.N)
+
+	sig := append(r, scalarBytes(s)...)
+	if err := VerifySchnorr(px, hash, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// VerifySchnorr checks a BIP340 signature of a 32 byte hash against an x-only public key
+func VerifySchnorr(xOnlyPubKey []byte, hash []byte, sig []byte) error {
+	if len(sig) != SchnorrSignatureLength {
+		return ErrInvalidSchnorrSignature
+	}
+
+	p, err := ParseXOnlyPubKey(xOnlyPubKey)
+	if err != nil {
+		return err
+	}
+
+	curve := btcec.S256()
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return ErrInvalidSchnorrSignature
+	}
+
+	// R = sG - eP
+	e := challenge(sig[:32], xOnlyPubKey, hash)
+	sx, sy := curve.ScalarBaseMult(scalarBytes(s))
+	ex, ey := curve.ScalarMult(p.X, p.Y, scalarBytes(e))
+	ey.Sub(curve.P, ey)
+
+	rx, ry := curve.Add(sx, sy, ex, ey)
+	if (rx.Sign() == 0 && ry.Sign() == 0) || ry.Bit(0) == 1 || rx.Cmp(r) != 0 {
+		return ErrInvalidSchnorrSignature
+	}
+	return nil
+}
+
+// ParseXOnlyPubKey returns the curve point with an even y coordinate for a 32 byte x-only public key
+func ParseXOnlyPubKey(xOnlyPubKey []byte) (*btcec.PublicKey, error) {
+	if len(xOnlyPubKey) != XOnlyPubKeyLength {
+		return nil, ErrInvalidXOnlyPubKey
+	}
+
+	p, err := btcec.ParsePubKey(append([]byte{0x02}, xOnlyPubKey...), btcec.S256())
+	if err != nil {
+		return nil, ErrInvalidXOnlyPubKey
+	}
+	return p, nil
+}
+
+// challenge returns the BIP340 challenge scalar e = H_challenge(R.x || P.x || m) mod n
+func challenge(rx []byte, px []byte, hash []byte) *big.Int {
+	e := new(big.Int).SetBytes(TaggedHash(challengeTag, rx, px, hash))
+	return e.Mod(e, btcec.S256().N)
+}
+
+// scalarBytes returns an integer as 32 big-endian bytes
+func scalarBytes(i *big.Int) []byte {
+	b := i.Bytes()
+	if len(b) >= 32 {
+		return b
+	}
+	return append(make([]byte, 32-len(b)), b...)
+}
@@ -0,0 +1,126 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package taproot
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+func TestSchnorr(t *testing.T) {
+	// Test vector ref: https://github.com/bitcoin/bips/blob/master/bip-0340/test-vectors.csv
+	vectors := []struct {
+		priv    string
+		pub     string
+		auxRand string
+		msg     string
+		sig     string
+	}{
+		{
+			"0000000000000000000000000000000000000000000000000000000000000003",
+			"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+		},
+		{
+			"b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef",
+			"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			"6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+		},
+		{
+			"c90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74020bbea63b14e5c9",
+			"dd308afec5777e13121fa72b9cc1b7cc0139715309b086c960e18fd969774eb8",
+			"c87aa53824b4d7ae2eb035a2b5bbbccc080e76cdc6d1692c4b0b62d798e6d906",
+			"7e2d58d8b3bcdf1abadec7829054f90dda9805aab56c77333024b9d0a508b75c",
+			"5831aaeed7b44bb74e5eab94ba9d4294c49bcf2a60728d8b4c200f50dd313c1bab745879a5ad954a72c45a91c3a51d3c7adea98d82f8481e0e1e03674a6f3fb7",
+		},
+		{
+			"0b432b2677937381aef05bb02a66ecd012773062cf3fa2549e44f58ed2401710",
+			"25d1dff95105f5253c4022f628a996ad3a0d95fbf21d468a1b33f8c160d8f517",
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"7eb0509757e246f19449885651611cb965ecc1a187dd51b64fda1edc9637d5ec97582b9cb13db3933705b32ba982af5af25fd78881ebb32771fc5922efc66ea3",
+		},
+	}
+
+	for _, v := range vectors {
+		privBytes, _ := hex.DecodeString(v.priv)
+		pub, _ := hex.DecodeString(v.pub)
+		auxRand, _ := hex.DecodeString(v.auxRand)
+		msg, _ := hex.DecodeString(v.msg)
+		priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), privBytes)
+
+		if hex.EncodeToString(XOnlyPubKey(priv.PubKey())) != v.pub {
+			t.Errorf("public key of %s is not expected value want %s got %x", v.priv, v.pub, XOnlyPubKey(priv.PubKey()))
+		}
+
+		sig, err := SignSchnorr(priv, msg, auxRand)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if hex.EncodeToString(sig) != v.sig {
+			t.Errorf("signature with %s is not expected value want %s got %x", v.priv, v.sig, sig)
+		}
+
+		if err := VerifySchnorr(pub, msg, sig); err != nil {
+			t.Errorf("signature with %s did not verify: %s", v.priv, err.Error())
+		}
+
+		sig[SchnorrSignatureLength-1] ^= 0x01
+		if err := VerifySchnorr(pub, msg, sig); err != ErrInvalidSchnorrSignature {
+			t.Errorf("altered signature with %s did not fail where expected", v.priv)
+		}
+	}
+
+	// Verification only vectors
+	pub, _ := hex.DecodeString("d69c3509bb99e412e68b0fe8544e72837dfa30746d8be2aa65975f29d22dc7b9")
+	msg, _ := hex.DecodeString("4df3c3f68fcc83b27e9d42c90431a72499f17875c81a599b566c9889b9696703")
+	sig, _ := hex.DecodeString("00000000000000000000003b78ce563f89a0ed9414f5aa28ad0d96d6795f9c6376afb1548af603b3eb45c9f8207dee1060cb71c04e80f593060b07d28308d7f4")
+	if err := VerifySchnorr(pub, msg, sig); err != nil {
+		t.Errorf("signature with small R.x did not verify: %s", err.Error())
+	}
+
+	notOnCurve, _ := hex.DecodeString("eefdea4cdb677750a420fee807eacf21eb9898ae79b9768766e4faa04a2d4a34")
+	if err := VerifySchnorr(notOnCurve, msg, sig); err != ErrInvalidXOnlyPubKey {
+		t.Error("public key not on the curve did not fail where expected")
+	}
+
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01})
+	if _, err := SignSchnorr(priv, msg, make([]byte, 31)); err != ErrInvalidAuxRand {
+		t.Error("short auxiliary randomness did not fail where expected")
+	}
+
+	deterministic, err := SignSchnorr(priv, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zeroAux, _ := SignSchnorr(priv, msg, make([]byte, 32))
+	if !bytes.Equal(deterministic, zeroAux) {
+		t.Error("nil auxiliary randomness does not sign like all zero randomness")
+	}
+}
@@ -0,0 +1,148 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package taproot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// TapSighashTag is the BIP340 tagged hash tag of BIP341 signature messages
+	TapSighashTag = "TapSighash"
+
+	// SigHashDefault is the BIP341 hash type committing to the whole transaction with a 64 byte signature
+	SigHashDefault txscript.SigHashType = 0x00
+)
+
+var (
+	// ErrInvalidSigHashType is returned for hash types BIP341 does not define
+	ErrInvalidSigHashType = errors.New("Taproot signature hash type is invalid")
+
+	// ErrPrevOutsMismatch is returned when the spent outputs do not match the transaction inputs
+	ErrPrevOutsMismatch = errors.New("Spent outputs must be given for every transaction input")
+
+	// ErrInputIndex is returned when the signed input does not exist, or has no output for SIGHASH_SINGLE
+	ErrInputIndex = errors.New("Input index is out of range")
+)
+
+// KeySpendSigHash returns the BIP341 signature hash of a key path spend of input inputIndex.
+// prevOuts are the outputs spent by every input of tx, in input order
+func KeySpendSigHash(tx *wire.MsgTx, prevOuts []*wire.TxOut, inputIndex int, hashType txscript.SigHashType) ([]byte, error) {
+	base := hashType & 0x03
+	anyoneCanPay := hashType&txscript.SigHashAnyOneCanPay != 0
+	if hashType&^(txscript.SigHashAnyOneCanPay|0x03) != 0 || (hashType != SigHashDefault && base == 0) {
+		return nil, ErrInvalidSigHashType
+	}
+
+	if len(prevOuts) != len(tx.TxIn) {
+		return nil, ErrPrevOutsMismatch
+	}
+
+	if inputIndex < 0 || inputIndex >= len(tx.TxIn) || (base == txscript.SigHashSingle && inputIndex >= len(tx.TxOut)) {
+		return nil, ErrInputIndex
+	}
+
+	var msg bytes.Buffer
+	msg.WriteByte(0x00) // epoch
+	msg.WriteByte(byte(hashType))
+	writeUint32(&msg, uint32(tx.Version))
+	writeUint32(&msg, tx.LockTime)
+
+	if !anyoneCanPay {
+		var prevouts, amounts, scripts, sequences bytes.Buffer
+		for i, in := range tx.TxIn {
+			writeOutPoint(&prevouts, &in.PreviousOutPoint)
+			writeUint64(&amounts, uint64(prevOuts[i].Value))
+			if err := wire.WriteVarBytes(&scripts, 0, prevOuts[i].PkScript); err != nil {
+				return nil, err
+			}
+			writeUint32(&sequences, in.Sequence)
+		}
+		writeSHA256(&msg, prevouts.Bytes())
+		writeSHA256(&msg, amounts.Bytes())
+		writeSHA256(&msg, scripts.Bytes())
+		writeSHA256(&msg, sequences.Bytes())
+	}
+
+	if base != txscript.SigHashNone && base != txscript.SigHashSingle {
+		var outputs bytes.Buffer
+		for _, out := range tx.TxOut {
+			if err := wire.WriteTxOut(&outputs, 0, 0, out); err != nil {
+				return nil, err
+			}
+		}
+		writeSHA256(&msg, outputs.Bytes())
+	}
+
+	msg.WriteByte(0x00) // spend type: key path, no annex
+
+	if anyoneCanPay {
+		in := tx.TxIn[inputIndex]
+		writeOutPoint(&msg, &in.PreviousOutPoint)
+		writeUint64(&msg, uint64(prevOuts[inputIndex].Value))
+		if err := wire.WriteVarBytes(&msg, 0, prevOuts[inputIndex].PkScript); err != nil {
+			return nil, err
+		}
+		writeUint32(&msg, in.Sequence)
+	} else {
+		writeUint32(&msg, uint32(inputIndex))
+	}
+
+	if base == txscript.SigHashSingle {
+		var output bytes.Buffer
+		if err := wire.WriteTxOut(&output, 0, 0, tx.TxOut[inputIndex]); err != nil {
+			return nil, err
+		}
+		writeSHA256(&msg, output.Bytes())
+	}
+
+	return TaggedHash(TapSighashTag, msg.Bytes()), nil
+}
+
+// writeOutPoint writes the 36 byte serialization of an outpoint
+func writeOutPoint(b *bytes.Buffer, op *wire.OutPoint) {
+	b.Write(op.Hash[:])
+	writeUint32(b, op.Index)
+}
+
+// writeSHA256 writes the single SHA256 of data
+func writeSHA256(b *bytes.Buffer, data []byte) {
+	h := sha256.Sum256(data)
+	b.Write(h[:])
+}
+
+// writeUint32 writes a little-endian uint32
+func writeUint32(b *bytes.Buffer, v uint32) {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	b.Write(buf[:])
+}
+
+// writeUint64 writes a little-endian uint64
+func writeUint64(b *bytes.Buffer, v uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	b.Write(buf[:])
+}
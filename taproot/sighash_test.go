@@ -0,0 +1,72 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package taproot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestKeySpendSigHash(t *testing.T) {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0}, nil, nil))
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_RETURN}))
+
+	prevOuts := []*wire.TxOut{wire.NewTxOut(2000, []byte{txscript.OP_1}), wire.NewTxOut(3000, []byte{txscript.OP_1})}
+
+	hashes := make(map[string]txscript.SigHashType)
+	for _, hashType := range []txscript.SigHashType{0x00, 0x01, 0x02, 0x81, 0x82} {
+		h, err := KeySpendSigHash(tx, prevOuts, 0, hashType)
+		if err != nil {
+			t.Errorf("hash type %#x failed: %s", hashType, err.Error())
+			continue
+		}
+
+		if other, ok := hashes[string(h)]; ok {
+			t.Errorf("hash types %#x and %#x give the same signature hash", other, hashType)
+		}
+		hashes[string(h)] = hashType
+	}
+
+	// ANYONECANPAY does not commit to the other inputs
+	a, _ := KeySpendSigHash(tx, prevOuts, 0, 0x81)
+	prevOuts[1].Value++
+	b, _ := KeySpendSigHash(tx, prevOuts, 0, 0x81)
+	if !bytes.Equal(a, b) {
+		t.Error("SIGHASH_ALL|ANYONECANPAY commits to another input amount")
+	}
+
+	if _, err := KeySpendSigHash(tx, prevOuts, 1, txscript.SigHashSingle); err != ErrInputIndex {
+		t.Error("SIGHASH_SINGLE without a matching output did not fail where expected")
+	}
+
+	for _, hashType := range []txscript.SigHashType{0x04, 0x80, 0x84} {
+		if _, err := KeySpendSigHash(tx, prevOuts, 0, hashType); err != ErrInvalidSigHashType {
+			t.Errorf("hash type %#x did not fail where expected", hashType)
+		}
+	}
+
+	if _, err := KeySpendSigHash(tx, prevOuts[:1], 0, SigHashDefault); err != ErrPrevOutsMismatch {
+		t.Error("missing spent output did not fail where expected")
+	}
+}
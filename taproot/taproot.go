@@ -0,0 +1,123 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package taproot
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+const (
+	// TapTweakTag is the BIP340 tagged hash tag used to tweak internal keys
+	TapTweakTag = "TapTweak"
+
+	// XOnlyPubKeyLength is the size of a BIP340 x-only public key
+	XOnlyPubKeyLength = 32
+)
+
+var (
+	// ErrInvalidTweak is returned in the negligible case that a tweak is not a valid scalar or gives the point at infinity
+	ErrInvalidTweak = errors.New("Taproot tweak is out of range")
+
+	// ErrInvalidMerkleRoot is returned when a script tree merkle root is not 32 bytes
+	ErrInvalidMerkleRoot = errors.New("Taproot merkle root must be 32 bytes")
+)
+
+// TaggedHash returns the BIP340 tagged hash SHA256(SHA256(tag) || SHA256(tag) || msgs...)
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, m := range msgs {
+		h.Write(m)
+	}
+	return h.Sum(nil)
+}
+
+// XOnlyPubKey returns the 32 byte x coordinate of a public key (BIP340)
+func XOnlyPubKey(pub *btcec.PublicKey) []byte {
+	return pub.SerializeCompressed()[1:]
+}
+
+// TweakPublicKey returns the BIP341 output key Q = P + H_TapTweak(P || merkleRoot)G for an internal key.
+// P is the internal key with an even y coordinate, merkleRoot is nil for a key-path only output (BIP86)
+func TweakPublicKey(internal *btcec.PublicKey, merkleRoot []byte) (*btcec.PublicKey, error) {
+	curve := btcec.S256()
+
+	p, err := btcec.ParsePubKey(append([]byte{0x02}, XOnlyPubKey(internal)...), curve)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := tweak(XOnlyPubKey(internal), merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, ty := curve.ScalarBaseMult(t.Bytes())
+	qx, qy := curve.Add(p.X, p.Y, tx, ty)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, ErrInvalidTweak
+	}
+
+	return &btcec.PublicKey{Curve: curve, X: qx, Y: qy}, nil
+}
+
+// TweakPrivateKey returns the private key of the output key for an internal private key.
+// The internal key is negated first when its public key has an odd y coordinate so the result matches TweakPublicKey
+func TweakPrivateKey(internal *btcec.PrivateKey, merkleRoot []byte) (*btcec.PrivateKey, error) {
+	curve := btcec.S256()
+	pub := internal.PubKey()
+
+	t, err := tweak(XOnlyPubKey(pub), merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(big.Int).Set(internal.D)
+	if pub.Y.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+
+	d.Add(d, t)
+	d.Mod(d, curve.N)
+	if d.Sign() == 0 {
+		return nil, ErrInvalidTweak
+	}
+
+	priv, _ := btcec.PrivKeyFromBytes(curve, d.Bytes())
+	return priv, nil
+}
+
+// tweak returns the tweak scalar H_TapTweak(P || merkleRoot) checked against the curve order
+func tweak(xOnly []byte, merkleRoot []byte) (*big.Int, error) {
+	if merkleRoot != nil && len(merkleRoot) != sha256.Size {
+		return nil, ErrInvalidMerkleRoot
+	}
+
+	t := new(big.Int).SetBytes(TaggedHash(TapTweakTag, xOnly, merkleRoot))
+	if t.Cmp(btcec.S256().N) >= 0 {
+		return nil, ErrInvalidTweak
+	}
+	return t, nil
+}
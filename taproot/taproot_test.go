@@ -0,0 +1,98 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package taproot
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+func TestTweakPublicKey(t *testing.T) {
+	// Test vector ref: https://github.com/bitcoin/bips/blob/master/bip-0086.mediawiki#test-vectors
+	vectors := []struct {
+		internal string
+		output   string
+	}{
+		{"cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115", "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c"},
+		{"83dfe85a3151d2517290da461fe2815591ef69f2b18a2ce63f01697a8b313145", "a82f29944d65b86ae6b5e5cc75e294ead6c59391a1edc5e016e3498c67fc7bbb"},
+		{"399f1b2f4393f29a18c937859c5dd8a77350103157eb880f02e8c08214277cef", "882d74e5d0572d5a816cef0041a96b6c1de832f6f9676d9605c44d5e9a97d3dc"},
+	}
+
+	for _, v := range vectors {
+		b, _ := hex.DecodeString(v.internal)
+
+		// The tweak only depends on the x coordinate so both parities must give the same output key
+		for _, prefix := range []byte{0x02, 0x03} {
+			internal, err := btcec.ParsePubKey(append([]byte{prefix}, b...), btcec.S256())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			q, err := TweakPublicKey(internal, nil)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			if hex.EncodeToString(XOnlyPubKey(q)) != v.output {
+				t.Errorf("output key for %s is not expected value want %s got %x", v.internal, v.output, XOnlyPubKey(q))
+			}
+		}
+	}
+
+	k, _ := btcec.NewPrivateKey(btcec.S256())
+	if _, err := TweakPublicKey(k.PubKey(), make([]byte, 31)); err != ErrInvalidMerkleRoot {
+		t.Error("short merkle root did not fail where expected")
+	}
+}
+
+func TestTweakPrivateKey(t *testing.T) {
+	root := TaggedHash("TapBranch", []byte("script tree"))
+	for i := 1; i <= 16; i++ {
+		priv, pub := btcec.PrivKeyFromBytes(btcec.S256(), []byte{byte(i)})
+		for _, merkleRoot := range [][]byte{nil, root} {
+			tweakedPriv, err := TweakPrivateKey(priv, merkleRoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tweakedPub, err := TweakPublicKey(pub, merkleRoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if hex.EncodeToString(XOnlyPubKey(tweakedPriv.PubKey())) != hex.EncodeToString(XOnlyPubKey(tweakedPub)) {
+				t.Errorf("tweaked private key %d does not match tweaked public key", i)
+			}
+		}
+	}
+}
+
+func TestTaggedHash(t *testing.T) {
+	// Messages are concatenated so empty parts (e.g. a nil merkle root) must not change the hash
+	if hex.EncodeToString(TaggedHash(TapTweakTag)) != hex.EncodeToString(TaggedHash(TapTweakTag, nil, []byte{})) {
+		t.Error("empty messages changed the tagged hash")
+	}
+
+	if hex.EncodeToString(TaggedHash(TapTweakTag, []byte{1})) == hex.EncodeToString(TaggedHash("TapLeaf", []byte{1})) {
+		t.Error("tag does not change the tagged hash")
+	}
+}
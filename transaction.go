@@ -0,0 +1,428 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/taproot"
+	"github.com/sanscentral/sanswallet/txsize"
+)
+
+// txVersion is the version of built transactions
+const txVersion = 2
+
+var (
+	// ErrNoInputs is returned when a transaction is built without UTXOs to spend
+	ErrNoInputs = errors.New("Transaction has no inputs")
+
+	// ErrNoRecipients is returned when a transaction is built without recipients
+	ErrNoRecipients = errors.New("Transaction has no recipients")
+
+	// ErrInsufficientFunds is returned when the UTXOs do not cover the recipients and the fee
+	ErrInsufficientFunds = errors.New("Inputs do not cover the outputs and fee")
+
+	// ErrDustOutput is returned when a recipient amount is below the dust threshold of its output script
+	ErrDustOutput = errors.New("Output amount is below the dust threshold")
+
+	// ErrInvalidFeeRate is returned for a negative fee rate
+	ErrInvalidFeeRate = errors.New("Fee rate must not be negative")
+
+	// ErrInvalidAmount is returned for amounts that are not positive
+	ErrInvalidAmount = errors.New("Amount must be positive")
+
+	// ErrPrevTxMismatch is returned when the previous transaction of a UTXO does not hold its output
+	ErrPrevTxMismatch = errors.New("Previous transaction does not hold the UTXO")
+
+	// ErrForkIDSigning is returned when spending from networks whose signatures commit to a fork id (Bitcoin Cash)
+	ErrForkIDSigning = errors.New("Transactions of this network cannot be signed")
+)
+
+// UTXO is an unspent output paying to an address of the account
+type UTXO struct {
+	// TxID is the hex encoded id of the transaction holding the output
+	TxID string
+	Vout int
+
+	// Amount is the output value in satoshis
+	Amount int64
+
+	// AddressIndex and IsChange locate the address the output pays to
+	AddressIndex int
+	IsChange     bool
+
+	// PrevTxHex is the optional hex encoded transaction holding the output.
+	// PSBTs include it for hardware wallets to check the amount, it is required to create PSBTs spending P2PKH outputs
+	PrevTxHex string
+}
+
+// Recipient is an address and the amount in satoshis to pay it
+type Recipient struct {
+	Address string
+	Amount  int64
+}
+
+// SignedTransaction is a transaction ready to broadcast
+type SignedTransaction struct {
+	// Hex is the serialized transaction and TxID its id
+	Hex  string
+	TxID string
+
+	// Fee is the amount in satoshis left to miners
+	Fee int64
+
+	// ChangeIndex is the change address index paid ChangeAmount, -1 when change was too small to output
+	ChangeIndex  int
+	ChangeAmount int64
+
+	// VSize is the virtual size of the signed transaction in vbytes
+	VSize int64
+}
+
+// BuildTransaction spends all utxos of the account to recipients and returns the signed transaction.
+// The fee is feeRate sat/vB of the estimated size and change goes to the change address at changeIndex
+// (e.g. ScanResult.NextChangeIndex), or to the fee if it would be dust. The account must hold the extended private key
+func (a *Account) BuildTransaction(utxos []*UTXO, recipients []*Recipient, feeRate int64, changeIndex int) (*SignedTransaction, error) {
+	f, err := a.fundTransaction(utxos, recipients, feeRate, changeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.signTransaction(f.tx, f.inputs); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := f.tx.Serialize(&b); err != nil {
+		return nil, err
+	}
+
+	return &SignedTransaction{
+		Hex:          hex.EncodeToString(b.Bytes()),
+		TxID:         f.tx.TxHash().String(),
+		Fee:          f.fee,
+		ChangeIndex:  f.changeIndex,
+		ChangeAmount: f.changeAmount,
+		VSize:        txsize.VSize(int64(blockchainWeight(f.tx))),
+	}, nil
+}
+
+// fundedTransaction is an unsigned transaction spending account UTXOs, with its fee and change
+type fundedTransaction struct {
+	tx     *wire.MsgTx
+	inputs []*txInput
+	fee    int64
+
+	// changeIndex is the change address index of the last output, -1 when there is no change output
+	changeIndex  int
+	changeAmount int64
+}
+
+// fundTransaction returns the unsigned transaction spending all utxos to recipients with change to changeIndex
+func (a *Account) fundTransaction(utxos []*UTXO, recipients []*Recipient, feeRate int64, changeIndex int) (*fundedTransaction, error) {
+	if a.cashAddrPrefix != "" {
+		return nil, ErrForkIDSigning
+	}
+
+	if len(utxos) == 0 {
+		return nil, ErrNoInputs
+	}
+
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	if feeRate < 0 {
+		return nil, ErrInvalidFeeRate
+	}
+
+	tx := wire.NewMsgTx(txVersion)
+	inputs := make([]*txInput, len(utxos))
+	var total int64
+	for i, u := range utxos {
+		in, err := a.newTxInput(u)
+		if err != nil {
+			return nil, err
+		}
+
+		inputs[i] = in
+		total += u.Amount
+		tx.AddTxIn(wire.NewTxIn(&in.outPoint, nil, nil))
+	}
+
+	var spent int64
+	for _, r := range recipients {
+		if r.Amount <= 0 {
+			return nil, ErrInvalidAmount
+		}
+
+		script, err := a.addressScript(r.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Amount < txsize.ScriptDustThreshold(script) {
+			return nil, ErrDustOutput
+		}
+
+		spent += r.Amount
+		tx.AddTxOut(wire.NewTxOut(r.Amount, script))
+	}
+
+	changeAddress, err := a.ChangeAddress(changeIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	changeScript, err := a.addressScript(changeAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fee with and without the change output, change is only added when it is above the dust threshold
+	weight, err := estimateWeight(a.scriptType, len(inputs), tx.TxOut)
+	if err != nil {
+		return nil, err
+	}
+	feeNoChange := txsize.Fee(weight, feeRate)
+
+	change := wire.NewTxOut(0, changeScript)
+	weight, err = estimateWeight(a.scriptType, len(inputs), append(tx.TxOut, change))
+	if err != nil {
+		return nil, err
+	}
+	fee := txsize.Fee(weight, feeRate)
+
+	f := &fundedTransaction{tx: tx, inputs: inputs, changeIndex: -1}
+	switch {
+	case total-spent-fee >= txsize.ScriptDustThreshold(changeScript):
+		change.Value = total - spent - fee
+		tx.AddTxOut(change)
+		f.changeIndex = changeIndex
+		f.changeAmount = change.Value
+		f.fee = fee
+	case total-spent >= feeNoChange:
+		f.fee = total - spent
+	default:
+		return nil, ErrInsufficientFunds
+	}
+	return f, nil
+}
+
+// txInput is a UTXO with the address key and output script needed to sign its spend
+type txInput struct {
+	outPoint wire.OutPoint
+	prevOut  *wire.TxOut
+
+	// prevTx is the transaction holding the output, nil when the UTXO did not include it
+	prevTx *wire.MsgTx
+
+	key          *hdkeychain.ExtendedKey
+	addt         keys.AddressType
+	addressIndex uint32
+}
+
+// newTxInput derives the key and output script of the address a UTXO pays to
+func (a *Account) newTxInput(u *UTXO) (*txInput, error) {
+	if u.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	hash, err := chainhash.NewHashFromStr(u.TxID)
+	if err != nil {
+		return nil, err
+	}
+
+	vout, err := intToUint32(u.Vout)
+	if err != nil {
+		return nil, err
+	}
+
+	addressIndex, err := intToUint32(u.AddressIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	addt := keys.ExternalAddress
+	if u.IsChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := a.addressKey(addt, u.AddressIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := a.encodeAddress(k)
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := a.addressScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	in := &txInput{
+		outPoint:     *wire.NewOutPoint(hash, vout),
+		prevOut:      wire.NewTxOut(u.Amount, script),
+		key:          k,
+		addt:         addt,
+		addressIndex: addressIndex,
+	}
+
+	if u.PrevTxHex != "" {
+		if in.prevTx, err = parsePrevTx(u.PrevTxHex, in.outPoint, in.prevOut); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// parsePrevTx decodes the transaction holding a UTXO and checks it has the expected output at the outpoint
+func parsePrevTx(txHex string, op wire.OutPoint, prevOut *wire.TxOut) (*wire.MsgTx, error) {
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(0)
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	if tx.TxHash() != op.Hash || int(op.Index) >= len(tx.TxOut) {
+		return nil, ErrPrevTxMismatch
+	}
+
+	out := tx.TxOut[op.Index]
+	if out.Value != prevOut.Value || !bytes.Equal(out.PkScript, prevOut.PkScript) {
+		return nil, ErrPrevTxMismatch
+	}
+	return tx, nil
+}
+
+// addressScript returns the output script paying to an address of the account network
+func (a *Account) addressScript(address string) ([]byte, error) {
+	info, err := DecodeAddressOnNetwork(address, a.net)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(info.ScriptPubKey)
+}
+
+// signTransaction sets the scriptSig or witness of every input, all inputs being of the account script type
+func (a *Account) signTransaction(tx *wire.MsgTx, inputs []*txInput) error {
+	sigHashes := txscript.NewTxSigHashes(tx)
+	prevOuts := make([]*wire.TxOut, len(inputs))
+	for i, in := range inputs {
+		prevOuts[i] = in.prevOut
+	}
+
+	for i, in := range inputs {
+		priv, err := in.key.ECPrivKey()
+		if err != nil {
+			return err
+		}
+
+		txIn := tx.TxIn[i]
+		switch a.scriptType {
+		case keys.ScriptP2PKH:
+			sigScript, err := txscript.SignatureScript(tx, i, in.prevOut.PkScript, txscript.SigHashAll, priv, true)
+			if err != nil {
+				return err
+			}
+			txIn.SignatureScript = sigScript
+		case keys.ScriptP2WPKHInP2SH:
+			redeemScript := p2wpkhScript(priv.PubKey())
+			witness, err := txscript.WitnessSignature(tx, sigHashes, i, in.prevOut.Value, redeemScript, txscript.SigHashAll, priv, true)
+			if err != nil {
+				return err
+			}
+
+			sigScript, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+			if err != nil {
+				return err
+			}
+			txIn.SignatureScript = sigScript
+			txIn.Witness = witness
+		case keys.ScriptP2WPKH:
+			witness, err := txscript.WitnessSignature(tx, sigHashes, i, in.prevOut.Value, in.prevOut.PkScript, txscript.SigHashAll, priv, true)
+			if err != nil {
+				return err
+			}
+			txIn.Witness = witness
+		case keys.ScriptP2TR:
+			outputPriv, err := taproot.TweakPrivateKey(priv, nil)
+			if err != nil {
+				return err
+			}
+
+			hash, err := taproot.KeySpendSigHash(tx, prevOuts, i, taproot.SigHashDefault)
+			if err != nil {
+				return err
+			}
+
+			sig, err := taproot.SignSchnorr(outputPriv, hash, nil)
+			if err != nil {
+				return err
+			}
+			txIn.Witness = wire.TxWitness{sig}
+		default:
+			return ErrUnsupportedScriptType
+		}
+	}
+	return nil
+}
+
+// p2wpkhScript returns the P2WPKH output script of a public key, the redeem script of P2SH-P2WPKH addresses
+func p2wpkhScript(pub *btcec.PublicKey) []byte {
+	return append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(pub.SerializeCompressed())...)
+}
+
+// estimateWeight returns the weight of a transaction spending inputs of scriptType to outputs,
+// assuming the largest (72 byte) ECDSA signatures
+func estimateWeight(scriptType keys.ScriptType, inputs int, outputs []*wire.TxOut) (int64, error) {
+	var e txsize.Estimator
+	for i := 0; i < inputs; i++ {
+		if err := e.AddInput(scriptType); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, out := range outputs {
+		e.AddOutputScript(out.PkScript)
+	}
+	return e.Weight(), nil
+}
+
+// blockchainWeight returns the weight of a serialized transaction (BIP141)
+func blockchainWeight(tx *wire.MsgTx) int {
+	return tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+}
@@ -0,0 +1,176 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/sanscentral/sanswallet/taproot"
+)
+
+const (
+	testFundingTxID = "a1075db55d416d3ca199f55b6084e2115b9345e16c5cf302fc80e9d5fbf5d48d"
+	testFeeRate     = 5
+)
+
+func TestBuildTransaction(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, purpose := range []int{44, 49, 84, 86} {
+		account, err := NewAccountFromSeed(seed, purpose, 0, testIsTestnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		utxos := []*UTXO{
+			{TxID: testFundingTxID, Vout: 0, Amount: 60000, AddressIndex: 0},
+			{TxID: testFundingTxID, Vout: 3, Amount: 45000, AddressIndex: 2, IsChange: true},
+		}
+		recipients := []*Recipient{{testP2PK1, 30000}, {testP2WPKH10, 40000}}
+
+		signed, err := account.BuildTransaction(utxos, recipients, testFeeRate, 4)
+		if err != nil {
+			t.Errorf("purpose %d transaction failed: %s", purpose, err.Error())
+			continue
+		}
+
+		raw, _ := hex.DecodeString(signed.Hex)
+		tx := wire.NewMsgTx(0)
+		if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+			t.Fatal(err)
+		}
+
+		if tx.TxHash().String() != signed.TxID || len(tx.TxIn) != 2 || len(tx.TxOut) != 3 {
+			t.Errorf("purpose %d transaction %s has %d inputs and %d outputs", purpose, signed.TxID, len(tx.TxIn), len(tx.TxOut))
+			continue
+		}
+
+		if signed.ChangeIndex != 4 || tx.TxOut[2].Value != signed.ChangeAmount {
+			t.Errorf("purpose %d change is %d to index %d", purpose, signed.ChangeAmount, signed.ChangeIndex)
+		}
+
+		change, _ := account.ChangeAddress(4)
+		changeScript, _ := account.addressScript(change)
+		if !bytes.Equal(tx.TxOut[2].PkScript, changeScript) {
+			t.Errorf("purpose %d change does not pay to change address %s", purpose, change)
+		}
+
+		if signed.Fee+signed.ChangeAmount+70000 != 105000 {
+			t.Errorf("purpose %d amounts do not balance, fee %d change %d", purpose, signed.Fee, signed.ChangeAmount)
+		}
+
+		// The estimate assumes the largest signatures so the paid rate is at least the requested one
+		if signed.Fee < testFeeRate*signed.VSize || signed.Fee > testFeeRate*(signed.VSize+4) {
+			t.Errorf("purpose %d fee %d is not %d sat/vB of %d vbytes", purpose, signed.Fee, testFeeRate, signed.VSize)
+		}
+
+		prevOuts := make([]*wire.TxOut, len(utxos))
+		for i, u := range utxos {
+			in, err := account.newTxInput(u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prevOuts[i] = in.prevOut
+		}
+
+		for i := range tx.TxIn {
+			if err := verifyInput(tx, prevOuts, i); err != nil {
+				t.Errorf("purpose %d input %d does not verify: %s", purpose, i, err.Error())
+			}
+		}
+	}
+}
+
+// verifyInput runs the script of input i, checking taproot key path spends against BIP341
+func verifyInput(tx *wire.MsgTx, prevOuts []*wire.TxOut, i int) error {
+	script := prevOuts[i].PkScript
+	if len(script) == 34 && script[0] == txscript.OP_1 {
+		hash, err := taproot.KeySpendSigHash(tx, prevOuts, i, taproot.SigHashDefault)
+		if err != nil {
+			return err
+		}
+		return taproot.VerifySchnorr(script[2:], hash, tx.TxIn[i].Witness[0])
+	}
+
+	vm, err := txscript.NewEngine(script, tx, i, txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(tx), prevOuts[i].Value)
+	if err != nil {
+		return err
+	}
+	return vm.Execute()
+}
+
+func TestBuildTransactionChange(t *testing.T) {
+	account, err := NewAccountFromExtendedKey(testP2WPKHPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	utxos := []*UTXO{{TxID: testFundingTxID, Vout: 1, Amount: 10000}}
+
+	// Change below the dust threshold is left to the fee
+	signed, err := account.BuildTransaction(utxos, []*Recipient{{testP2WPKH1, 9500}}, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signed.ChangeIndex != -1 || signed.ChangeAmount != 0 || signed.Fee != 500 {
+		t.Errorf("dust change was not left to the fee, change %d fee %d", signed.ChangeAmount, signed.Fee)
+	}
+
+	if _, err := account.BuildTransaction(utxos, []*Recipient{{testP2WPKH1, 9900}}, 2, 0); err != ErrInsufficientFunds {
+		t.Error("insufficient funds did not fail where expected")
+	}
+
+	if _, err := account.BuildTransaction(utxos, []*Recipient{{testP2WPKH1, 200}}, 2, 0); err != ErrDustOutput {
+		t.Error("dust recipient did not fail where expected")
+	}
+
+	if _, err := account.BuildTransaction(utxos, []*Recipient{{testTestnetP2WPKH1, 5000}}, 2, 0); err != ErrWrongNetwork {
+		t.Error("testnet recipient did not fail where expected")
+	}
+
+	if _, err := account.BuildTransaction(nil, []*Recipient{{testP2WPKH1, 5000}}, 2, 0); err != ErrNoInputs {
+		t.Error("transaction without inputs did not fail where expected")
+	}
+
+	if _, err := account.BuildTransaction(utxos, nil, 2, 0); err != ErrNoRecipients {
+		t.Error("transaction without recipients did not fail where expected")
+	}
+
+	if _, err := account.BuildTransaction(utxos, []*Recipient{{testP2WPKH1, 5000}}, -1, 0); err != ErrInvalidFeeRate {
+		t.Error("negative fee rate did not fail where expected")
+	}
+
+	watchOnly, err := NewAccountFromExtendedKey(testP2WPKHPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := watchOnly.BuildTransaction(utxos, []*Recipient{{testP2WPKH1, 5000}}, 2, 0); err == nil {
+		t.Error("watch-only account signed where failure was expected")
+	}
+}
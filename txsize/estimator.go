@@ -0,0 +1,105 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package txsize
+
+import (
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// Estimator accumulates the inputs and outputs of a transaction to estimate its weight and fee
+type Estimator struct {
+	inputs  []input
+	outputs []int64
+}
+
+// AddInput adds the input spending a single key output of scriptType (P2PKH, P2SH-P2WPKH, P2WPKH or P2TR key path)
+func (e *Estimator) AddInput(scriptType network.ScriptType) error {
+	in, err := singleSigInput(scriptType)
+	if err != nil {
+		return err
+	}
+
+	e.inputs = append(e.inputs, in)
+	return nil
+}
+
+// AddMultisigInput adds the input spending an m-of-n multisig output of scriptType
+// (ScriptP2PKH for legacy P2SH, ScriptP2WSHInP2SH or ScriptP2WSH)
+func (e *Estimator) AddMultisigInput(scriptType network.ScriptType, m int, n int) error {
+	in, err := multisigInput(scriptType, m, n)
+	if err != nil {
+		return err
+	}
+
+	e.inputs = append(e.inputs, in)
+	return nil
+}
+
+// AddOutput adds an output of outputType
+func (e *Estimator) AddOutput(outputType OutputType) error {
+	size, err := OutputSize(outputType)
+	if err != nil {
+		return err
+	}
+
+	e.outputs = append(e.outputs, size)
+	return nil
+}
+
+// AddOutputScript adds an output paying to script
+func (e *Estimator) AddOutputScript(script []byte) {
+	e.outputs = append(e.outputs, outputSize(len(script)))
+}
+
+// Weight returns the weight of the transaction. Once any input has witness data the others
+// hold an empty witness, one byte each
+func (e *Estimator) Weight() int64 {
+	weight := int64(txBaseSize+wire.VarIntSerializeSize(uint64(len(e.inputs)))+wire.VarIntSerializeSize(uint64(len(e.outputs)))) * 4
+	for _, size := range e.outputs {
+		weight += size * 4
+	}
+
+	var witness bool
+	var emptyWitnesses int64
+	for _, in := range e.inputs {
+		weight += in.weight()
+		if in.witnessSize != 0 {
+			witness = true
+		} else {
+			emptyWitnesses++
+		}
+	}
+
+	if witness {
+		weight += segwitMarkerWeight + emptyWitnesses
+	}
+	return weight
+}
+
+// VSize returns the virtual size of the transaction
+func (e *Estimator) VSize() int64 {
+	return VSize(e.Weight())
+}
+
+// Fee returns the fee in satoshis of the transaction at feeRate sat/vB
+func (e *Estimator) Fee(feeRate int64) int64 {
+	return Fee(e.Weight(), feeRate)
+}
@@ -0,0 +1,181 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package txsize
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// testInput is an estimator input and the largest input it describes
+type testInput struct {
+	scriptType network.ScriptType
+	m, n       int
+}
+
+// testTxIn returns the input spending scriptType with the largest signatures, m-of-n multisig when n is not 0
+func testTxIn(t *testing.T, in testInput) *wire.TxIn {
+	sig := bytes.Repeat([]byte{0x30}, 72)
+	pub := bytes.Repeat([]byte{0x02}, 33)
+	txIn := wire.NewTxIn(&wire.OutPoint{}, nil, nil)
+
+	push := func(data ...[]byte) []byte {
+		b := txscript.NewScriptBuilder()
+		for _, d := range data {
+			if d == nil {
+				b.AddOp(txscript.OP_0)
+			} else {
+				b.AddData(d)
+			}
+		}
+
+		script, err := b.Script()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return script
+	}
+
+	if in.n != 0 {
+		b := txscript.NewScriptBuilder().AddInt64(int64(in.m))
+		for i := 0; i < in.n; i++ {
+			b.AddData(pub)
+		}
+
+		script, err := b.AddInt64(int64(in.n)).AddOp(txscript.OP_CHECKMULTISIG).Script()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stack := [][]byte{nil}
+		for i := 0; i < in.m; i++ {
+			stack = append(stack, sig)
+		}
+
+		switch in.scriptType {
+		case network.ScriptP2PKH:
+			txIn.SignatureScript = push(append(stack, script)...)
+		case network.ScriptP2WSHInP2SH:
+			txIn.SignatureScript = push(make([]byte, 34))
+			fallthrough
+		default:
+			txIn.Witness = append(wire.TxWitness{{}}, stack[1:]...)
+			txIn.Witness = append(txIn.Witness, script)
+		}
+		return txIn
+	}
+
+	switch in.scriptType {
+	case network.ScriptP2PKH:
+		txIn.SignatureScript = push(sig, pub)
+	case network.ScriptP2WPKHInP2SH:
+		txIn.SignatureScript = push(make([]byte, 22))
+		txIn.Witness = wire.TxWitness{sig, pub}
+	case network.ScriptP2WPKH:
+		txIn.Witness = wire.TxWitness{sig, pub}
+	case network.ScriptP2TR:
+		txIn.Witness = wire.TxWitness{make([]byte, 64)}
+	}
+	return txIn
+}
+
+func TestEstimatorWeight(t *testing.T) {
+	p2pkh := testInput{scriptType: network.ScriptP2PKH}
+	p2sh := testInput{scriptType: network.ScriptP2WPKHInP2SH}
+	p2wpkh := testInput{scriptType: network.ScriptP2WPKH}
+	p2tr := testInput{scriptType: network.ScriptP2TR}
+
+	txs := []struct {
+		inputs  []testInput
+		outputs []OutputType
+	}{
+		{[]testInput{p2pkh}, []OutputType{OutputP2PKH}},
+		{[]testInput{p2pkh, p2pkh, p2pkh}, []OutputType{OutputP2PKH, OutputP2SH}},
+		{[]testInput{p2sh, p2sh}, []OutputType{OutputP2WPKH, OutputP2SH}},
+		{[]testInput{p2wpkh}, []OutputType{OutputP2WPKH, OutputP2TR}},
+		{[]testInput{p2tr, p2tr}, []OutputType{OutputP2TR}},
+		{[]testInput{p2pkh, p2wpkh, p2tr, p2sh}, []OutputType{OutputP2PKH, OutputP2SH, OutputP2WPKH, OutputP2WSH, OutputP2TR}},
+		{[]testInput{{network.ScriptP2PKH, 2, 3}}, []OutputType{OutputP2SH}},
+		{[]testInput{{network.ScriptP2PKH, 15, 15}, p2pkh}, []OutputType{OutputP2SH}},
+		{[]testInput{{network.ScriptP2WSHInP2SH, 2, 3}, p2sh}, []OutputType{OutputP2SH}},
+		{[]testInput{{network.ScriptP2WSH, 1, 1}, {network.ScriptP2WSH, 11, 16}, p2pkh}, []OutputType{OutputP2WSH, OutputP2WPKH}},
+	}
+
+	for i, c := range txs {
+		var e Estimator
+		tx := wire.NewMsgTx(2)
+		for _, in := range c.inputs {
+			var err error
+			if in.n != 0 {
+				err = e.AddMultisigInput(in.scriptType, in.m, in.n)
+			} else {
+				err = e.AddInput(in.scriptType)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx.AddTxIn(testTxIn(t, in))
+		}
+
+		for _, outputType := range c.outputs {
+			if err := e.AddOutput(outputType); err != nil {
+				t.Fatal(err)
+			}
+
+			size, _ := scriptSize(outputType)
+			tx.AddTxOut(wire.NewTxOut(0, make([]byte, size)))
+		}
+
+		weight := int64(tx.SerializeSizeStripped()*3 + tx.SerializeSize())
+		if e.Weight() != weight {
+			t.Errorf("transaction %d estimated weight is %d want %d", i, e.Weight(), weight)
+		}
+
+		if e.VSize() != (weight+3)/4 || e.Fee(7) != e.VSize()*7 {
+			t.Errorf("transaction %d vsize %d or fee %d do not match the weight", i, e.VSize(), e.Fee(7))
+		}
+	}
+}
+
+func TestEstimatorOutputScript(t *testing.T) {
+	var e, expected Estimator
+	e.AddOutputScript(make([]byte, 22))
+	if err := expected.AddOutput(OutputP2WPKH); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Weight() != expected.Weight() {
+		t.Errorf("output script weight is %d want %d", e.Weight(), expected.Weight())
+	}
+
+	// A data carrier output above 252 bytes has a three byte script length
+	e.AddOutputScript(make([]byte, 300))
+	if w := e.Weight() - expected.Weight(); w != (8+3+300)*4 {
+		t.Errorf("large output script weight is %d want %d", w, (8+3+300)*4)
+	}
+
+	if ScriptDustThreshold(make([]byte, 22)) != 546-(34-31)*3 {
+		t.Error("non witness script of P2WPKH size did not use the legacy spend size")
+	}
+}
@@ -0,0 +1,265 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package txsize estimates the weight, virtual size, fee and dust thresholds of transactions before they are signed,
+// assuming compressed keys and the largest (72 byte) ECDSA signatures
+package txsize
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+// Serialized sizes of transaction parts
+const (
+	// txBaseSize is version and locktime
+	txBaseSize = 4 + 4
+
+	// segwitMarkerWeight is the marker and flag bytes of transactions with witness data
+	segwitMarkerWeight = 2
+
+	// inputBaseSize is outpoint and sequence of an input, without its script
+	inputBaseSize = 32 + 4 + 4
+
+	// sigPushSize is the push of a DER signature of at most 72 bytes including the hash type
+	sigPushSize = 1 + 72
+
+	// pubKeyPushSize is the push of a compressed public key
+	pubKeyPushSize = 1 + 33
+
+	// p2pkhScriptSigSize is the signature and public key pushes of a P2PKH spend
+	p2pkhScriptSigSize = sigPushSize + pubKeyPushSize
+
+	// p2wpkhWitnessSize is the item count, signature and compressed key of a P2WPKH witness
+	p2wpkhWitnessSize = 1 + sigPushSize + pubKeyPushSize
+
+	// p2trWitnessSize is the item count and 64 byte Schnorr signature of a key path spend
+	p2trWitnessSize = 1 + 1 + 64
+
+	// Output script sizes
+	p2pkhScriptSize  = 25
+	p2shScriptSize   = 23
+	p2wpkhScriptSize = 22
+	p2wshScriptSize  = 34
+	p2trScriptSize   = 34
+
+	// maxMultisigKeys is the largest key count encoded by the OP_1 to OP_16 opcodes of standard multisig scripts
+	maxMultisigKeys = 16
+
+	// maxRedeemScriptSize is the largest P2SH redeem script, the limit of a pushed element
+	maxRedeemScriptSize = 520
+
+	// dustRelayFeeRate is the sat/vB rate of the default relay policy dust threshold
+	dustRelayFeeRate = 3
+)
+
+// OutputType is the kind of script an output pays to
+type OutputType int
+
+// Output types
+const (
+	OutputP2PKH OutputType = iota
+	OutputP2SH
+	OutputP2WPKH
+	OutputP2WSH
+	OutputP2TR
+)
+
+var (
+	// ErrUnsupportedScriptType is returned for script types without a known input size
+	ErrUnsupportedScriptType = errors.New("Script type input size is not known")
+
+	// ErrUnknownOutputType is returned for output types that are not defined
+	ErrUnknownOutputType = errors.New("Unknown output type")
+
+	// ErrInvalidMultisig is returned for m-of-n multisig with m or n out of range, or a redeem script too large for P2SH
+	ErrInvalidMultisig = errors.New("Invalid multisig threshold or key count")
+)
+
+// String returns the name of the output type
+func (t OutputType) String() string {
+	switch t {
+	case OutputP2PKH:
+		return "P2PKH"
+	case OutputP2SH:
+		return "P2SH"
+	case OutputP2WPKH:
+		return "P2WPKH"
+	case OutputP2WSH:
+		return "P2WSH"
+	case OutputP2TR:
+		return "P2TR"
+	}
+	return "Unknown"
+}
+
+// input is the serialized size of an input without and with its witness
+type input struct {
+	scriptSigSize int
+	witnessSize   int
+}
+
+// weight returns the weight of the input, witness bytes counting once
+func (in input) weight() int64 {
+	return int64((inputBaseSize+wire.VarIntSerializeSize(uint64(in.scriptSigSize))+in.scriptSigSize)*4 + in.witnessSize)
+}
+
+// singleSigInput returns the size of the input spending a single key output of scriptType
+func singleSigInput(scriptType network.ScriptType) (input, error) {
+	switch scriptType {
+	case network.ScriptP2PKH:
+		return input{scriptSigSize: p2pkhScriptSigSize}, nil
+	case network.ScriptP2WPKHInP2SH:
+		return input{scriptSigSize: 1 + p2wpkhScriptSize, witnessSize: p2wpkhWitnessSize}, nil
+	case network.ScriptP2WPKH:
+		return input{witnessSize: p2wpkhWitnessSize}, nil
+	case network.ScriptP2TR:
+		return input{witnessSize: p2trWitnessSize}, nil
+	}
+	return input{}, ErrUnsupportedScriptType
+}
+
+// multisigInput returns the size of the input spending an m-of-n multisig output of scriptType,
+// ScriptP2PKH being legacy P2SH multisig
+func multisigInput(scriptType network.ScriptType, m int, n int) (input, error) {
+	if m < 1 || n < m || n > maxMultisigKeys {
+		return input{}, ErrInvalidMultisig
+	}
+
+	// OP_m, the keys, OP_n and OP_CHECKMULTISIG
+	scriptSize := 1 + n*pubKeyPushSize + 1 + 1
+
+	// The dummy element consumed by OP_CHECKMULTISIG and the signatures
+	sigsSize := 1 + m*sigPushSize
+
+	switch scriptType {
+	case network.ScriptP2PKH:
+		if scriptSize > maxRedeemScriptSize {
+			return input{}, ErrInvalidMultisig
+		}
+		return input{scriptSigSize: sigsSize + pushSize(scriptSize)}, nil
+	case network.ScriptP2WSHInP2SH, network.ScriptP2WSH:
+		in := input{witnessSize: wire.VarIntSerializeSize(uint64(m+2)) + sigsSize +
+			wire.VarIntSerializeSize(uint64(scriptSize)) + scriptSize}
+		if scriptType == network.ScriptP2WSHInP2SH {
+			in.scriptSigSize = 1 + p2wshScriptSize
+		}
+		return in, nil
+	}
+	return input{}, ErrUnsupportedScriptType
+}
+
+// pushSize returns the size of a script push of size bytes, with its OP_PUSHDATA opcode and length
+func pushSize(size int) int {
+	switch {
+	case size < txscript.OP_PUSHDATA1:
+		return 1 + size
+	case size <= 0xff:
+		return 2 + size
+	}
+	return 3 + size
+}
+
+// InputWeight returns the weight of the input spending a single key output of scriptType
+// (P2PKH, P2SH-P2WPKH, P2WPKH or P2TR key path)
+func InputWeight(scriptType network.ScriptType) (int64, error) {
+	in, err := singleSigInput(scriptType)
+	if err != nil {
+		return 0, err
+	}
+	return in.weight(), nil
+}
+
+// MultisigInputWeight returns the weight of the input spending an m-of-n multisig output of scriptType
+// (ScriptP2PKH for legacy P2SH, ScriptP2WSHInP2SH or ScriptP2WSH)
+func MultisigInputWeight(scriptType network.ScriptType, m int, n int) (int64, error) {
+	in, err := multisigInput(scriptType, m, n)
+	if err != nil {
+		return 0, err
+	}
+	return in.weight(), nil
+}
+
+// OutputSize returns the serialized size of an output of outputType, amount and script included
+func OutputSize(outputType OutputType) (int64, error) {
+	size, err := scriptSize(outputType)
+	if err != nil {
+		return 0, err
+	}
+	return outputSize(size), nil
+}
+
+// scriptSize returns the size of the output script of outputType
+func scriptSize(outputType OutputType) (int, error) {
+	switch outputType {
+	case OutputP2PKH:
+		return p2pkhScriptSize, nil
+	case OutputP2SH:
+		return p2shScriptSize, nil
+	case OutputP2WPKH:
+		return p2wpkhScriptSize, nil
+	case OutputP2WSH:
+		return p2wshScriptSize, nil
+	case OutputP2TR:
+		return p2trScriptSize, nil
+	}
+	return 0, ErrUnknownOutputType
+}
+
+// outputSize returns the size of an output with a script of scriptSize bytes
+func outputSize(scriptSize int) int64 {
+	return int64(8 + wire.VarIntSerializeSize(uint64(scriptSize)) + scriptSize)
+}
+
+// DustThreshold returns the smallest amount an output of outputType may hold under the default relay policy
+func DustThreshold(outputType OutputType) (int64, error) {
+	size, err := scriptSize(outputType)
+	if err != nil {
+		return 0, err
+	}
+	return dustThreshold(outputSize(size), outputType == OutputP2WPKH || outputType == OutputP2WSH || outputType == OutputP2TR), nil
+}
+
+// ScriptDustThreshold returns the smallest amount an output of script may hold under the default relay policy
+func ScriptDustThreshold(script []byte) int64 {
+	return dustThreshold(outputSize(len(script)), txscript.IsWitnessProgram(script))
+}
+
+// dustThreshold returns three times the cost in sat/vB of an output and of an input spending it,
+// the input being P2PKH sized with its script counted as witness data for witness programs
+func dustThreshold(outputSize int64, witness bool) int64 {
+	spendSize := int64(inputBaseSize + 1 + p2pkhScriptSigSize)
+	if witness {
+		spendSize = inputBaseSize + 1 + p2pkhScriptSigSize/4
+	}
+	return (outputSize + spendSize) * dustRelayFeeRate
+}
+
+// VSize returns the virtual size of a weight, rounded up
+func VSize(weight int64) int64 {
+	return (weight + 3) / 4
+}
+
+// Fee returns the fee in satoshis of a transaction of weight at feeRate sat/vB
+func Fee(weight int64, feeRate int64) int64 {
+	return VSize(weight) * feeRate
+}
@@ -0,0 +1,134 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package txsize
+
+import (
+	"testing"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+func TestInputWeight(t *testing.T) {
+	weights := map[network.ScriptType]int64{
+		network.ScriptP2PKH:        592,
+		network.ScriptP2WPKHInP2SH: 364,
+		network.ScriptP2WPKH:       272,
+		network.ScriptP2TR:         230,
+	}
+
+	for scriptType, expected := range weights {
+		w, err := InputWeight(scriptType)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if w != expected {
+			t.Errorf("%s input weight is %d want %d", scriptType, w, expected)
+		}
+	}
+
+	if _, err := InputWeight(network.ScriptP2WSH); err != ErrUnsupportedScriptType {
+		t.Error("multisig script type did not fail where expected")
+	}
+}
+
+func TestMultisigInputWeight(t *testing.T) {
+	// 2-of-3: a 105 byte script and two signatures, the legacy scriptSig of 254 bytes having a three byte length
+	weights := map[network.ScriptType]int64{
+		network.ScriptP2PKH:       (40 + 3 + 1 + 146 + 2 + 105) * 4,
+		network.ScriptP2WSHInP2SH: (40+1+35)*4 + 1 + 1 + 146 + 1 + 105,
+		network.ScriptP2WSH:       (40+1)*4 + 1 + 1 + 146 + 1 + 105,
+	}
+
+	for scriptType, expected := range weights {
+		w, err := MultisigInputWeight(scriptType, 2, 3)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if w != expected {
+			t.Errorf("%s 2-of-3 input weight is %d want %d", scriptType, w, expected)
+		}
+	}
+
+	invalid := []struct {
+		scriptType network.ScriptType
+		m, n       int
+	}{
+		{network.ScriptP2WSH, 0, 3},
+		{network.ScriptP2WSH, 3, 2},
+		{network.ScriptP2WSH, 1, 17},
+		{network.ScriptP2PKH, 1, 16},
+	}
+
+	for _, c := range invalid {
+		if _, err := MultisigInputWeight(c.scriptType, c.m, c.n); err != ErrInvalidMultisig {
+			t.Errorf("%s %d-of-%d did not fail where expected", c.scriptType, c.m, c.n)
+		}
+	}
+
+	if _, err := MultisigInputWeight(network.ScriptP2TR, 1, 2); err != ErrUnsupportedScriptType {
+		t.Error("P2TR multisig did not fail where expected")
+	}
+}
+
+func TestOutputSizeAndDust(t *testing.T) {
+	outputs := []struct {
+		outputType OutputType
+		size, dust int64
+	}{
+		{OutputP2PKH, 34, 546},
+		{OutputP2SH, 32, 540},
+		{OutputP2WPKH, 31, 294},
+		{OutputP2WSH, 43, 330},
+		{OutputP2TR, 43, 330},
+	}
+
+	for _, o := range outputs {
+		size, err := OutputSize(o.outputType)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if size != o.size {
+			t.Errorf("%s output size is %d want %d", o.outputType, size, o.size)
+		}
+
+		dust, err := DustThreshold(o.outputType)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if dust != o.dust {
+			t.Errorf("%s dust threshold is %d want %d", o.outputType, dust, o.dust)
+		}
+	}
+
+	if _, err := OutputSize(OutputType(-1)); err != ErrUnknownOutputType {
+		t.Error("unknown output type did not fail where expected")
+	}
+
+	if v := VSize(561); v != 141 {
+		t.Errorf("vsize of weight 561 is %d want 141", v)
+	}
+
+	if f := Fee(561, 3); f != 423 {
+		t.Errorf("fee of weight 561 is %d want 423", f)
+	}
+}
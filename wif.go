@@ -0,0 +1,158 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+
+	"github.com/sanscentral/sanswallet/keys"
+	"github.com/sanscentral/sanswallet/network"
+)
+
+var (
+	// ErrWIFNetwork is returned when a WIF private key is imported for a network other than the one it was encoded for
+	ErrWIFNetwork = errors.New("WIF private key is for a different network")
+
+	// ErrUncompressedSegwit is returned for segwit addresses of an uncompressed key, which are not spendable
+	ErrUncompressedSegwit = errors.New("Segwit addresses require a compressed public key")
+)
+
+// GetWIFForIndex returns the WIF private key of the address at given index for an account extended private key
+func GetWIFForIndex(accountKey string, addressIndex int, isChange bool, compressed bool, testnet bool) (string, error) {
+	return GetWIFForIndexOnNetwork(accountKey, addressIndex, isChange, compressed, testnetToNetwork(testnet))
+}
+
+// GetWIFForIndexOnNetwork returns the WIF private key of the address at given index for an account extended private key on net.
+// Any single-sig SLIP-132 private key (xprv/yprv/zprv, tprv/uprv/vprv, ...) of net is accepted
+func GetWIFForIndexOnNetwork(accountKey string, addressIndex int, isChange bool, compressed bool, net network.Network) (string, error) {
+	v, _, err := keys.GetExtendedKeyInfo(accountKey)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkAccountKeyVersion(accountKey, v.ScriptType, net); err != nil {
+		return "", err
+	}
+
+	index, err := intToUint32(addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := keys.GetAccountAddressKey(accountKey, addt, index)
+	if err != nil {
+		return "", err
+	}
+
+	return keys.GetWIF(k, net, compressed)
+}
+
+// AddressWIF returns the WIF private key of the address at addressIndex, the account must hold the extended private key
+func (a *Account) AddressWIF(addressIndex int, isChange bool, compressed bool) (string, error) {
+	addt := keys.ExternalAddress
+	if isChange {
+		addt = keys.ChangeAddress
+	}
+
+	k, err := a.addressKey(addt, addressIndex)
+	if err != nil {
+		return "", err
+	}
+
+	return keys.GetWIF(k, a.net, compressed)
+}
+
+// ImportedKey is a standalone private key imported from WIF, outside of any HD account
+type ImportedKey struct {
+	wif      *btcutil.WIF
+	netParam *chaincfg.Params
+}
+
+// NewImportedKeyFromWIF returns a standalone key for a mainnet or testnet WIF private key
+func NewImportedKeyFromWIF(wif string, testnet bool) (*ImportedKey, error) {
+	return NewImportedKeyFromWIFOnNetwork(wif, testnetToNetwork(testnet))
+}
+
+// NewImportedKeyFromWIFOnNetwork returns a standalone key for a WIF private key of net
+func NewImportedKeyFromWIFOnNetwork(wif string, net network.Network) (*ImportedKey, error) {
+	w, err := btcutil.DecodeWIF(wif)
+	if err != nil {
+		return nil, err
+	}
+
+	netParam, err := keys.GetChainParams(net)
+	if err != nil {
+		return nil, err
+	}
+
+	if !w.IsForNet(netParam) {
+		return nil, ErrWIFNetwork
+	}
+
+	return &ImportedKey{wif: w, netParam: netParam}, nil
+}
+
+// WIF returns the private key in Wallet Import Format
+func (k *ImportedKey) WIF() string {
+	return k.wif.String()
+}
+
+// IsCompressed returns true if the key addresses are for the compressed public key
+func (k *ImportedKey) IsCompressed() bool {
+	return k.wif.CompressPubKey
+}
+
+// PublicKey returns the hex encoded public key, compressed or uncompressed as the WIF specifies
+func (k *ImportedKey) PublicKey() string {
+	return hex.EncodeToString(k.wif.SerializePubKey())
+}
+
+// P2PKHAddress returns the P2PKH ('1' prefixed on mainnet) address of the key
+func (k *ImportedKey) P2PKHAddress() (string, error) {
+	a, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(k.wif.SerializePubKey()), k.netParam)
+	if err != nil {
+		return "", err
+	}
+	return a.EncodeAddress(), nil
+}
+
+// P2SHAddress returns the P2WPKH-in-P2SH ('3' prefixed on mainnet) address of the key
+func (k *ImportedKey) P2SHAddress() (string, error) {
+	if !k.wif.CompressPubKey {
+		return "", ErrUncompressedSegwit
+	}
+	return getP2SHAddressForKeyHash(btcutil.Hash160(k.wif.SerializePubKey()), k.netParam)
+}
+
+// P2WPKHAddress returns the segwit bech32 address of the key
+func (k *ImportedKey) P2WPKHAddress() (string, error) {
+	if !k.wif.CompressPubKey {
+		return "", ErrUncompressedSegwit
+	}
+	return getP2WPKHAddressForKeyHash(btcutil.Hash160(k.wif.SerializePubKey()), k.netParam)
+}
@@ -0,0 +1,162 @@
+/*
+	SansWallet is a BIP32, BIP44, BIP49 and BIP84 compatible hierarchical determinstic wallet
+	Copyright (C) 2018  Sans Central
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU Affero General Public License as
+	published by the Free Software Foundation, either version 3 of the
+	License, or (at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU Affero General Public License for more details.
+
+	You should have received a copy of the GNU Affero General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package sanswallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sanscentral/sanswallet/network"
+)
+
+const (
+	// BIP84 vector ref: https://github.com/bitcoin/bips/blob/master/bip-0084.mediawiki#test-vectors
+	// Private key of m/84'/0'/0'/0/0 (testP2WPKH0, public key testP2WPKH0PubKey)
+	testP2WPKH0WIF = "KyZpNDKnfs94vbrwhJneDi77V6jF64PWPF8x5cdJb8ifgg2DUc9d"
+
+	// Private key 1 (the generator point) in all WIF forms and its addresses
+	testKeyOneWIF             = "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn"
+	testKeyOneUncompressedWIF = "5HpHagT65TZzG1PH3CSu63k8DbpvD8s5ip4nEB3kEsreAnchuDf"
+	testKeyOneTestnetWIF      = "cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA"
+	testKeyOneP2PKH           = "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"
+	testKeyOneUncompressed    = "1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm"
+	testKeyOneP2SH            = "3JvL6Ymt8MVWiCNHC7oWU6nLeHNJKLZGLN"
+	testKeyOneP2WPKH          = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+)
+
+func TestWIFExport(t *testing.T) {
+	wif, err := GetWIFForIndex(testP2WPKHPriv, 0, testIsChangeAddress, true, testIsTestnet)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if wif != testP2WPKH0WIF {
+		t.Errorf("WIF is not expected value want %s got %s", testP2WPKH0WIF, wif)
+	}
+
+	seed, err := hex.DecodeString(testSeedHex)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	account, err := NewAccountFromSeed(seed, 84, 0, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wif, _ := account.AddressWIF(0, testIsChangeAddress, true); wif != testP2WPKH0WIF {
+		t.Errorf("account WIF is not expected value want %s got %s", testP2WPKH0WIF, wif)
+	}
+
+	// The uncompressed form holds the same private key
+	uncompressed, err := GetWIFForIndex(testP2WPKHPriv, 0, testIsChangeAddress, false, testIsTestnet)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if uncompressed == wif || uncompressed[0] != '5' {
+		t.Errorf("uncompressed WIF is not expected value, got %s", uncompressed)
+	}
+
+	if _, err := GetWIFForIndex(testP2WPKHPub, 0, testIsChangeAddress, true, testIsTestnet); err == nil {
+		t.Error("public key did not fail for WIF export where expected")
+	}
+
+	if _, err := GetWIFForIndex(testP2WPKHPriv, 0, testIsChangeAddress, true, true); err == nil {
+		t.Error("mainnet key did not fail for testnet WIF export where expected")
+	}
+
+	testnetWIF, err := GetWIFForIndex(testTestnetP2WPKHPriv, 0, testIsChangeAddress, true, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	key, err := NewImportedKeyFromWIF(testnetWIF, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if address, _ := key.P2WPKHAddress(); address != testTestnetP2WPKH0 {
+		t.Errorf("testnet WIF address is not expected value want %s got %s", testTestnetP2WPKH0, address)
+	}
+}
+
+func TestWIFImport(t *testing.T) {
+	key, err := NewImportedKeyFromWIF(testP2WPKH0WIF, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.PublicKey() != testP2WPKH0PubKey || !key.IsCompressed() || key.WIF() != testP2WPKH0WIF {
+		t.Errorf("imported public key is not expected value, got %s", key.PublicKey())
+	}
+
+	if address, _ := key.P2WPKHAddress(); address != testP2WPKH0 {
+		t.Errorf("imported P2WPKH address is not expected value want %s got %s", testP2WPKH0, address)
+	}
+
+	addresses := []struct {
+		wif      string
+		address  func(k *ImportedKey) (string, error)
+		expected string
+	}{
+		{testKeyOneWIF, (*ImportedKey).P2PKHAddress, testKeyOneP2PKH},
+		{testKeyOneWIF, (*ImportedKey).P2SHAddress, testKeyOneP2SH},
+		{testKeyOneWIF, (*ImportedKey).P2WPKHAddress, testKeyOneP2WPKH},
+		{testKeyOneUncompressedWIF, (*ImportedKey).P2PKHAddress, testKeyOneUncompressed},
+	}
+
+	for i, a := range addresses {
+		key, err := NewImportedKeyFromWIF(a.wif, testIsTestnet)
+		if err != nil {
+			t.Error(err.Error())
+			continue
+		}
+
+		address, err := a.address(key)
+		if err != nil {
+			t.Error(err.Error())
+		}
+
+		if address != a.expected {
+			t.Errorf("address %d is not expected value want %s got %s", i, a.expected, address)
+		}
+	}
+
+	uncompressed, err := NewImportedKeyFromWIF(testKeyOneUncompressedWIF, testIsTestnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := uncompressed.P2WPKHAddress(); err != ErrUncompressedSegwit {
+		t.Error("uncompressed key did not fail for segwit address where expected")
+	}
+
+	if _, err := NewImportedKeyFromWIF(testKeyOneTestnetWIF, false); err != ErrWIFNetwork {
+		t.Error("testnet WIF did not fail on mainnet where expected")
+	}
+
+	if _, err := NewImportedKeyFromWIFOnNetwork(testKeyOneTestnetWIF, network.BTCRegtest); err != nil {
+		t.Error("testnet WIF did not import on regtest")
+	}
+
+	if _, err := NewImportedKeyFromWIF("KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWm", testIsTestnet); err == nil {
+		t.Error("WIF with invalid checksum did not fail where expected")
+	}
+}